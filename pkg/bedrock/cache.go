@@ -0,0 +1,101 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/firebase/genkit/go/ai"
+)
+
+// CachePoint is the metadata key that marks a prompt cache checkpoint. Set it
+// to true on a part, message or tool definition to cache the prompt up to and
+// including that content.
+const CachePoint = "cachePoint"
+
+// Keys of the prompt cache token counts in GenerationUsage.Custom
+const (
+	UsageCacheReadInputTokens  = "cacheReadInputTokens"
+	UsageCacheWriteInputTokens = "cacheWriteInputTokens"
+)
+
+// hasCachePoint reports whether metadata marks a cache checkpoint
+func hasCachePoint(metadata map[string]any) bool {
+	marked, _ := metadata[CachePoint].(bool)
+	return marked
+}
+
+// cachePointAfter reports whether a cache checkpoint follows part i of a
+// message. A checkpoint on the message follows its last part. Reasoning
+// blocks cannot carry a checkpoint, so one that would follow them is dropped.
+func cachePointAfter(msg *ai.Message, i int) bool {
+	if msg.Content[i].IsReasoning() {
+		return false
+	}
+	return hasCachePoint(msg.Content[i].Metadata) ||
+		(i == len(msg.Content)-1 && hasCachePoint(msg.Metadata))
+}
+
+// cacheSystem reports whether a cache checkpoint follows the system prompt,
+// either by config or because a leading system message is marked
+func (m *Model) cacheSystem(messages []*ai.Message) bool {
+	if m.config.CacheSystem {
+		return true
+	}
+
+	for _, msg := range messages {
+		if msg.Role != ai.RoleSystem {
+			break
+		}
+		for i := range msg.Content {
+			if cachePointAfter(msg, i) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// cacheToolAfter reports whether a cache checkpoint follows tool i, either
+// because it is marked or, by config, because it is the last tool. Only
+// Claude caches tool definitions.
+func (m *Model) cacheToolAfter(tools []*ai.ToolDefinition, i int) bool {
+	if !isClaudeModel(m.foundationModel()) {
+		return false
+	}
+	return hasCachePoint(tools[i].Metadata) || (m.config.CacheTools && i == len(tools)-1)
+}
+
+// cacheUsage records prompt cache token counts on a usage, leaving counts
+// the model did not report unset
+func cacheUsage(usage *ai.GenerationUsage, read, write int) *ai.GenerationUsage {
+	if read == 0 && write == 0 {
+		return usage
+	}
+
+	usage.CachedContentTokens = read
+	if usage.Custom == nil {
+		usage.Custom = map[string]float64{}
+	}
+	usage.Custom[UsageCacheReadInputTokens] = float64(read)
+	usage.Custom[UsageCacheWriteInputTokens] = float64(write)
+	return usage
+}
+
+// claudeCacheControl returns Claude's marker for a cache checkpoint
+func claudeCacheControl() map[string]interface{} {
+	return map[string]interface{}{"type": "ephemeral"}
+}
+
+// novaCachePoint returns Nova's cache checkpoint block
+func novaCachePoint() map[string]interface{} {
+	return map[string]interface{}{
+		"cachePoint": map[string]interface{}{"type": "default"},
+	}
+}
+
+// converseCachePoint returns a Converse cache checkpoint
+func converseCachePoint() types.CachePointBlock {
+	return types.CachePointBlock{Type: types.CachePointTypeDefault}
+}
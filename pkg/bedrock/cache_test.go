@@ -0,0 +1,253 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cachedRequest returns a request with a marked context part and a marked
+// tool definition
+func cachedRequest() *ai.ModelRequest {
+	handbook := ai.NewTextPart("<handbook>...</handbook>")
+	handbook.Metadata = map[string]any{CachePoint: true}
+
+	return &ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewSystemTextMessage("Answer from the handbook."),
+			ai.NewUserMessage(handbook, ai.NewTextPart("What is the leave policy?")),
+		},
+		Tools: []*ai.ToolDefinition{
+			{Name: "lookup", Metadata: map[string]any{CachePoint: true}},
+			{Name: "escalate"},
+		},
+	}
+}
+
+func TestModel_convertClaudeRequest_CachePoints(t *testing.T) {
+	model := &Model{
+		modelID: "anthropic.claude-3-5-sonnet-20241022-v2:0",
+		config:  &ModelConfig{MaxTokens: 1024, CacheSystem: true},
+	}
+
+	result, err := model.convertClaudeRequest(cachedRequest())
+	require.NoError(t, err)
+
+	var claudeReq map[string]interface{}
+	require.NoError(t, json.Unmarshal(result, &claudeReq))
+
+	ephemeral := map[string]interface{}{"type": "ephemeral"}
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"type":          "text",
+		"text":          "Answer from the handbook.",
+		"cache_control": ephemeral,
+	}}, claudeReq["system"])
+
+	content := claudeReq["messages"].([]interface{})[0].(map[string]interface{})["content"].([]interface{})
+	assert.Equal(t, ephemeral, content[0].(map[string]interface{})["cache_control"])
+	assert.NotContains(t, content[1], "cache_control")
+
+	tools := claudeReq["tools"].([]interface{})
+	assert.Equal(t, ephemeral, tools[0].(map[string]interface{})["cache_control"])
+	assert.NotContains(t, tools[1], "cache_control")
+
+	t.Run("cached message", func(t *testing.T) {
+		msg := ai.NewUserTextMessage("Hello")
+		msg.Metadata = map[string]any{CachePoint: true}
+
+		content, err := claudeContent(msg, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []map[string]interface{}{
+			{"type": "text", "text": "Hello", "cache_control": claudeCacheControl()},
+		}, content)
+	})
+
+	t.Run("cache tools by config", func(t *testing.T) {
+		model := &Model{modelID: model.modelID, config: &ModelConfig{MaxTokens: 1024, CacheTools: true}}
+		result, err := model.convertClaudeRequest(&ai.ModelRequest{
+			Messages: []*ai.Message{ai.NewUserTextMessage("Hi")},
+			Tools:    []*ai.ToolDefinition{{Name: "lookup"}, {Name: "escalate"}},
+		})
+		require.NoError(t, err)
+
+		var claudeReq map[string]interface{}
+		require.NoError(t, json.Unmarshal(result, &claudeReq))
+		tools := claudeReq["tools"].([]interface{})
+		assert.NotContains(t, tools[0], "cache_control")
+		assert.Equal(t, ephemeral, tools[1].(map[string]interface{})["cache_control"])
+		assert.Equal(t, "Hi", claudeReq["messages"].([]interface{})[0].(map[string]interface{})["content"])
+	})
+}
+
+func TestModel_convertNovaRequest_CachePoints(t *testing.T) {
+	model := &Model{
+		modelID: "amazon.nova-pro-v1:0",
+		config:  &ModelConfig{MaxTokens: 1024, CacheSystem: true},
+	}
+
+	result, err := model.convertNovaRequest(cachedRequest())
+	require.NoError(t, err)
+
+	var novaReq map[string]interface{}
+	require.NoError(t, json.Unmarshal(result, &novaReq))
+
+	cachePoint := map[string]interface{}{"cachePoint": map[string]interface{}{"type": "default"}}
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"text": "Answer from the handbook."},
+		cachePoint,
+	}, novaReq["system"])
+
+	content := novaReq["messages"].([]interface{})[0].(map[string]interface{})["content"].([]interface{})
+	require.Len(t, content, 3)
+	assert.Equal(t, cachePoint, content[1])
+	assert.Equal(t, "What is the leave policy?", content[2].(map[string]interface{})["text"])
+}
+
+func TestModel_convertConverseRequest_CachePoints(t *testing.T) {
+	model := &Model{
+		modelID: "anthropic.claude-3-5-sonnet-20241022-v2:0",
+		config:  &ModelConfig{MaxTokens: 1024, CacheSystem: true},
+	}
+
+	input, err := model.convertConverseRequest(cachedRequest())
+	require.NoError(t, err)
+
+	cachePoint := types.CachePointBlock{Type: types.CachePointTypeDefault}
+	require.Len(t, input.System, 2)
+	assert.Equal(t, &types.SystemContentBlockMemberCachePoint{Value: cachePoint}, input.System[1])
+
+	content := input.Messages[0].Content
+	require.Len(t, content, 3)
+	assert.Equal(t, &types.ContentBlockMemberCachePoint{Value: cachePoint}, content[1])
+
+	require.Len(t, input.ToolConfig.Tools, 3)
+	assert.Equal(t, &types.ToolMemberCachePoint{Value: cachePoint}, input.ToolConfig.Tools[1])
+}
+
+func TestModel_convertConverseRequest_NovaToolCachePoints(t *testing.T) {
+	model := &Model{
+		modelID: "amazon.nova-pro-v1:0",
+		config:  &ModelConfig{MaxTokens: 1024, CacheTools: true},
+	}
+
+	input, err := model.convertConverseRequest(cachedRequest())
+	require.NoError(t, err)
+
+	// Nova caches messages but not tools
+	assert.Len(t, input.Messages[0].Content, 3)
+	require.Len(t, input.ToolConfig.Tools, 2)
+	for _, tool := range input.ToolConfig.Tools {
+		assert.IsType(t, &types.ToolMemberToolSpec{}, tool)
+	}
+}
+
+func TestCachePointAfter_Reasoning(t *testing.T) {
+	reasoning := ai.NewReasoningPart("The user wants the policy.", []byte("sig"))
+	reasoning.Metadata[CachePoint] = true
+	msg := ai.NewModelMessage(ai.NewTextPart("Let me check."), reasoning)
+	msg.Metadata = map[string]any{CachePoint: true}
+
+	assert.False(t, cachePointAfter(msg, 0))
+	assert.False(t, cachePointAfter(msg, 1), "no checkpoint after a reasoning block")
+
+	content, err := claudeContent(msg, nil)
+	require.NoError(t, err)
+	for _, block := range content.([]map[string]interface{}) {
+		assert.NotContains(t, block, "cache_control")
+	}
+
+	blocks, err := convertConverseParts(msg, nil)
+	require.NoError(t, err)
+	require.Len(t, blocks, 2)
+	assert.IsType(t, &types.ContentBlockMemberReasoningContent{}, blocks[1])
+}
+
+func TestCacheUsage(t *testing.T) {
+	tests := []struct {
+		name       string
+		model      *Model
+		body       string
+		wantRead   int
+		wantWrite  int
+		wantCustom bool
+	}{
+		{
+			name:       "claude",
+			model:      &Model{modelID: "anthropic.claude-3-5-sonnet-20241022-v2:0"},
+			body:       `{"content":[{"type":"text","text":"Hi"}],"usage":{"input_tokens":12,"output_tokens":3,"cache_read_input_tokens":2048,"cache_creation_input_tokens":0}}`,
+			wantRead:   2048,
+			wantCustom: true,
+		},
+		{
+			name:       "nova",
+			model:      &Model{modelID: "amazon.nova-pro-v1:0"},
+			body:       `{"output":{"message":{"content":[{"text":"Hi"}]}},"usage":{"inputTokens":12,"outputTokens":3,"cacheReadInputTokenCount":0,"cacheWriteInputTokenCount":1024}}`,
+			wantWrite:  1024,
+			wantCustom: true,
+		},
+		{
+			name:  "no cache",
+			model: &Model{modelID: "anthropic.claude-3-5-sonnet-20241022-v2:0"},
+			body:  `{"content":[{"type":"text","text":"Hi"}],"usage":{"input_tokens":12,"output_tokens":3}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.model.convertResponse([]byte(tt.body))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantRead, result.Usage.CachedContentTokens)
+			if !tt.wantCustom {
+				assert.Nil(t, result.Usage.Custom)
+				return
+			}
+			assert.Equal(t, map[string]float64{
+				UsageCacheReadInputTokens:  float64(tt.wantRead),
+				UsageCacheWriteInputTokens: float64(tt.wantWrite),
+			}, result.Usage.Custom)
+		})
+	}
+
+	t.Run("converse", func(t *testing.T) {
+		result, err := convertConverseResponse(&bedrockruntime.ConverseOutput{
+			Output: &types.ConverseOutputMemberMessage{Value: types.Message{
+				Role:    types.ConversationRoleAssistant,
+				Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: "Hi"}},
+			}},
+			Usage: &types.TokenUsage{
+				InputTokens:           aws.Int32(12),
+				OutputTokens:          aws.Int32(3),
+				CacheReadInputTokens:  aws.Int32(2048),
+				CacheWriteInputTokens: aws.Int32(0),
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2048, result.Usage.CachedContentTokens)
+		assert.Equal(t, 2048.0, result.Usage.Custom[UsageCacheReadInputTokens])
+	})
+
+	t.Run("claude stream", func(t *testing.T) {
+		model := &Model{modelID: "anthropic.claude-3-5-sonnet-20241022-v2:0", config: &ModelConfig{}}
+		events := chunkEvents(
+			`{"type":"message_start","message":{"usage":{"input_tokens":12,"cache_read_input_tokens":0,"cache_creation_input_tokens":4096}}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi"}}`,
+			`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":3}}`,
+		)
+
+		result, err := model.readStream(context.Background(), events, func(context.Context, *ai.ModelResponseChunk) error { return nil })
+		require.NoError(t, err)
+		assert.Equal(t, 4096.0, result.Usage.Custom[UsageCacheWriteInputTokens])
+		assert.Zero(t, result.Usage.CachedContentTokens)
+	})
+}
@@ -6,18 +6,25 @@ package bedrock
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/firebase/genkit/go/ai"
 )
 
 // Client wraps AWS Bedrock runtime client for GenKit integration
 type Client struct {
 	runtime *bedrockruntime.Client
+	agent   retrieveAPI
 	config  *Config
 }
 
@@ -25,40 +32,97 @@ type Client struct {
 func NewClient(ctx context.Context, awsCfg aws.Config, config *Config) (*Client, error) {
 	return &Client{
 		runtime: bedrockruntime.NewFromConfig(awsCfg),
+		agent:   bedrockagentruntime.NewFromConfig(awsCfg),
 		config:  config,
 	}, nil
 }
 
 // Model returns a GenKit-compatible model interface for the given model ID
 func (c *Client) Model(modelID string) *Model {
+	config := c.config.ModelConfig(modelID)
+	if c.config.UseConverse && !config.UseConverse {
+		withConverse := *config
+		withConverse.UseConverse = true
+		config = &withConverse
+	}
+
+	// Malformed IDs are reported by Config.Validate
+	foundation, _ := c.config.ResolveModel(modelID)
+
 	return &Model{
-		client:  c,
-		modelID: modelID,
-		config:  c.config.ModelConfig(modelID),
+		client:      c,
+		modelID:     modelID,
+		foundation:  foundation,
+		config:      config,
+		useConverse: config.UseConverse,
 	}
 }
 
 // Model represents a Bedrock model compatible with GenKit
 type Model struct {
-	client  *Client
-	modelID string
-	config  *ModelConfig
+	client      *Client
+	modelID     string
+	foundation  string
+	config      *ModelConfig
+	useConverse bool
 }
 
 // Generate implements GenKit's generation interface
 func (m *Model) Generate(ctx context.Context, req *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+	m, err := m.forRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Image and video models have their own request shapes and neither
+	// converse, stream nor take a guardrail
+	if (isImageModel(m.foundationModel()) || isReelModel(m.foundationModel())) && m.config.GuardrailID != "" {
+		return nil, fmt.Errorf("guardrails are not supported for model %s", m.modelID)
+	}
+	if isImageModel(m.foundationModel()) {
+		return m.generateImage(ctx, req, cb)
+	}
+	if isReelModel(m.foundationModel()) {
+		return m.generateVideo(ctx, req, cb)
+	}
+
+	// The Converse API handles every family with typed structures
+	if m.useConverse {
+		return m.generateConverse(ctx, req, cb)
+	}
+
+	// InvokeModel bodies are family-specific, so an ARN that does not name
+	// its model needs a base model
+	if m.foundation == "" {
+		if id, err := ParseModelID(m.modelID); err == nil && id.Foundation == "" {
+			return nil, fmt.Errorf("model %s does not name its foundation model: add it to BaseModels or set UseConverse", m.modelID)
+		}
+	}
+
 	// Convert GenKit request to Bedrock format
 	bedrockReq, err := m.convertRequest(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert request: %w", err)
 	}
 
+	// Stream incrementally when the caller wants chunks
+	if cb != nil {
+		return m.generateStream(ctx, bedrockReq, cb)
+	}
+
 	// Call Bedrock
-	result, err := m.client.runtime.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
+	input := &bedrockruntime.InvokeModelInput{
 		ModelId:     aws.String(m.modelID),
 		ContentType: aws.String("application/json"),
 		Body:        bedrockReq,
-	})
+	}
+	if m.config.GuardrailID != "" {
+		input.GuardrailIdentifier = aws.String(m.config.GuardrailID)
+		input.GuardrailVersion = aws.String(m.config.GuardrailVersion)
+		input.Trace = m.guardrailInvokeTrace()
+	}
+
+	result, err := m.client.runtime.InvokeModel(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("bedrock invoke failed: %w", err)
 	}
@@ -69,43 +133,142 @@ func (m *Model) Generate(ctx context.Context, req *ai.ModelRequest, cb ai.ModelS
 		return nil, fmt.Errorf("failed to convert response: %w", err)
 	}
 
-	// Call streaming callback if provided
-	if cb != nil && response.Message != nil && len(response.Message.Content) > 0 {
-		chunk := &ai.ModelResponseChunk{
-			Content: []*ai.Part{{Text: response.Message.Content[0].Text}},
-		}
-		if err := cb(ctx, chunk); err != nil {
-			return nil, fmt.Errorf("callback failed: %w", err)
-		}
+	// Families whose body has no token counts get them from the headers
+	if raw, ok := awsmiddleware.GetRawResponse(result.ResultMetadata).(*smithyhttp.Response); ok {
+		headerUsage(response.Usage, raw.Header)
 	}
 
 	return response, nil
 }
 
+// forRequest returns a copy of the model whose config has the request's
+// config merged over the plugin-level ModelConfig
+func (m *Model) forRequest(req *ai.ModelRequest) (*Model, error) {
+	config, err := requestConfig(m.config, req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid request config: %w", err)
+	}
+
+	// A configured guardrail is a control on all traffic, so requests may
+	// only change its trace
+	if m.config.GuardrailID != "" &&
+		(config.GuardrailID != m.config.GuardrailID || config.GuardrailVersion != m.config.GuardrailVersion) {
+		return nil, fmt.Errorf("invalid request config: guardrail_id and guardrail_version of model %s cannot be changed per request", m.modelID)
+	}
+
+	model := *m
+	model.config = config
+	if config.UseConverse != m.config.UseConverse {
+		model.useConverse = config.UseConverse
+	}
+	return &model, nil
+}
+
+// Supports reports the GenKit capabilities of this model
+func (m *Model) Supports() *ai.ModelSupports {
+	foundation := m.foundationModel()
+	if isImageModel(foundation) || isReelModel(foundation) {
+		return &ai.ModelSupports{
+			Output: []string{"media"},
+			Media:  true,
+		}
+	}
+
+	tools := m.useConverse || isClaudeModel(foundation) || isNovaModel(foundation) ||
+		isMistralChatModel(foundation) || isCohereModel(foundation)
+
+	// Command R has no way to force or forbid tool use
+	toolChoice := tools && (m.useConverse || !isCohereModel(foundation))
+
+	return &ai.ModelSupports{
+		Output:     []string{"text"},
+		Tools:      tools,
+		ToolChoice: toolChoice,
+		Media:      isVisionModel(foundation),
+		Multiturn:  true,
+		SystemRole: true,
+	}
+}
+
 // convertRequest converts GenKit request to Bedrock-specific format
 func (m *Model) convertRequest(req *ai.ModelRequest) ([]byte, error) {
 	// Implementation varies by model family (Claude, Nova, etc.)
 	switch {
-	case isClaudeModel(m.modelID):
+	case isClaudeModel(m.foundationModel()):
 		return m.convertClaudeRequest(req)
-	case isNovaModel(m.modelID):
+	case isNovaModel(m.foundationModel()):
 		return m.convertNovaRequest(req)
-	case isLlamaModel(m.modelID):
+	case isLlamaModel(m.foundationModel()):
 		return m.convertLlamaRequest(req)
+	case isMistralModel(m.foundationModel()):
+		return m.convertMistralRequest(req)
+	case isCohereModel(m.foundationModel()):
+		return m.convertCohereRequest(req)
+	case isTitanTextModel(m.foundationModel()):
+		return m.convertTitanRequest(req)
+	case isJambaModel(m.foundationModel()):
+		return m.convertJambaRequest(req)
+	case isDeepSeekModel(m.foundationModel()):
+		return m.convertDeepSeekRequest(req)
 	default:
 		return nil, fmt.Errorf("unsupported model: %s", m.modelID)
 	}
 }
 
-// convertResponse converts Bedrock response to GenKit format
+// convertResponse converts Bedrock response to GenKit format, including any
+// guardrail action and trace
 func (m *Model) convertResponse(body []byte) (*ai.ModelResponse, error) {
+	response, err := m.convertFamilyResponse(body)
+	if err != nil {
+		return nil, err
+	}
+
+	guardrail, err := parseInvokeGuardrail(body)
+	if err != nil {
+		return nil, err
+	}
+	if guardrail != nil {
+		applyGuardrail(response, guardrail.Action, guardrail.trace())
+	}
+
+	return response, nil
+}
+
+// headerUsage fills token counts missing from a usage with those in the
+// InvokeModel response headers
+func headerUsage(usage *ai.GenerationUsage, header http.Header) {
+	if usage == nil || usage.InputTokens != 0 || usage.OutputTokens != 0 {
+		return
+	}
+
+	usage.InputTokens, _ = strconv.Atoi(header.Get("X-Amzn-Bedrock-Input-Token-Count"))
+	usage.OutputTokens, _ = strconv.Atoi(header.Get("X-Amzn-Bedrock-Output-Token-Count"))
+	usage.TotalTokens = usage.InputTokens + usage.OutputTokens
+}
+
+// convertFamilyResponse converts a response body in the model family's format
+func (m *Model) convertFamilyResponse(body []byte) (*ai.ModelResponse, error) {
 	switch {
-	case isClaudeModel(m.modelID):
+	case isClaudeModel(m.foundationModel()):
 		return m.convertClaudeResponse(body)
-	case isNovaModel(m.modelID):
+	case isNovaModel(m.foundationModel()):
 		return m.convertNovaResponse(body)
-	case isLlamaModel(m.modelID):
+	case isLlamaModel(m.foundationModel()):
 		return m.convertLlamaResponse(body)
+	case isMistralModel(m.foundationModel()):
+		return m.convertMistralResponse(body)
+	case isCohereModel(m.foundationModel()):
+		return m.convertCohereResponse(body)
+	case isTitanTextModel(m.foundationModel()):
+		return m.convertTitanResponse(body)
+	case isJambaModel(m.foundationModel()):
+		return m.convertJambaResponse(body)
+	case isDeepSeekModel(m.foundationModel()):
+		return m.convertDeepSeekResponse(body)
 	default:
 		return nil, fmt.Errorf("unsupported model: %s", m.modelID)
 	}
@@ -117,8 +280,18 @@ func (m *Model) convertClaudeRequest(req *ai.ModelRequest) ([]byte, error) {
 		return nil, fmt.Errorf("no messages in request")
 	}
 
+	system, conversation, err := splitSystemMessages(req.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := m.prepareMedia(conversation)
+	if err != nil {
+		return nil, err
+	}
+
 	var messages []map[string]interface{}
-	for _, msg := range req.Messages {
+	for _, msg := range conversation {
 		if len(msg.Content) == 0 {
 			continue
 		}
@@ -127,45 +300,253 @@ func (m *Model) convertClaudeRequest(req *ai.ModelRequest) ([]byte, error) {
 		switch msg.Role {
 		case "model":
 			role = "assistant"
-		case "system":
-			role = "user" // Claude handles system messages differently
 		default:
-			role = "user"
+			role = "user" // Tool results are sent back as user turns
+		}
+
+		content, err := claudeContent(msg, docs)
+		if err != nil {
+			return nil, err
 		}
 
 		messages = append(messages, map[string]interface{}{
 			"role":    role,
-			"content": msg.Content[0].Text,
+			"content": content,
 		})
 	}
 
 	claudeReq := map[string]interface{}{
 		"anthropic_version": "bedrock-2023-05-31",
 		"max_tokens":        m.config.MaxTokens,
-		"temperature":       m.config.Temperature,
 		"messages":          messages,
 	}
 
-	if m.config.TopP > 0 {
-		claudeReq["top_p"] = m.config.TopP
+	if system != "" {
+		if m.cacheSystem(req.Messages) {
+			claudeReq["system"] = []map[string]interface{}{{
+				"type":          "text",
+				"text":          system,
+				"cache_control": claudeCacheControl(),
+			}}
+		} else {
+			claudeReq["system"] = system
+		}
+	}
+
+	// Extended thinking fixes the sampling parameters, so they are only
+	// sent without it
+	if m.config.ThinkingBudget > 0 {
+		thinking, err := claudeThinking(m.config.ThinkingBudget, req.ToolChoice)
+		if err != nil {
+			return nil, err
+		}
+		claudeReq["thinking"] = thinking
+	} else {
+		claudeReq["temperature"] = m.config.Temperature
+
+		if m.config.TopP > 0 {
+			claudeReq["top_p"] = m.config.TopP
+		}
+
+		if m.config.TopK > 0 {
+			claudeReq["top_k"] = m.config.TopK
+		}
 	}
 
 	if len(m.config.StopSequences) > 0 {
 		claudeReq["stop_sequences"] = m.config.StopSequences
 	}
 
+	if len(req.Tools) > 0 {
+		tools := claudeTools(req.Tools)
+		for i, tool := range tools {
+			if m.cacheToolAfter(req.Tools, i) {
+				tool["cache_control"] = claudeCacheControl()
+			}
+		}
+		claudeReq["tools"] = tools
+		claudeReq["tool_choice"] = claudeToolChoice(req.ToolChoice)
+	}
+
 	return json.Marshal(claudeReq)
 }
 
+// claudeContent converts message parts to Claude content, using the plain
+// string form when the message is a single uncached text part
+func claudeContent(msg *ai.Message, docs documents) (interface{}, error) {
+	parts := msg.Content
+	if len(parts) == 1 && parts[0].IsText() && !cachePointAfter(msg, 0) {
+		return parts[0].Text, nil
+	}
+
+	var blocks []map[string]interface{}
+	for i, part := range parts {
+		switch {
+		case part.IsText():
+			blocks = append(blocks, map[string]interface{}{
+				"type": "text",
+				"text": part.Text,
+			})
+		case part.IsToolRequest():
+			blocks = append(blocks, map[string]interface{}{
+				"type":  "tool_use",
+				"id":    part.ToolRequest.Ref,
+				"name":  part.ToolRequest.Name,
+				"input": toolInput(part.ToolRequest.Input),
+			})
+		case docs[part] != nil:
+			block, err := claudeDocumentBlock(docs[part])
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, block)
+		case part.IsMedia():
+			image, _, err := decodeImage(part, claudeMaxImageBytes)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, map[string]interface{}{
+				"type": "image",
+				"source": map[string]interface{}{
+					"type":       "base64",
+					"media_type": image.mimeType,
+					"data":       image.base64(),
+				},
+			})
+		case part.IsReasoning():
+			blocks = append(blocks, claudeThinkingBlock(part))
+		case part.IsToolResponse():
+			output, err := json.Marshal(part.ToolResponse.Output)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal output of tool %s: %w", part.ToolResponse.Name, err)
+			}
+			blocks = append(blocks, map[string]interface{}{
+				"type":        "tool_result",
+				"tool_use_id": part.ToolResponse.Ref,
+				"content":     string(output),
+			})
+		default:
+			return nil, fmt.Errorf("unsupported part kind %d for Claude", part.Kind)
+		}
+
+		if cachePointAfter(msg, i) {
+			blocks[len(blocks)-1]["cache_control"] = claudeCacheControl()
+		}
+	}
+
+	return blocks, nil
+}
+
+// claudeDocumentBlock converts a document to a Claude document block. Claude
+// reads PDFs natively and plain-text formats as text; other formats need the
+// Converse API.
+func claudeDocumentBlock(doc *documentData) (map[string]interface{}, error) {
+	var source map[string]interface{}
+	switch doc.format {
+	case "pdf":
+		source = map[string]interface{}{
+			"type":       "base64",
+			"media_type": doc.mimeType,
+			"data":       doc.base64(),
+		}
+	case "txt", "md", "csv", "html":
+		source = map[string]interface{}{
+			"type":       "text",
+			"media_type": "text/plain",
+			"data":       string(doc.data),
+		}
+	default:
+		return nil, fmt.Errorf("%s documents are not supported by Claude through InvokeModel, enable UseConverse instead", doc.format)
+	}
+
+	return map[string]interface{}{
+		"type":   "document",
+		"title":  doc.name,
+		"source": source,
+	}, nil
+}
+
+// claudeThinkingBlock converts a reasoning part back to the thinking block it
+// came from, since Claude requires earlier thinking to be returned unmodified
+func claudeThinkingBlock(part *ai.Part) map[string]interface{} {
+	if data, ok := part.Metadata["redactedData"].(string); ok {
+		return map[string]interface{}{
+			"type": "redacted_thinking",
+			"data": data,
+		}
+	}
+
+	return map[string]interface{}{
+		"type":      "thinking",
+		"thinking":  part.Text,
+		"signature": reasoningSignature(part),
+	}
+}
+
+// claudeMinThinkingBudget is the smallest thinking budget Claude accepts
+const claudeMinThinkingBudget = 1024
+
+// claudeThinking returns Claude's extended thinking setting for a budget.
+// Thinking models choose whether to call tools, so they cannot be forced to.
+func claudeThinking(budget int, choice ai.ToolChoice) (map[string]interface{}, error) {
+	if budget < claudeMinThinkingBudget {
+		return nil, fmt.Errorf("thinking_budget must be at least %d tokens for Claude", claudeMinThinkingBudget)
+	}
+
+	if choice == ai.ToolChoiceRequired {
+		return nil, fmt.Errorf("tool choice %q cannot be combined with extended thinking", choice)
+	}
+
+	return map[string]interface{}{
+		"type":          "enabled",
+		"budget_tokens": budget,
+	}, nil
+}
+
+// claudeTools converts GenKit tool definitions to Anthropic tool specs
+func claudeTools(tools []*ai.ToolDefinition) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(tools))
+	for _, tool := range tools {
+		result = append(result, map[string]interface{}{
+			"name":         tool.Name,
+			"description":  tool.Description,
+			"input_schema": toolSchema(tool.InputSchema),
+		})
+	}
+	return result
+}
+
+// claudeToolChoice converts a GenKit tool choice to Anthropic's tool_choice
+func claudeToolChoice(choice ai.ToolChoice) map[string]interface{} {
+	switch choice {
+	case ai.ToolChoiceRequired:
+		return map[string]interface{}{"type": "any"}
+	case ai.ToolChoiceNone:
+		return map[string]interface{}{"type": "none"}
+	default:
+		return map[string]interface{}{"type": "auto"}
+	}
+}
+
 // Claude-specific response conversion
 func (m *Model) convertClaudeResponse(body []byte) (*ai.ModelResponse, error) {
 	var claudeResp struct {
 		Content []struct {
-			Text string `json:"text"`
+			Type      string                 `json:"type"`
+			Text      string                 `json:"text"`
+			Thinking  string                 `json:"thinking"`
+			Signature string                 `json:"signature"`
+			Data      string                 `json:"data"`
+			ID        string                 `json:"id"`
+			Name      string                 `json:"name"`
+			Input     map[string]interface{} `json:"input"`
 		} `json:"content"`
-		Usage struct {
-			InputTokens  int `json:"input_tokens"`
-			OutputTokens int `json:"output_tokens"`
+		StopReason string `json:"stop_reason"`
+		Usage      struct {
+			InputTokens              int `json:"input_tokens"`
+			OutputTokens             int `json:"output_tokens"`
+			CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+			CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
 		} `json:"usage"`
 	}
 
@@ -177,19 +558,38 @@ func (m *Model) convertClaudeResponse(body []byte) (*ai.ModelResponse, error) {
 		return nil, fmt.Errorf("no content in Claude response")
 	}
 
+	var content []*ai.Part
+	for _, block := range claudeResp.Content {
+		switch block.Type {
+		case "text", "":
+			content = append(content, ai.NewTextPart(block.Text))
+		case "tool_use":
+			content = append(content, ai.NewToolRequestPart(&ai.ToolRequest{
+				Name:  block.Name,
+				Ref:   block.ID,
+				Input: block.Input,
+			}))
+		case "thinking":
+			content = append(content, ai.NewReasoningPart(block.Thinking, []byte(block.Signature)))
+		case "redacted_thinking":
+			part := ai.NewReasoningPart("", nil)
+			part.Metadata["redactedData"] = block.Data
+			content = append(content, part)
+		}
+	}
+
 	return &ai.ModelResponse{
 		Message: &ai.Message{
-			Role: "model",
-			Content: []*ai.Part{
-				{Text: claudeResp.Content[0].Text},
-			},
+			Role:    "model",
+			Content: content,
 		},
-		Usage: &ai.GenerationUsage{
+		Usage: cacheUsage(&ai.GenerationUsage{
 			InputTokens:  claudeResp.Usage.InputTokens,
 			OutputTokens: claudeResp.Usage.OutputTokens,
 			TotalTokens:  claudeResp.Usage.InputTokens + claudeResp.Usage.OutputTokens,
-		},
-		FinishReason: "stop",
+		}, claudeResp.Usage.CacheReadInputTokens, claudeResp.Usage.CacheCreationInputTokens),
+		FinishReason:  finishReason(claudeResp.StopReason),
+		FinishMessage: claudeResp.StopReason,
 	}, nil
 }
 
@@ -199,8 +599,18 @@ func (m *Model) convertNovaRequest(req *ai.ModelRequest) ([]byte, error) {
 		return nil, fmt.Errorf("no messages in request")
 	}
 
+	system, conversation, err := splitSystemMessages(req.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := m.prepareMedia(conversation)
+	if err != nil {
+		return nil, err
+	}
+
 	var messages []map[string]interface{}
-	for _, msg := range req.Messages {
+	for _, msg := range conversation {
 		if len(msg.Content) == 0 {
 			continue
 		}
@@ -213,11 +623,14 @@ func (m *Model) convertNovaRequest(req *ai.ModelRequest) ([]byte, error) {
 			role = "user"
 		}
 
+		content, err := novaContent(msg, docs)
+		if err != nil {
+			return nil, err
+		}
+
 		messages = append(messages, map[string]interface{}{
-			"role": role,
-			"content": []map[string]interface{}{
-				{"text": msg.Content[0].Text},
-			},
+			"role":    role,
+			"content": content,
 		})
 	}
 
@@ -233,26 +646,163 @@ func (m *Model) convertNovaRequest(req *ai.ModelRequest) ([]byte, error) {
 		novaReq["inferenceConfig"].(map[string]interface{})["topP"] = m.config.TopP
 	}
 
+	if m.config.TopK > 0 {
+		novaReq["inferenceConfig"].(map[string]interface{})["topK"] = m.config.TopK
+	}
+
+	if system != "" {
+		systemBlocks := []map[string]interface{}{
+			{"text": system},
+		}
+		if m.cacheSystem(req.Messages) {
+			systemBlocks = append(systemBlocks, novaCachePoint())
+		}
+		novaReq["system"] = systemBlocks
+	}
+
 	if len(m.config.StopSequences) > 0 {
 		novaReq["inferenceConfig"].(map[string]interface{})["stopSequences"] = m.config.StopSequences
 	}
 
+	if len(req.Tools) > 0 && (req.ToolChoice != ai.ToolChoiceNone || hasToolParts(req.Messages)) {
+		novaReq["toolConfig"] = novaToolConfig(req.Tools, req.ToolChoice)
+	}
+
 	return json.Marshal(novaReq)
 }
 
+// novaContent converts message parts to Nova content blocks
+func novaContent(msg *ai.Message, docs documents) ([]map[string]interface{}, error) {
+	var blocks []map[string]interface{}
+	for i, part := range msg.Content {
+		switch {
+		case part.IsText():
+			blocks = append(blocks, map[string]interface{}{"text": part.Text})
+		case part.IsToolRequest():
+			blocks = append(blocks, map[string]interface{}{
+				"toolUse": map[string]interface{}{
+					"toolUseId": part.ToolRequest.Ref,
+					"name":      part.ToolRequest.Name,
+					"input":     toolInput(part.ToolRequest.Input),
+				},
+			})
+		case docs[part] != nil:
+			doc := docs[part]
+			blocks = append(blocks, map[string]interface{}{
+				"document": map[string]interface{}{
+					"format": doc.format,
+					"name":   doc.name,
+					"source": map[string]interface{}{"bytes": doc.base64()},
+				},
+			})
+		case isVideoPart(part):
+			block, err := novaVideoBlock(part)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, block)
+		case part.IsMedia():
+			image, format, err := decodeImage(part, novaMaxImageBytes)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, map[string]interface{}{
+				"image": map[string]interface{}{
+					"format": format,
+					"source": map[string]interface{}{"bytes": image.base64()},
+				},
+			})
+		case part.IsReasoning():
+			reasoningText := map[string]interface{}{"text": part.Text}
+			if signature := reasoningSignature(part); signature != "" {
+				reasoningText["signature"] = signature
+			}
+			blocks = append(blocks, map[string]interface{}{
+				"reasoningContent": map[string]interface{}{
+					"reasoningText": reasoningText,
+				},
+			})
+		case part.IsToolResponse():
+			blocks = append(blocks, map[string]interface{}{
+				"toolResult": map[string]interface{}{
+					"toolUseId": part.ToolResponse.Ref,
+					"content": []map[string]interface{}{
+						{"json": jsonObject(part.ToolResponse.Output)},
+					},
+				},
+			})
+		default:
+			return nil, fmt.Errorf("unsupported part kind %d for Nova", part.Kind)
+		}
+
+		if cachePointAfter(msg, i) {
+			blocks = append(blocks, novaCachePoint())
+		}
+	}
+	return blocks, nil
+}
+
+// novaToolConfig converts GenKit tool definitions to Nova's toolConfig
+func novaToolConfig(tools []*ai.ToolDefinition, choice ai.ToolChoice) map[string]interface{} {
+	specs := make([]map[string]interface{}, 0, len(tools))
+	for _, tool := range tools {
+		specs = append(specs, map[string]interface{}{
+			"toolSpec": map[string]interface{}{
+				"name":        tool.Name,
+				"description": tool.Description,
+				"inputSchema": map[string]interface{}{
+					"json": toolSchema(tool.InputSchema),
+				},
+			},
+		})
+	}
+
+	config := map[string]interface{}{"tools": specs}
+	switch choice {
+	case ai.ToolChoiceRequired:
+		// With a single tool, requiring a tool means requiring that one
+		if len(tools) == 1 {
+			config["toolChoice"] = map[string]interface{}{"tool": map[string]interface{}{"name": tools[0].Name}}
+		} else {
+			config["toolChoice"] = map[string]interface{}{"any": map[string]interface{}{}}
+		}
+	case ai.ToolChoiceNone:
+		// Nova has no choice that forbids tools; the specs are only sent
+		// because the history uses them
+	default:
+		config["toolChoice"] = map[string]interface{}{"auto": map[string]interface{}{}}
+	}
+
+	return config
+}
+
 // Nova-specific response conversion
 func (m *Model) convertNovaResponse(body []byte) (*ai.ModelResponse, error) {
 	var novaResp struct {
 		Output struct {
 			Message struct {
 				Content []struct {
-					Text string `json:"text"`
+					Text    *string `json:"text"`
+					ToolUse *struct {
+						ToolUseID string                 `json:"toolUseId"`
+						Name      string                 `json:"name"`
+						Input     map[string]interface{} `json:"input"`
+					} `json:"toolUse"`
+					ReasoningContent *struct {
+						ReasoningText struct {
+							Text      string `json:"text"`
+							Signature string `json:"signature"`
+						} `json:"reasoningText"`
+					} `json:"reasoningContent"`
 				} `json:"content"`
 			} `json:"message"`
 		} `json:"output"`
-		Usage struct {
-			InputTokens  int `json:"inputTokens"`
-			OutputTokens int `json:"outputTokens"`
+		StopReason string `json:"stopReason"`
+		Usage      struct {
+			InputTokens               int `json:"inputTokens"`
+			OutputTokens              int `json:"outputTokens"`
+			CacheReadInputTokenCount  int `json:"cacheReadInputTokenCount"`
+			CacheWriteInputTokenCount int `json:"cacheWriteInputTokenCount"`
 		} `json:"usage"`
 	}
 
@@ -264,19 +814,35 @@ func (m *Model) convertNovaResponse(body []byte) (*ai.ModelResponse, error) {
 		return nil, fmt.Errorf("no content in Nova response")
 	}
 
+	var content []*ai.Part
+	for _, block := range novaResp.Output.Message.Content {
+		switch {
+		case block.ToolUse != nil:
+			content = append(content, ai.NewToolRequestPart(&ai.ToolRequest{
+				Name:  block.ToolUse.Name,
+				Ref:   block.ToolUse.ToolUseID,
+				Input: block.ToolUse.Input,
+			}))
+		case block.ReasoningContent != nil:
+			reasoning := block.ReasoningContent.ReasoningText
+			content = append(content, ai.NewReasoningPart(reasoning.Text, []byte(reasoning.Signature)))
+		case block.Text != nil:
+			content = append(content, ai.NewTextPart(*block.Text))
+		}
+	}
+
 	return &ai.ModelResponse{
 		Message: &ai.Message{
-			Role: "model",
-			Content: []*ai.Part{
-				{Text: novaResp.Output.Message.Content[0].Text},
-			},
+			Role:    "model",
+			Content: content,
 		},
-		Usage: &ai.GenerationUsage{
+		Usage: cacheUsage(&ai.GenerationUsage{
 			InputTokens:  novaResp.Usage.InputTokens,
 			OutputTokens: novaResp.Usage.OutputTokens,
 			TotalTokens:  novaResp.Usage.InputTokens + novaResp.Usage.OutputTokens,
-		},
-		FinishReason: "stop",
+		}, novaResp.Usage.CacheReadInputTokenCount, novaResp.Usage.CacheWriteInputTokenCount),
+		FinishReason:  finishReason(novaResp.StopReason),
+		FinishMessage: novaResp.StopReason,
 	}, nil
 }
 
@@ -286,22 +852,25 @@ func (m *Model) convertLlamaRequest(req *ai.ModelRequest) ([]byte, error) {
 		return nil, fmt.Errorf("no messages in request")
 	}
 
-	// Llama uses a simple prompt format
-	var prompt strings.Builder
-	for _, msg := range req.Messages {
-		if len(msg.Content) == 0 {
-			continue
-		}
-		prompt.WriteString(msg.Content[0].Text)
-		prompt.WriteString("\n")
+	if _, err := m.prepareMedia(req.Messages); err != nil {
+		return nil, err
+	}
+
+	prompt, images, err := m.llamaPrompt(req.Messages)
+	if err != nil {
+		return nil, err
 	}
 
 	llamaReq := map[string]interface{}{
-		"prompt":      prompt.String(),
+		"prompt":      prompt,
 		"max_gen_len": m.config.MaxTokens,
 		"temperature": m.config.Temperature,
 	}
 
+	if len(images) > 0 {
+		llamaReq["images"] = images
+	}
+
 	if m.config.TopP > 0 {
 		llamaReq["top_p"] = m.config.TopP
 	}
@@ -315,6 +884,7 @@ func (m *Model) convertLlamaResponse(body []byte) (*ai.ModelResponse, error) {
 		Generation           string `json:"generation"`
 		PromptTokenCount     int    `json:"prompt_token_count"`
 		GenerationTokenCount int    `json:"generation_token_count"`
+		StopReason           string `json:"stop_reason"`
 	}
 
 	if err := json.Unmarshal(body, &llamaResp); err != nil {
@@ -325,7 +895,7 @@ func (m *Model) convertLlamaResponse(body []byte) (*ai.ModelResponse, error) {
 		Message: &ai.Message{
 			Role: "model",
 			Content: []*ai.Part{
-				{Text: llamaResp.Generation},
+				{Text: llamaStopTokens.Replace(llamaResp.Generation)},
 			},
 		},
 		Usage: &ai.GenerationUsage{
@@ -333,10 +903,80 @@ func (m *Model) convertLlamaResponse(body []byte) (*ai.ModelResponse, error) {
 			OutputTokens: llamaResp.GenerationTokenCount,
 			TotalTokens:  llamaResp.PromptTokenCount + llamaResp.GenerationTokenCount,
 		},
-		FinishReason: "stop",
+		FinishReason:  finishReason(llamaResp.StopReason),
+		FinishMessage: llamaResp.StopReason,
 	}, nil
 }
 
+// isTrailingModel reports whether a message at index i of n is a model
+// message ending the conversation. Text prompt formats leave such a message
+// open, without its end-of-turn marker, so the model continues it.
+func isTrailingModel(role ai.Role, i, n int) bool {
+	return role == ai.RoleModel && i == n-1
+}
+
+// splitSystemMessages extracts the leading system messages into a single
+// system prompt and returns the remaining conversation. System messages after
+// the conversation has started cannot be sent through a native system channel.
+func splitSystemMessages(messages []*ai.Message) (string, []*ai.Message, error) {
+	var system []string
+
+	start := 0
+	for ; start < len(messages) && messages[start].Role == ai.RoleSystem; start++ {
+		for _, part := range messages[start].Content {
+			if !part.IsText() {
+				return "", nil, fmt.Errorf("system message at position %d contains a non-text part", start)
+			}
+			if part.Text != "" {
+				system = append(system, part.Text)
+			}
+		}
+	}
+
+	for i := start; i < len(messages); i++ {
+		if messages[i].Role == ai.RoleSystem {
+			return "", nil, fmt.Errorf("system message at position %d must precede the conversation", i)
+		}
+	}
+
+	return strings.Join(system, "\n\n"), messages[start:], nil
+}
+
+// finishReason maps a provider stop reason onto GenKit's finish reasons. The
+// families use overlapping vocabularies, differing mostly in case, so one
+// mapping covers them all.
+func finishReason(stopReason string) ai.FinishReason {
+	switch strings.ToLower(stopReason) {
+	case "end_turn", "stop_sequence", "tool_use", "tool_calls", "stop", "complete", "finish", "finished", "stop_criteria_met":
+		return ai.FinishReasonStop
+	case "max_tokens", "length", "model_context_window_exceeded", "error_limit":
+		return ai.FinishReasonLength
+	case "content_filtered", "guardrail_intervened", "refusal", "error_toxic":
+		return ai.FinishReasonBlocked
+	case "":
+		return ai.FinishReasonUnknown
+	default:
+		return ai.FinishReasonOther
+	}
+}
+
+// reasoningSignature returns the provider signature attached to a reasoning
+// part, which is a byte slice when produced by this package and a base64
+// string once the message has been through JSON
+func reasoningSignature(part *ai.Part) string {
+	switch sig := part.Metadata["signature"].(type) {
+	case []byte:
+		return string(sig)
+	case string:
+		if decoded, err := base64.StdEncoding.DecodeString(sig); err == nil {
+			return string(decoded)
+		}
+		return sig
+	default:
+		return ""
+	}
+}
+
 // Helper functions for model identification
 func isClaudeModel(modelID string) bool {
 	return strings.Contains(strings.ToLower(modelID), "claude") ||
@@ -350,3 +990,26 @@ func isNovaModel(modelID string) bool {
 func isLlamaModel(modelID string) bool {
 	return strings.Contains(strings.ToLower(modelID), "llama")
 }
+
+func isMistralModel(modelID string) bool {
+	return strings.Contains(strings.ToLower(modelID), "mistral")
+}
+
+// isCohereModel matches the Command R chat models; older Cohere text models
+// use a different request format
+func isCohereModel(modelID string) bool {
+	return strings.Contains(strings.ToLower(modelID), "cohere.command-r")
+}
+
+func isTitanTextModel(modelID string) bool {
+	return strings.Contains(strings.ToLower(modelID), "titan-text") ||
+		strings.Contains(strings.ToLower(modelID), "titan-tg1")
+}
+
+func isJambaModel(modelID string) bool {
+	return strings.Contains(strings.ToLower(modelID), "jamba")
+}
+
+func isDeepSeekModel(modelID string) bool {
+	return strings.Contains(strings.ToLower(modelID), "deepseek")
+}
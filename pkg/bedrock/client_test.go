@@ -130,6 +130,31 @@ func TestModelConfig_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "top_p must be between 0.0 and 1.0",
 		},
+		{
+			name: "thinking budget",
+			config: &ModelConfig{
+				MaxTokens:      8192,
+				ThinkingBudget: 4096,
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative thinking budget",
+			config: &ModelConfig{
+				ThinkingBudget: -1,
+			},
+			wantErr: true,
+			errMsg:  "thinking_budget must be non-negative",
+		},
+		{
+			name: "thinking budget exceeds max tokens",
+			config: &ModelConfig{
+				MaxTokens:      2048,
+				ThinkingBudget: 2048,
+			},
+			wantErr: true,
+			errMsg:  "thinking_budget must be less than max_tokens",
+		},
 	}
 
 	for _, tt := range tests {
@@ -214,6 +239,210 @@ func TestConfig_ModelConfig(t *testing.T) {
 	}
 }
 
+func TestRequestConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       any
+		expected     *ModelConfig
+		wantErrorMsg string
+	}{
+		{
+			name:     "no config",
+			config:   nil,
+			expected: &ModelConfig{},
+		},
+		{
+			name: "GenKit common config",
+			config: &ai.GenerationCommonConfig{
+				MaxOutputTokens: 256,
+				Temperature:     0.2,
+				TopP:            0.8,
+				TopK:            40,
+				StopSequences:   []string{"###"},
+			},
+			expected: &ModelConfig{
+				MaxTokens:     256,
+				Temperature:   0.2,
+				TopP:          0.8,
+				TopK:          40,
+				StopSequences: []string{"###"},
+			},
+		},
+		{
+			name:     "Bedrock model config",
+			config:   &ModelConfig{MaxTokens: 128, UseConverse: true},
+			expected: &ModelConfig{MaxTokens: 128, UseConverse: true},
+		},
+		{
+			name:     "Bedrock model config by value",
+			config:   ModelConfig{TopK: 5},
+			expected: &ModelConfig{TopK: 5},
+		},
+		{
+			name: "JSON object with keys from both",
+			config: map[string]any{
+				"maxOutputTokens": 100,
+				"temperature":     0.3,
+				"max_tokens":      200,
+				"top_k":           10,
+			},
+			expected: &ModelConfig{MaxTokens: 200, Temperature: 0.3, TopK: 10},
+		},
+		{
+			name:         "unsupported type",
+			config:       "hot",
+			wantErrorMsg: "unsupported config type string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := requestConfig(&ModelConfig{}, tt.config)
+			if tt.wantErrorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErrorMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, config)
+		})
+	}
+}
+
+func TestModel_forRequest(t *testing.T) {
+	base := &Model{
+		modelID: "anthropic.claude-3-haiku-20240307-v1:0",
+		config: &ModelConfig{
+			MaxTokens:     4096,
+			Temperature:   0.7,
+			TopP:          0.9,
+			StopSequences: []string{"END"},
+		},
+	}
+
+	model, err := base.forRequest(&ai.ModelRequest{
+		Config: &ai.GenerationCommonConfig{Temperature: 0.1, TopK: 20},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, &ModelConfig{
+		MaxTokens:     4096,
+		Temperature:   0.1,
+		TopP:          0.9,
+		TopK:          20,
+		StopSequences: []string{"END"},
+	}, model.config)
+	assert.Equal(t, 0.7, base.config.Temperature, "plugin config must not be modified")
+
+	result, err := model.convertClaudeRequest(&ai.ModelRequest{
+		Messages: []*ai.Message{ai.NewUserTextMessage("Hi")},
+	})
+	require.NoError(t, err)
+	var claudeReq map[string]interface{}
+	require.NoError(t, json.Unmarshal(result, &claudeReq))
+	assert.Equal(t, 0.1, claudeReq["temperature"])
+	assert.Equal(t, float64(20), claudeReq["top_k"])
+
+	t.Run("converse per request", func(t *testing.T) {
+		model, err := base.forRequest(&ai.ModelRequest{Config: &ModelConfig{UseConverse: true}})
+		require.NoError(t, err)
+		assert.True(t, model.useConverse)
+		assert.False(t, base.useConverse)
+	})
+
+	t.Run("invalid override", func(t *testing.T) {
+		_, err := base.forRequest(&ai.ModelRequest{Config: &ai.GenerationCommonConfig{Temperature: 1.5}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid request config")
+	})
+
+	t.Run("thinking budget per request", func(t *testing.T) {
+		model, err := base.forRequest(&ai.ModelRequest{Config: map[string]any{"thinking_budget": 2048}})
+		require.NoError(t, err)
+		assert.Equal(t, 2048, model.config.ThinkingBudget)
+		assert.Zero(t, base.config.ThinkingBudget)
+	})
+
+	t.Run("explicit zero values in JSON", func(t *testing.T) {
+		base := &Model{
+			modelID:     "anthropic.claude-3-haiku-20240307-v1:0",
+			config:      &ModelConfig{Temperature: 0.7, TopK: 40, StopSequences: []string{"END"}, UseConverse: true, CacheSystem: true},
+			useConverse: true,
+		}
+
+		model, err := base.forRequest(&ai.ModelRequest{Config: map[string]any{
+			"temperature":    0,
+			"topK":           0,
+			"stop_sequences": []string{"STOP"},
+			"use_converse":   false,
+			"cache_system":   false,
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, &ModelConfig{StopSequences: []string{"STOP"}}, model.config)
+		assert.False(t, model.useConverse)
+
+		assert.Equal(t, &ModelConfig{Temperature: 0.7, TopK: 40, StopSequences: []string{"END"}, UseConverse: true, CacheSystem: true}, base.config,
+			"plugin config must not be modified")
+	})
+}
+
+func TestModel_convertClaudeRequest_Thinking(t *testing.T) {
+	model := &Model{
+		modelID: "anthropic.claude-3-7-sonnet-20250219-v1:0",
+		config: &ModelConfig{
+			MaxTokens:      8192,
+			Temperature:    0.7,
+			TopP:           0.9,
+			TopK:           40,
+			ThinkingBudget: 4096,
+		},
+	}
+
+	result, err := model.convertClaudeRequest(&ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewUserTextMessage("What is 27 * 453?"),
+			ai.NewModelMessage(
+				ai.NewReasoningPart("27 * 453 = 12231", []byte("sig-1")),
+				ai.NewTextPart("12231"),
+			),
+			ai.NewUserTextMessage("And divided by 3?"),
+		},
+	})
+	require.NoError(t, err)
+
+	var claudeReq map[string]interface{}
+	require.NoError(t, json.Unmarshal(result, &claudeReq))
+	assert.Equal(t, map[string]interface{}{"type": "enabled", "budget_tokens": float64(4096)}, claudeReq["thinking"])
+	assert.NotContains(t, claudeReq, "temperature")
+	assert.NotContains(t, claudeReq, "top_p")
+	assert.NotContains(t, claudeReq, "top_k")
+
+	// Earlier thinking is returned with its signature
+	blocks := claudeReq["messages"].([]interface{})[1].(map[string]interface{})["content"].([]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"type":      "thinking",
+		"thinking":  "27 * 453 = 12231",
+		"signature": "sig-1",
+	}, blocks[0])
+
+	t.Run("budget too small", func(t *testing.T) {
+		small := &Model{modelID: model.modelID, config: &ModelConfig{MaxTokens: 4096, ThinkingBudget: 512}}
+		_, err := small.convertClaudeRequest(&ai.ModelRequest{Messages: []*ai.Message{ai.NewUserTextMessage("Hi")}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "at least 1024")
+	})
+
+	t.Run("forced tool use", func(t *testing.T) {
+		_, err := model.convertClaudeRequest(&ai.ModelRequest{
+			Messages:   []*ai.Message{ai.NewUserTextMessage("Hi")},
+			Tools:      []*ai.ToolDefinition{{Name: "weather"}},
+			ToolChoice: ai.ToolChoiceRequired,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cannot be combined with extended thinking")
+	})
+}
+
 func TestModel_convertClaudeRequest(t *testing.T) {
 	model := &Model{
 		modelID: "anthropic.claude-3-sonnet-20240229-v1:0",
@@ -291,6 +520,613 @@ func TestModel_convertClaudeResponse(t *testing.T) {
 	assert.Equal(t, 18, result.Usage.TotalTokens)
 }
 
+func TestModel_convertClaudeRequest_Tools(t *testing.T) {
+	model := &Model{
+		modelID: "anthropic.claude-3-5-sonnet-20241022-v2:0",
+		config:  &ModelConfig{MaxTokens: 1024},
+	}
+
+	req := &ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewUserTextMessage("What's the weather in Paris?"),
+			ai.NewModelMessage(ai.NewToolRequestPart(&ai.ToolRequest{
+				Name:  "weather",
+				Ref:   "toolu_01",
+				Input: map[string]any{"city": "Paris"},
+			})),
+			ai.NewMessage(ai.RoleTool, nil, ai.NewToolResponsePart(&ai.ToolResponse{
+				Name:   "weather",
+				Ref:    "toolu_01",
+				Output: map[string]any{"forecast": "sunny"},
+			})),
+		},
+		Tools: []*ai.ToolDefinition{
+			{
+				Name:        "weather",
+				Description: "Look up the weather",
+				InputSchema: map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"city": map[string]any{"type": "string"}},
+				},
+			},
+		},
+		ToolChoice: ai.ToolChoiceRequired,
+	}
+
+	result, err := model.convertClaudeRequest(req)
+	require.NoError(t, err)
+
+	var claudeReq map[string]interface{}
+	require.NoError(t, json.Unmarshal(result, &claudeReq))
+
+	tools, ok := claudeReq["tools"].([]interface{})
+	require.True(t, ok)
+	require.Len(t, tools, 1)
+	tool := tools[0].(map[string]interface{})
+	assert.Equal(t, "weather", tool["name"])
+	assert.Equal(t, "Look up the weather", tool["description"])
+	assert.Equal(t, "object", tool["input_schema"].(map[string]interface{})["type"])
+	assert.Equal(t, map[string]interface{}{"type": "any"}, claudeReq["tool_choice"])
+
+	messages := claudeReq["messages"].([]interface{})
+	require.Len(t, messages, 3)
+
+	assistant := messages[1].(map[string]interface{})
+	assert.Equal(t, "assistant", assistant["role"])
+	toolUse := assistant["content"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "tool_use", toolUse["type"])
+	assert.Equal(t, "toolu_01", toolUse["id"])
+	assert.Equal(t, "weather", toolUse["name"])
+	assert.Equal(t, map[string]interface{}{"city": "Paris"}, toolUse["input"])
+
+	user := messages[2].(map[string]interface{})
+	assert.Equal(t, "user", user["role"])
+	toolResult := user["content"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "tool_result", toolResult["type"])
+	assert.Equal(t, "toolu_01", toolResult["tool_use_id"])
+	assert.JSONEq(t, `{"forecast":"sunny"}`, toolResult["content"].(string))
+}
+
+func TestClaudeToolChoice(t *testing.T) {
+	assert.Equal(t, "auto", claudeToolChoice(ai.ToolChoiceAuto)["type"])
+	assert.Equal(t, "auto", claudeToolChoice("")["type"])
+	assert.Equal(t, "any", claudeToolChoice(ai.ToolChoiceRequired)["type"])
+	assert.Equal(t, "none", claudeToolChoice(ai.ToolChoiceNone)["type"])
+}
+
+func TestModel_convertClaudeResponse_ToolUse(t *testing.T) {
+	model := &Model{
+		modelID: "anthropic.claude-3-5-sonnet-20241022-v2:0",
+		config:  &ModelConfig{},
+	}
+
+	body := []byte(`{
+		"content": [
+			{"type": "text", "text": "Let me check."},
+			{"type": "tool_use", "id": "toolu_01", "name": "weather", "input": {"city": "Paris"}}
+		],
+		"stop_reason": "tool_use",
+		"usage": {"input_tokens": 30, "output_tokens": 12}
+	}`)
+
+	result, err := model.convertClaudeResponse(body)
+	require.NoError(t, err)
+
+	require.Len(t, result.Message.Content, 2)
+	assert.Equal(t, "Let me check.", result.Message.Content[0].Text)
+	require.True(t, result.Message.Content[1].IsToolRequest())
+	assert.Equal(t, &ai.ToolRequest{
+		Name:  "weather",
+		Ref:   "toolu_01",
+		Input: map[string]interface{}{"city": "Paris"},
+	}, result.Message.Content[1].ToolRequest)
+}
+
+func TestModel_convertNovaRequest_Tools(t *testing.T) {
+	model := &Model{
+		modelID: "amazon.nova-pro-v1:0",
+		config:  &ModelConfig{MaxTokens: 1024},
+	}
+
+	weather := &ai.ToolDefinition{
+		Name:        "weather",
+		Description: "Look up the weather",
+		InputSchema: map[string]any{"type": "object"},
+	}
+
+	req := &ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewUserTextMessage("What's the weather in Paris?"),
+			ai.NewModelMessage(ai.NewToolRequestPart(&ai.ToolRequest{
+				Name:  "weather",
+				Ref:   "tooluse_1",
+				Input: map[string]any{"city": "Paris"},
+			})),
+			ai.NewMessage(ai.RoleTool, nil, ai.NewToolResponsePart(&ai.ToolResponse{
+				Name:   "weather",
+				Ref:    "tooluse_1",
+				Output: map[string]any{"forecast": "sunny"},
+			})),
+		},
+		Tools: []*ai.ToolDefinition{weather},
+	}
+
+	result, err := model.convertNovaRequest(req)
+	require.NoError(t, err)
+
+	var novaReq map[string]interface{}
+	require.NoError(t, json.Unmarshal(result, &novaReq))
+
+	toolConfig := novaReq["toolConfig"].(map[string]interface{})
+	spec := toolConfig["tools"].([]interface{})[0].(map[string]interface{})["toolSpec"].(map[string]interface{})
+	assert.Equal(t, "weather", spec["name"])
+	assert.Equal(t, "Look up the weather", spec["description"])
+	assert.Equal(t, map[string]interface{}{"json": map[string]interface{}{"type": "object"}}, spec["inputSchema"])
+	assert.Equal(t, map[string]interface{}{"auto": map[string]interface{}{}}, toolConfig["toolChoice"])
+
+	messages := novaReq["messages"].([]interface{})
+	require.Len(t, messages, 3)
+
+	toolUse := messages[1].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})["toolUse"].(map[string]interface{})
+	assert.Equal(t, "tooluse_1", toolUse["toolUseId"])
+	assert.Equal(t, "weather", toolUse["name"])
+	assert.Equal(t, map[string]interface{}{"city": "Paris"}, toolUse["input"])
+
+	user := messages[2].(map[string]interface{})
+	assert.Equal(t, "user", user["role"])
+	toolResult := user["content"].([]interface{})[0].(map[string]interface{})["toolResult"].(map[string]interface{})
+	assert.Equal(t, "tooluse_1", toolResult["toolUseId"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"json": map[string]interface{}{"forecast": "sunny"}},
+	}, toolResult["content"])
+}
+
+func TestNovaToolConfig_ToolChoice(t *testing.T) {
+	one := []*ai.ToolDefinition{{Name: "weather"}}
+	two := []*ai.ToolDefinition{{Name: "weather"}, {Name: "time"}}
+
+	assert.Equal(t, map[string]interface{}{"auto": map[string]interface{}{}},
+		novaToolConfig(two, ai.ToolChoiceAuto)["toolChoice"])
+	assert.Equal(t, map[string]interface{}{"any": map[string]interface{}{}},
+		novaToolConfig(two, ai.ToolChoiceRequired)["toolChoice"])
+	assert.Equal(t, map[string]interface{}{"tool": map[string]interface{}{"name": "weather"}},
+		novaToolConfig(one, ai.ToolChoiceRequired)["toolChoice"])
+	assert.NotContains(t, novaToolConfig(one, ai.ToolChoiceNone), "toolChoice")
+}
+
+func TestModel_convertNovaRequest_ToolChoiceNone(t *testing.T) {
+	model := &Model{modelID: "amazon.nova-pro-v1:0", config: &ModelConfig{MaxTokens: 1024}}
+	tools := []*ai.ToolDefinition{{Name: "weather"}}
+
+	tests := []struct {
+		name       string
+		messages   []*ai.Message
+		wantConfig bool
+	}{
+		{
+			name:     "no tool history",
+			messages: []*ai.Message{ai.NewUserTextMessage("Hi")},
+		},
+		{
+			name: "tool history",
+			messages: []*ai.Message{
+				ai.NewUserTextMessage("What's the weather in Paris?"),
+				ai.NewModelMessage(ai.NewToolRequestPart(&ai.ToolRequest{Name: "weather", Ref: "tooluse_1"})),
+				ai.NewMessage(ai.RoleTool, nil, ai.NewToolResponsePart(&ai.ToolResponse{Name: "weather", Ref: "tooluse_1", Output: "sunny"})),
+			},
+			wantConfig: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := model.convertNovaRequest(&ai.ModelRequest{
+				Messages:   tt.messages,
+				Tools:      tools,
+				ToolChoice: ai.ToolChoiceNone,
+			})
+			require.NoError(t, err)
+
+			var novaReq map[string]interface{}
+			require.NoError(t, json.Unmarshal(result, &novaReq))
+			if !tt.wantConfig {
+				assert.NotContains(t, novaReq, "toolConfig")
+				return
+			}
+			toolConfig := novaReq["toolConfig"].(map[string]interface{})
+			assert.Len(t, toolConfig["tools"], 1)
+			assert.NotContains(t, toolConfig, "toolChoice")
+		})
+	}
+}
+
+func TestModel_convertNovaResponse_ToolUse(t *testing.T) {
+	model := &Model{modelID: "amazon.nova-pro-v1:0", config: &ModelConfig{}}
+
+	body := []byte(`{
+		"output": {"message": {"role": "assistant", "content": [
+			{"text": "Checking the forecast."},
+			{"toolUse": {"toolUseId": "tooluse_1", "name": "weather", "input": {"city": "Paris"}}}
+		]}},
+		"stopReason": "tool_use",
+		"usage": {"inputTokens": 40, "outputTokens": 18}
+	}`)
+
+	result, err := model.convertNovaResponse(body)
+	require.NoError(t, err)
+
+	require.Len(t, result.Message.Content, 2)
+	assert.Equal(t, "Checking the forecast.", result.Message.Content[0].Text)
+	require.True(t, result.Message.Content[1].IsToolRequest())
+	assert.Equal(t, &ai.ToolRequest{
+		Name:  "weather",
+		Ref:   "tooluse_1",
+		Input: map[string]interface{}{"city": "Paris"},
+	}, result.Message.Content[1].ToolRequest)
+	assert.Equal(t, 58, result.Usage.TotalTokens)
+}
+
+func TestSplitSystemMessages(t *testing.T) {
+	tests := []struct {
+		name         string
+		messages     []*ai.Message
+		wantSystem   string
+		wantRest     int
+		wantErrorMsg string
+	}{
+		{
+			name:     "no system message",
+			messages: []*ai.Message{ai.NewUserTextMessage("Hi")},
+			wantRest: 1,
+		},
+		{
+			name: "leading system messages are concatenated",
+			messages: []*ai.Message{
+				ai.NewSystemMessage(ai.NewTextPart("Be terse."), ai.NewTextPart("Answer in French.")),
+				ai.NewSystemTextMessage("Never guess."),
+				ai.NewUserTextMessage("Hi"),
+			},
+			wantSystem: "Be terse.\n\nAnswer in French.\n\nNever guess.",
+			wantRest:   1,
+		},
+		{
+			name: "system message mid-conversation",
+			messages: []*ai.Message{
+				ai.NewUserTextMessage("Hi"),
+				ai.NewSystemTextMessage("Be terse."),
+			},
+			wantErrorMsg: "system message at position 1 must precede the conversation",
+		},
+		{
+			name: "non-text system part",
+			messages: []*ai.Message{
+				ai.NewSystemMessage(ai.NewMediaPart("image/png", "data:image/png;base64,AAAA")),
+			},
+			wantErrorMsg: "contains a non-text part",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			system, rest, err := splitSystemMessages(tt.messages)
+			if tt.wantErrorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErrorMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSystem, system)
+			assert.Len(t, rest, tt.wantRest)
+		})
+	}
+}
+
+func TestModel_convertRequest_SystemPrompt(t *testing.T) {
+	req := &ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewSystemTextMessage("You are a pirate."),
+			ai.NewUserTextMessage("Hello"),
+		},
+	}
+
+	t.Run("claude", func(t *testing.T) {
+		model := &Model{modelID: "anthropic.claude-3-haiku-20240307-v1:0", config: &ModelConfig{}}
+		result, err := model.convertClaudeRequest(req)
+		require.NoError(t, err)
+
+		var claudeReq map[string]interface{}
+		require.NoError(t, json.Unmarshal(result, &claudeReq))
+		assert.Equal(t, "You are a pirate.", claudeReq["system"])
+		messages := claudeReq["messages"].([]interface{})
+		require.Len(t, messages, 1)
+		assert.Equal(t, "user", messages[0].(map[string]interface{})["role"])
+	})
+
+	t.Run("nova", func(t *testing.T) {
+		model := &Model{modelID: "amazon.nova-lite-v1:0", config: &ModelConfig{}}
+		result, err := model.convertNovaRequest(req)
+		require.NoError(t, err)
+
+		var novaReq map[string]interface{}
+		require.NoError(t, json.Unmarshal(result, &novaReq))
+		assert.Equal(t, []interface{}{map[string]interface{}{"text": "You are a pirate."}}, novaReq["system"])
+		assert.Len(t, novaReq["messages"].([]interface{}), 1)
+	})
+
+	t.Run("llama", func(t *testing.T) {
+		model := &Model{modelID: "meta.llama3-1-8b-instruct-v1:0", config: &ModelConfig{}}
+		result, err := model.convertLlamaRequest(req)
+		require.NoError(t, err)
+
+		var llamaReq map[string]interface{}
+		require.NoError(t, json.Unmarshal(result, &llamaReq))
+		assert.Contains(t, llamaReq["prompt"],
+			"<|begin_of_text|><|start_header_id|>system<|end_header_id|>\n\nYou are a pirate.<|eot_id|>")
+	})
+}
+
+func TestModel_convertClaudeResponse_MultiBlock(t *testing.T) {
+	model := &Model{modelID: "anthropic.claude-3-7-sonnet-20250219-v1:0", config: &ModelConfig{}}
+
+	body := []byte(`{
+		"content": [
+			{"type": "thinking", "thinking": "The user wants weather.", "signature": "c2lnLTE="},
+			{"type": "redacted_thinking", "data": "b3BhcXVl"},
+			{"type": "text", "text": "Let me check."},
+			{"type": "tool_use", "id": "toolu_01", "name": "weather", "input": {"city": "Paris"}},
+			{"type": "text", "text": "One moment."}
+		],
+		"usage": {"input_tokens": 10, "output_tokens": 20}
+	}`)
+
+	result, err := model.convertClaudeResponse(body)
+	require.NoError(t, err)
+
+	content := result.Message.Content
+	require.Len(t, content, 5)
+	require.True(t, content[0].IsReasoning())
+	assert.Equal(t, "The user wants weather.", content[0].Text)
+	assert.Equal(t, "c2lnLTE=", reasoningSignature(content[0]))
+	require.True(t, content[1].IsReasoning())
+	assert.Equal(t, "b3BhcXVl", content[1].Metadata["redactedData"])
+	assert.Equal(t, "Let me check.", content[2].Text)
+	require.True(t, content[3].IsToolRequest())
+	assert.Equal(t, "One moment.", content[4].Text)
+
+	// Reasoning is sent back verbatim on the next turn
+	req := &ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewUserTextMessage("Weather in Paris?"),
+			ai.NewModelMessage(content...),
+		},
+	}
+	reqBody, err := model.convertClaudeRequest(req)
+	require.NoError(t, err)
+
+	var claudeReq map[string]interface{}
+	require.NoError(t, json.Unmarshal(reqBody, &claudeReq))
+	blocks := claudeReq["messages"].([]interface{})[1].(map[string]interface{})["content"].([]interface{})
+	require.Len(t, blocks, 5)
+	assert.Equal(t, map[string]interface{}{
+		"type":      "thinking",
+		"thinking":  "The user wants weather.",
+		"signature": "c2lnLTE=",
+	}, blocks[0])
+	assert.Equal(t, map[string]interface{}{
+		"type": "redacted_thinking",
+		"data": "b3BhcXVl",
+	}, blocks[1])
+	assert.Equal(t, "text", blocks[2].(map[string]interface{})["type"])
+	assert.Equal(t, "tool_use", blocks[3].(map[string]interface{})["type"])
+	assert.Equal(t, "One moment.", blocks[4].(map[string]interface{})["text"])
+}
+
+func TestModel_convertNovaRequest_MultiPart(t *testing.T) {
+	model := &Model{modelID: "amazon.nova-pro-v1:0", config: &ModelConfig{}}
+
+	req := &ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewUserMessage(ai.NewTextPart("First."), ai.NewTextPart("Second.")),
+			ai.NewModelMessage(ai.NewReasoningPart("Thinking it over.", []byte("sig")), ai.NewTextPart("Done.")),
+		},
+	}
+
+	result, err := model.convertNovaRequest(req)
+	require.NoError(t, err)
+
+	var novaReq map[string]interface{}
+	require.NoError(t, json.Unmarshal(result, &novaReq))
+	messages := novaReq["messages"].([]interface{})
+	require.Len(t, messages, 2)
+
+	userContent := messages[0].(map[string]interface{})["content"].([]interface{})
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"text": "First."},
+		map[string]interface{}{"text": "Second."},
+	}, userContent)
+
+	modelContent := messages[1].(map[string]interface{})["content"].([]interface{})
+	require.Len(t, modelContent, 2)
+	assert.Equal(t, map[string]interface{}{
+		"reasoningContent": map[string]interface{}{
+			"reasoningText": map[string]interface{}{"text": "Thinking it over.", "signature": "sig"},
+		},
+	}, modelContent[0])
+	assert.Equal(t, map[string]interface{}{"text": "Done."}, modelContent[1])
+}
+
+func TestModel_convertNovaResponse_MultiBlock(t *testing.T) {
+	model := &Model{modelID: "amazon.nova-premier-v1:0", config: &ModelConfig{}}
+
+	body := []byte(`{
+		"output": {"message": {"role": "assistant", "content": [
+			{"reasoningContent": {"reasoningText": {"text": "Considering."}}},
+			{"text": "Part one."},
+			{"text": "Part two."}
+		]}},
+		"usage": {"inputTokens": 3, "outputTokens": 4}
+	}`)
+
+	result, err := model.convertNovaResponse(body)
+	require.NoError(t, err)
+
+	content := result.Message.Content
+	require.Len(t, content, 3)
+	require.True(t, content[0].IsReasoning())
+	assert.Equal(t, "Considering.", content[0].Text)
+	assert.Equal(t, "Part one.", content[1].Text)
+	assert.Equal(t, "Part two.", content[2].Text)
+}
+
+func TestModel_convertLlamaRequest_MultiPart(t *testing.T) {
+	model := &Model{modelID: "meta.llama3-1-8b-instruct-v1:0", config: &ModelConfig{}}
+
+	result, err := model.convertLlamaRequest(&ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewUserMessage(ai.NewTextPart("Summarise "), ai.NewTextPart("this.")),
+		},
+	})
+	require.NoError(t, err)
+
+	var llamaReq map[string]interface{}
+	require.NoError(t, json.Unmarshal(result, &llamaReq))
+	assert.Contains(t, llamaReq["prompt"], "<|end_header_id|>\n\nSummarise \nthis.<|eot_id|>")
+
+	_, err = model.convertLlamaRequest(&ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewUserMessage(ai.NewTextPart("Call it"), ai.NewToolRequestPart(&ai.ToolRequest{Name: "weather"})),
+		},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported part kind")
+}
+
+func TestFinishReason(t *testing.T) {
+	tests := []struct {
+		stopReason string
+		expected   ai.FinishReason
+	}{
+		{"end_turn", ai.FinishReasonStop},
+		{"stop_sequence", ai.FinishReasonStop},
+		{"tool_use", ai.FinishReasonStop},
+		{"tool_calls", ai.FinishReasonStop},
+		{"stop", ai.FinishReasonStop},
+		{"max_tokens", ai.FinishReasonLength},
+		{"length", ai.FinishReasonLength},
+		{"model_context_window_exceeded", ai.FinishReasonLength},
+		{"content_filtered", ai.FinishReasonBlocked},
+		{"guardrail_intervened", ai.FinishReasonBlocked},
+		{"refusal", ai.FinishReasonBlocked},
+		{"COMPLETE", ai.FinishReasonStop},
+		{"FINISH", ai.FinishReasonStop},
+		{"FINISHED", ai.FinishReasonStop},
+		{"STOP_CRITERIA_MET", ai.FinishReasonStop},
+		{"LENGTH", ai.FinishReasonLength},
+		{"CONTENT_FILTERED", ai.FinishReasonBlocked},
+		{"MAX_TOKENS", ai.FinishReasonLength},
+		{"ERROR_TOXIC", ai.FinishReasonBlocked},
+		{"pause_turn", ai.FinishReasonOther},
+		{"", ai.FinishReasonUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.stopReason, func(t *testing.T) {
+			assert.Equal(t, tt.expected, finishReason(tt.stopReason))
+		})
+	}
+}
+
+func TestModel_convertResponse_FinishReason(t *testing.T) {
+	tests := []struct {
+		name    string
+		modelID string
+		body    string
+		reason  ai.FinishReason
+		message string
+	}{
+		{
+			name:    "claude truncated",
+			modelID: "anthropic.claude-3-haiku-20240307-v1:0",
+			body:    `{"content":[{"type":"text","text":"Once upon"}],"stop_reason":"max_tokens","usage":{}}`,
+			reason:  ai.FinishReasonLength,
+			message: "max_tokens",
+		},
+		{
+			name:    "nova filtered",
+			modelID: "amazon.nova-lite-v1:0",
+			body:    `{"output":{"message":{"content":[{"text":""}]}},"stopReason":"content_filtered","usage":{}}`,
+			reason:  ai.FinishReasonBlocked,
+			message: "content_filtered",
+		},
+		{
+			name:    "llama natural end",
+			modelID: "meta.llama3-1-8b-instruct-v1:0",
+			body:    `{"generation":"Done.","stop_reason":"stop"}`,
+			reason:  ai.FinishReasonStop,
+			message: "stop",
+		},
+		{
+			name:    "llama truncated",
+			modelID: "meta.llama3-1-8b-instruct-v1:0",
+			body:    `{"generation":"And then","stop_reason":"length"}`,
+			reason:  ai.FinishReasonLength,
+			message: "length",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &Model{modelID: tt.modelID, config: &ModelConfig{}}
+			result, err := model.convertResponse([]byte(tt.body))
+			require.NoError(t, err)
+			assert.Equal(t, tt.reason, result.FinishReason)
+			assert.Equal(t, tt.message, result.FinishMessage)
+		})
+	}
+}
+
+func TestReasoningSignature(t *testing.T) {
+	part := ai.NewReasoningPart("thought", []byte("sig-1"))
+	assert.Equal(t, "sig-1", reasoningSignature(part))
+
+	// A message that has been through JSON carries the signature as base64
+	data, err := json.Marshal(part)
+	require.NoError(t, err)
+	var decoded ai.Part
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "sig-1", reasoningSignature(&decoded))
+
+	assert.Empty(t, reasoningSignature(ai.NewReasoningPart("thought", nil)))
+}
+
+func TestModel_Supports(t *testing.T) {
+	tests := []struct {
+		name        string
+		model       *Model
+		expectTools bool
+		expectMedia bool
+	}{
+		{"claude", &Model{modelID: "anthropic.claude-3-sonnet-20240229-v1:0"}, true, true},
+		{"nova", &Model{modelID: "amazon.nova-lite-v1:0"}, true, true},
+		{"nova micro", &Model{modelID: "amazon.nova-micro-v1:0"}, true, false},
+		{"llama", &Model{modelID: "meta.llama3-2-90b-instruct-v1:0"}, false, true},
+		{"mistral", &Model{modelID: "mistral.mixtral-8x7b-instruct-v0:1"}, false, false},
+		{"mistral large", &Model{modelID: "mistral.mistral-large-2407-v1:0"}, true, false},
+		{"converse", &Model{modelID: "mistral.mistral-large-2407-v1:0", useConverse: true}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			supports := tt.model.Supports()
+			assert.Equal(t, tt.expectTools, supports.Tools)
+			assert.Equal(t, tt.expectTools, supports.ToolChoice)
+			assert.Equal(t, tt.expectMedia, supports.Media)
+			assert.True(t, supports.Multiturn)
+			assert.True(t, supports.SystemRole)
+		})
+	}
+}
+
 func TestIsClaudeModel(t *testing.T) {
 	tests := []struct {
 		modelID  string
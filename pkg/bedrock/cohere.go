@@ -0,0 +1,335 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// Citation is a span of a Cohere response grounded in request documents.
+// Responses that cite documents carry them in Custom under "citations".
+type Citation struct {
+	Start       int      `json:"start"`
+	End         int      `json:"end"`
+	Text        string   `json:"text"`
+	DocumentIDs []string `json:"document_ids"`
+}
+
+// cohereToolCall is a tool call in the Cohere chat format, which has no IDs
+type cohereToolCall struct {
+	Name       string         `json:"name"`
+	Parameters map[string]any `json:"parameters"`
+}
+
+// Cohere-specific request conversion. The final user message is sent as
+// message, earlier turns as chat_history and system messages as preamble.
+func (m *Model) convertCohereRequest(req *ai.ModelRequest) ([]byte, error) {
+	if len(req.Messages) == 0 {
+		return nil, fmt.Errorf("no messages in request")
+	}
+
+	if _, err := m.prepareMedia(req.Messages); err != nil {
+		return nil, err
+	}
+
+	system, conversation, err := splitSystemMessages(req.Messages)
+	if err != nil {
+		return nil, err
+	}
+	if len(conversation) == 0 {
+		return nil, fmt.Errorf("no messages in request")
+	}
+
+	history, err := cohereChatHistory(conversation)
+	if err != nil {
+		return nil, err
+	}
+
+	// The last turn becomes the message, or the tool results when the
+	// conversation ends by answering the model's tool calls
+	last := history[len(history)-1]
+	history = history[:len(history)-1]
+
+	cohereReq := map[string]interface{}{
+		"chat_history": history,
+		"max_tokens":   m.config.MaxTokens,
+		"temperature":  m.config.Temperature,
+	}
+
+	switch last["role"] {
+	case "USER":
+		cohereReq["message"] = last["message"]
+	case "TOOL":
+		cohereReq["message"] = ""
+		if message, ok := last["message"]; ok {
+			cohereReq["message"] = message
+		}
+		cohereReq["tool_results"] = last["tool_results"]
+	default:
+		return nil, fmt.Errorf("conversation must end with a user or tool message for Cohere")
+	}
+
+	if system != "" {
+		cohereReq["preamble"] = system
+	}
+
+	if m.config.TopP > 0 {
+		cohereReq["p"] = m.config.TopP
+	}
+
+	if m.config.TopK > 0 {
+		cohereReq["k"] = m.config.TopK
+	}
+
+	if len(m.config.StopSequences) > 0 {
+		cohereReq["stop_sequences"] = m.config.StopSequences
+	}
+
+	if len(req.Docs) > 0 {
+		cohereReq["documents"] = cohereDocuments(req.Docs)
+	}
+
+	if len(req.Tools) > 0 && req.ToolChoice != ai.ToolChoiceNone {
+		cohereReq["tools"] = cohereTools(req.Tools)
+	}
+
+	return json.Marshal(cohereReq)
+}
+
+// cohereChatHistory converts messages to Cohere chat turns. Cohere tool
+// results repeat the call they answer, so each tool response is matched to
+// the model's request by reference, falling back to the tool name.
+func cohereChatHistory(messages []*ai.Message) ([]map[string]interface{}, error) {
+	var history []map[string]interface{}
+	var calls []*ai.ToolRequest
+
+	for i, msg := range messages {
+		var text strings.Builder
+		var toolCalls []cohereToolCall
+		var toolResults []map[string]interface{}
+
+		for _, part := range msg.Content {
+			switch {
+			case part.IsText():
+				text.WriteString(part.Text)
+			case part.IsToolRequest():
+				calls = append(calls, part.ToolRequest)
+				toolCalls = append(toolCalls, cohereToolCall{
+					Name:       part.ToolRequest.Name,
+					Parameters: jsonObject(part.ToolRequest.Input),
+				})
+			case part.IsToolResponse():
+				call := cohereToolCall{Name: part.ToolResponse.Name, Parameters: map[string]any{}}
+				if toolReq := matchToolRequest(calls, part.ToolResponse); toolReq != nil {
+					call.Parameters = jsonObject(toolReq.Input)
+				}
+				toolResults = append(toolResults, map[string]interface{}{
+					"call":    call,
+					"outputs": []map[string]any{jsonObject(part.ToolResponse.Output)},
+				})
+			default:
+				return nil, fmt.Errorf("unsupported part kind %d for Cohere", part.Kind)
+			}
+		}
+
+		switch {
+		case len(toolResults) > 0:
+			turn := map[string]interface{}{
+				"role":         "TOOL",
+				"tool_results": toolResults,
+			}
+			history = append(history, turn)
+
+			// Tool turns have no text, so text sent with the results is the
+			// message of the final turn or a user turn of its own
+			if text.Len() > 0 && i == len(messages)-1 {
+				turn["message"] = text.String()
+			} else if text.Len() > 0 {
+				history = append(history, map[string]interface{}{
+					"role":    "USER",
+					"message": text.String(),
+				})
+			}
+		case msg.Role == ai.RoleModel:
+			turn := map[string]interface{}{
+				"role":    "CHATBOT",
+				"message": text.String(),
+			}
+			if len(toolCalls) > 0 {
+				turn["tool_calls"] = toolCalls
+			}
+			history = append(history, turn)
+		default:
+			history = append(history, map[string]interface{}{
+				"role":    "USER",
+				"message": text.String(),
+			})
+		}
+	}
+
+	return history, nil
+}
+
+// matchToolRequest finds the most recent tool request a response answers
+func matchToolRequest(calls []*ai.ToolRequest, resp *ai.ToolResponse) *ai.ToolRequest {
+	for i := len(calls) - 1; i >= 0; i-- {
+		if resp.Ref != "" && calls[i].Ref == resp.Ref {
+			return calls[i]
+		}
+	}
+	for i := len(calls) - 1; i >= 0; i-- {
+		if calls[i].Name == resp.Name {
+			return calls[i]
+		}
+	}
+	return nil
+}
+
+// cohereDocuments converts GenKit documents to Cohere grounding documents.
+// String metadata is passed through, and each document gets an ID so
+// citations can refer back to it.
+func cohereDocuments(docs []*ai.Document) []map[string]string {
+	result := make([]map[string]string, 0, len(docs))
+	for i, doc := range docs {
+		fields := map[string]string{}
+		for key, value := range doc.Metadata {
+			if s, ok := value.(string); ok {
+				fields[key] = s
+			}
+		}
+
+		var snippet strings.Builder
+		for _, part := range doc.Content {
+			if part.IsText() {
+				snippet.WriteString(part.Text)
+			}
+		}
+		fields["snippet"] = snippet.String()
+
+		if fields["id"] == "" {
+			fields["id"] = fmt.Sprintf("doc_%d", i)
+		}
+		result = append(result, fields)
+	}
+	return result
+}
+
+// cohereParameterTypes maps JSON schema types to Cohere's Python-style types
+var cohereParameterTypes = map[string]string{
+	"string":  "str",
+	"integer": "int",
+	"number":  "float",
+	"boolean": "bool",
+	"array":   "list",
+	"object":  "dict",
+}
+
+// cohereTools converts GenKit tool definitions to Cohere tools, flattening
+// the top-level JSON schema properties into parameter definitions
+func cohereTools(tools []*ai.ToolDefinition) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(tools))
+	for _, tool := range tools {
+		schema := toolSchema(tool.InputSchema)
+
+		required := map[string]bool{}
+		switch names := schema["required"].(type) {
+		case []string:
+			for _, name := range names {
+				required[name] = true
+			}
+		case []any:
+			for _, name := range names {
+				if s, ok := name.(string); ok {
+					required[s] = true
+				}
+			}
+		}
+
+		params := map[string]interface{}{}
+		properties, _ := schema["properties"].(map[string]any)
+		for name, value := range properties {
+			property, _ := value.(map[string]any)
+			definition := map[string]interface{}{"required": required[name]}
+
+			jsonType, _ := property["type"].(string)
+			if paramType, ok := cohereParameterTypes[jsonType]; ok {
+				definition["type"] = paramType
+			} else {
+				definition["type"] = "str"
+			}
+			if description, ok := property["description"].(string); ok {
+				definition["description"] = description
+			}
+			params[name] = definition
+		}
+
+		result = append(result, map[string]interface{}{
+			"name":                  tool.Name,
+			"description":           tool.Description,
+			"parameter_definitions": params,
+		})
+	}
+	return result
+}
+
+// Cohere-specific response conversion
+func (m *Model) convertCohereResponse(body []byte) (*ai.ModelResponse, error) {
+	var cohereResp struct {
+		Text         string           `json:"text"`
+		Citations    []Citation       `json:"citations"`
+		FinishReason string           `json:"finish_reason"`
+		ToolCalls    []cohereToolCall `json:"tool_calls"`
+		Meta         struct {
+			BilledUnits struct {
+				InputTokens  int `json:"input_tokens"`
+				OutputTokens int `json:"output_tokens"`
+			} `json:"billed_units"`
+		} `json:"meta"`
+	}
+
+	if err := json.Unmarshal(body, &cohereResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Cohere response: %w", err)
+	}
+
+	var content []*ai.Part
+	if cohereResp.Text != "" || len(cohereResp.ToolCalls) == 0 {
+		content = append(content, ai.NewTextPart(cohereResp.Text))
+	}
+	for _, call := range cohereResp.ToolCalls {
+		content = append(content, ai.NewToolRequestPart(call.toolRequest()))
+	}
+
+	units := cohereResp.Meta.BilledUnits
+	response := &ai.ModelResponse{
+		Message: &ai.Message{
+			Role:    "model",
+			Content: content,
+		},
+		Usage: &ai.GenerationUsage{
+			InputTokens:  units.InputTokens,
+			OutputTokens: units.OutputTokens,
+			TotalTokens:  units.InputTokens + units.OutputTokens,
+		},
+		FinishReason:  finishReason(cohereResp.FinishReason),
+		FinishMessage: cohereResp.FinishReason,
+	}
+	if len(cohereResp.Citations) > 0 {
+		response.Custom = map[string]any{"citations": cohereResp.Citations}
+	}
+
+	return response, nil
+}
+
+// toolRequest converts the call to a GenKit tool request
+func (c cohereToolCall) toolRequest() *ai.ToolRequest {
+	input := c.Parameters
+	if input == nil {
+		input = map[string]any{}
+	}
+	return &ai.ToolRequest{Name: c.Name, Input: input}
+}
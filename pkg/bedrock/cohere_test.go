@@ -0,0 +1,283 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModel_convertCohereRequest(t *testing.T) {
+	model := &Model{
+		modelID: "cohere.command-r-plus-v1:0",
+		config: &ModelConfig{
+			MaxTokens:     300,
+			Temperature:   0.3,
+			TopP:          0.8,
+			TopK:          40,
+			StopSequences: []string{"END"},
+		},
+	}
+
+	result, err := model.convertCohereRequest(&ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewSystemTextMessage("Cite your sources."),
+			ai.NewUserTextMessage("Hi."),
+			ai.NewModelTextMessage("Hello! How can I help?"),
+			ai.NewUserTextMessage("Which planet is largest?"),
+		},
+		Docs: []*ai.Document{
+			ai.DocumentFromText("Jupiter is the largest planet.", map[string]any{"title": "Planets"}),
+			ai.DocumentFromText("Saturn has rings.", map[string]any{"id": "saturn", "rank": 2}),
+		},
+	})
+	require.NoError(t, err)
+
+	var cohereReq map[string]interface{}
+	require.NoError(t, json.Unmarshal(result, &cohereReq))
+	assert.Equal(t, "Which planet is largest?", cohereReq["message"])
+	assert.Equal(t, "Cite your sources.", cohereReq["preamble"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"role": "USER", "message": "Hi."},
+		map[string]interface{}{"role": "CHATBOT", "message": "Hello! How can I help?"},
+	}, cohereReq["chat_history"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"id": "doc_0", "title": "Planets", "snippet": "Jupiter is the largest planet."},
+		map[string]interface{}{"id": "saturn", "snippet": "Saturn has rings."},
+	}, cohereReq["documents"])
+	assert.Equal(t, float64(300), cohereReq["max_tokens"])
+	assert.Equal(t, 0.3, cohereReq["temperature"])
+	assert.Equal(t, 0.8, cohereReq["p"])
+	assert.Equal(t, float64(40), cohereReq["k"])
+	assert.Equal(t, []interface{}{"END"}, cohereReq["stop_sequences"])
+	assert.NotContains(t, cohereReq, "tools")
+}
+
+func TestModel_convertCohereRequest_Tools(t *testing.T) {
+	model := &Model{modelID: "cohere.command-r-v1:0", config: &ModelConfig{}}
+
+	result, err := model.convertCohereRequest(&ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewUserTextMessage("Weather in Paris?"),
+			{
+				Role: ai.RoleModel,
+				Content: []*ai.Part{ai.NewToolRequestPart(&ai.ToolRequest{
+					Name:  "get_weather",
+					Input: map[string]any{"city": "Paris"},
+				})},
+			},
+			{
+				Role: ai.RoleTool,
+				Content: []*ai.Part{ai.NewToolResponsePart(&ai.ToolResponse{
+					Name:   "get_weather",
+					Output: map[string]any{"temp": 21},
+				})},
+			},
+		},
+		Tools: []*ai.ToolDefinition{{
+			Name:        "get_weather",
+			Description: "Look up the weather",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"city": map[string]any{"type": "string", "description": "City name"},
+					"days": map[string]any{"type": "integer"},
+				},
+				"required": []any{"city"},
+			},
+		}},
+	})
+	require.NoError(t, err)
+
+	var cohereReq map[string]interface{}
+	require.NoError(t, json.Unmarshal(result, &cohereReq))
+	assert.Equal(t, "", cohereReq["message"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"call":    map[string]interface{}{"name": "get_weather", "parameters": map[string]interface{}{"city": "Paris"}},
+			"outputs": []interface{}{map[string]interface{}{"temp": float64(21)}},
+		},
+	}, cohereReq["tool_results"])
+
+	history := cohereReq["chat_history"].([]interface{})
+	require.Len(t, history, 2)
+	assert.Equal(t, map[string]interface{}{
+		"role":    "CHATBOT",
+		"message": "",
+		"tool_calls": []interface{}{
+			map[string]interface{}{"name": "get_weather", "parameters": map[string]interface{}{"city": "Paris"}},
+		},
+	}, history[1])
+
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"name":        "get_weather",
+			"description": "Look up the weather",
+			"parameter_definitions": map[string]interface{}{
+				"city": map[string]interface{}{"type": "str", "description": "City name", "required": true},
+				"days": map[string]interface{}{"type": "int", "required": false},
+			},
+		},
+	}, cohereReq["tools"])
+}
+
+func TestModel_convertCohereRequest_ToolResultText(t *testing.T) {
+	model := &Model{modelID: "cohere.command-r-v1:0", config: &ModelConfig{}}
+
+	call := &ai.Message{
+		Role: ai.RoleModel,
+		Content: []*ai.Part{ai.NewToolRequestPart(&ai.ToolRequest{
+			Name:  "get_weather",
+			Input: map[string]any{"city": "Paris"},
+		})},
+	}
+	results := &ai.Message{
+		Role: ai.RoleTool,
+		Content: []*ai.Part{
+			ai.NewToolResponsePart(&ai.ToolResponse{
+				Name:   "get_weather",
+				Output: map[string]any{"temp": 21},
+			}),
+			ai.NewTextPart("Answer in Celsius."),
+		},
+	}
+	toolResults := []interface{}{
+		map[string]interface{}{
+			"call":    map[string]interface{}{"name": "get_weather", "parameters": map[string]interface{}{"city": "Paris"}},
+			"outputs": []interface{}{map[string]interface{}{"temp": float64(21)}},
+		},
+	}
+
+	t.Run("final turn", func(t *testing.T) {
+		result, err := model.convertCohereRequest(&ai.ModelRequest{
+			Messages: []*ai.Message{ai.NewUserTextMessage("Weather in Paris?"), call, results},
+		})
+		require.NoError(t, err)
+
+		var cohereReq map[string]interface{}
+		require.NoError(t, json.Unmarshal(result, &cohereReq))
+		assert.Equal(t, "Answer in Celsius.", cohereReq["message"])
+		assert.Equal(t, toolResults, cohereReq["tool_results"])
+	})
+
+	t.Run("history", func(t *testing.T) {
+		result, err := model.convertCohereRequest(&ai.ModelRequest{
+			Messages: []*ai.Message{
+				ai.NewUserTextMessage("Weather in Paris?"), call, results,
+				ai.NewModelTextMessage("It is 21°C."),
+				ai.NewUserTextMessage("Thanks"),
+			},
+		})
+		require.NoError(t, err)
+
+		var cohereReq map[string]interface{}
+		require.NoError(t, json.Unmarshal(result, &cohereReq))
+		assert.Equal(t, "Thanks", cohereReq["message"])
+
+		history := cohereReq["chat_history"].([]interface{})
+		require.Len(t, history, 5)
+		assert.Equal(t, map[string]interface{}{"role": "TOOL", "tool_results": toolResults}, history[2])
+		assert.Equal(t, map[string]interface{}{"role": "USER", "message": "Answer in Celsius."}, history[3])
+	})
+}
+
+func TestModel_convertCohereRequest_Errors(t *testing.T) {
+	model := &Model{modelID: "cohere.command-r-v1:0", config: &ModelConfig{}}
+
+	tests := []struct {
+		name         string
+		messages     []*ai.Message
+		wantErrorMsg string
+	}{
+		{
+			name:         "no messages",
+			wantErrorMsg: "no messages",
+		},
+		{
+			name:         "only system",
+			messages:     []*ai.Message{ai.NewSystemTextMessage("Be brief.")},
+			wantErrorMsg: "no messages",
+		},
+		{
+			name: "ends with model",
+			messages: []*ai.Message{
+				ai.NewUserTextMessage("Hi."),
+				ai.NewModelTextMessage("Hello"),
+			},
+			wantErrorMsg: "must end with a user or tool message",
+		},
+		{
+			name:         "image",
+			messages:     []*ai.Message{ai.NewUserMessage(ai.NewMediaPart("image/png", pngHeader))},
+			wantErrorMsg: "does not accept",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := model.convertCohereRequest(&ai.ModelRequest{Messages: tt.messages})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErrorMsg)
+		})
+	}
+}
+
+func TestModel_convertCohereResponse(t *testing.T) {
+	model := &Model{modelID: "cohere.command-r-plus-v1:0", config: &ModelConfig{}}
+
+	t.Run("grounded text", func(t *testing.T) {
+		result, err := model.convertResponse([]byte(`{
+			"text": "Jupiter is the largest planet.",
+			"citations": [{"start": 0, "end": 7, "text": "Jupiter", "document_ids": ["doc_0"]}],
+			"finish_reason": "COMPLETE",
+			"meta": {"billed_units": {"input_tokens": 40, "output_tokens": 8}}
+		}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, []*ai.Part{ai.NewTextPart("Jupiter is the largest planet.")}, result.Message.Content)
+		assert.Equal(t, ai.FinishReasonStop, result.FinishReason)
+		assert.Equal(t, "COMPLETE", result.FinishMessage)
+		assert.Equal(t, 40, result.Usage.InputTokens)
+		assert.Equal(t, 8, result.Usage.OutputTokens)
+		assert.Equal(t, 48, result.Usage.TotalTokens)
+		assert.Equal(t, map[string]any{
+			"citations": []Citation{{Start: 0, End: 7, Text: "Jupiter", DocumentIDs: []string{"doc_0"}}},
+		}, result.Custom)
+	})
+
+	t.Run("tool calls", func(t *testing.T) {
+		result, err := model.convertResponse([]byte(`{
+			"text": "",
+			"tool_calls": [{"name": "get_weather", "parameters": {"city": "Paris"}}],
+			"finish_reason": "COMPLETE"
+		}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, []*ai.Part{ai.NewToolRequestPart(&ai.ToolRequest{
+			Name:  "get_weather",
+			Input: map[string]any{"city": "Paris"},
+		})}, result.Message.Content)
+		assert.Nil(t, result.Custom)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		result, err := model.convertResponse([]byte(`{"text": "Once", "finish_reason": "MAX_TOKENS"}`))
+		require.NoError(t, err)
+		assert.Equal(t, ai.FinishReasonLength, result.FinishReason)
+	})
+}
+
+func TestModel_Supports_Cohere(t *testing.T) {
+	supports := (&Model{modelID: "cohere.command-r-v1:0"}).Supports()
+	assert.True(t, supports.Tools)
+	assert.False(t, supports.ToolChoice)
+	assert.False(t, supports.Media)
+
+	supports = (&Model{modelID: "cohere.command-r-v1:0", useConverse: true}).Supports()
+	assert.True(t, supports.ToolChoice)
+}
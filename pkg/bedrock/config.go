@@ -4,9 +4,12 @@
 package bedrock
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 
+	"github.com/firebase/genkit/go/ai"
 	"github.com/scttfrdmn/genkit-aws/internal/constants"
 )
 
@@ -20,6 +23,22 @@ type Config struct {
 
 	// DefaultModelConfig provides default settings for all models
 	DefaultModelConfig *ModelConfig `json:"default_model_config,omitempty"`
+
+	// UseConverse routes every model through the Converse API instead of
+	// the per-family InvokeModel request builders
+	UseConverse bool `json:"use_converse,omitempty"`
+
+	// EmbedderConfigs holds per-embedder configuration
+	EmbedderConfigs map[string]*EmbedderConfig `json:"embedder_configs,omitempty"`
+
+	// RetrieverConfigs holds per-retriever configuration, keyed by
+	// retriever name
+	RetrieverConfigs map[string]*RetrieverConfig `json:"retriever_configs,omitempty"`
+
+	// BaseModels maps application inference profile and provisioned
+	// throughput ARNs, which do not name their model, to the foundation
+	// model behind them
+	BaseModels map[string]string `json:"base_models,omitempty"`
 }
 
 // ModelConfig holds configuration for a specific model
@@ -33,8 +52,45 @@ type ModelConfig struct {
 	// TopP controls nucleus sampling
 	TopP float64 `json:"top_p,omitempty"`
 
+	// TopK limits sampling to the K most likely tokens, for families that
+	// support it
+	TopK int `json:"top_k,omitempty"`
+
 	// StopSequences are sequences that will stop generation
 	StopSequences []string `json:"stop_sequences,omitempty"`
+
+	// UseConverse routes this model through the Converse API, which
+	// supports any Converse-capable model without family-specific code
+	UseConverse bool `json:"use_converse,omitempty"`
+
+	// ThinkingBudget enables extended thinking on models that support it
+	// (Claude 3.7 and later), allowing up to this many tokens of reasoning
+	// before the answer. It counts towards MaxTokens.
+	ThinkingBudget int `json:"thinking_budget,omitempty"`
+
+	// CacheSystem places a prompt cache checkpoint after the system prompt
+	CacheSystem bool `json:"cache_system,omitempty"`
+
+	// CacheTools places a prompt cache checkpoint after the tool
+	// definitions, for models that can cache them (Claude)
+	CacheTools bool `json:"cache_tools,omitempty"`
+
+	// Image holds settings for image generation models
+	Image *ImageConfig `json:"image,omitempty"`
+
+	// Video holds settings for video generation models
+	Video *VideoConfig `json:"video,omitempty"`
+
+	// GuardrailID is the ID or ARN of a Bedrock guardrail applied to every
+	// generation
+	GuardrailID string `json:"guardrail_id,omitempty"`
+
+	// GuardrailVersion is the guardrail version, a number or DRAFT
+	GuardrailVersion string `json:"guardrail_version,omitempty"`
+
+	// GuardrailTrace returns the guardrail assessment in the response when
+	// "enabled" or "enabled_full"
+	GuardrailTrace string `json:"guardrail_trace,omitempty"`
 }
 
 // Validate validates the Bedrock configuration
@@ -47,6 +103,28 @@ func (c *Config) Validate() error {
 		if modelID == "" {
 			return errors.New("model ID cannot be empty")
 		}
+		if _, err := ParseModelID(modelID); err != nil {
+			return fmt.Errorf("invalid model ID: %w", err)
+		}
+	}
+
+	for name, config := range c.RetrieverConfigs {
+		if err := config.Validate(); err != nil {
+			return fmt.Errorf("invalid config for retriever %s: %w", name, err)
+		}
+	}
+
+	for modelID, base := range c.BaseModels {
+		if _, err := ParseModelID(modelID); err != nil {
+			return fmt.Errorf("invalid base_models key: %w", err)
+		}
+		id, err := ParseModelID(base)
+		if err != nil {
+			return fmt.Errorf("invalid base model for %s: %w", modelID, err)
+		}
+		if id.Foundation == "" {
+			return fmt.Errorf("base model for %s must name a foundation model, not %s", modelID, base)
+		}
 	}
 
 	// Validate model configs
@@ -62,6 +140,12 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	for modelID, config := range c.EmbedderConfigs {
+		if err := config.Validate(); err != nil {
+			return fmt.Errorf("invalid config for embedder %s: %w", modelID, err)
+		}
+	}
+
 	return nil
 }
 
@@ -79,6 +163,34 @@ func (mc *ModelConfig) Validate() error {
 		return errors.New("top_p must be between 0.0 and 1.0")
 	}
 
+	if mc.TopK < 0 {
+		return errors.New("top_k must be non-negative")
+	}
+
+	if mc.ThinkingBudget < 0 {
+		return errors.New("thinking_budget must be non-negative")
+	}
+
+	if mc.ThinkingBudget > 0 && mc.MaxTokens > 0 && mc.ThinkingBudget >= mc.MaxTokens {
+		return errors.New("thinking_budget must be less than max_tokens")
+	}
+
+	if err := mc.validateGuardrail(); err != nil {
+		return err
+	}
+
+	if mc.Image != nil {
+		if err := mc.Image.Validate(); err != nil {
+			return fmt.Errorf("invalid image config: %w", err)
+		}
+	}
+
+	if mc.Video != nil {
+		if err := mc.Video.Validate(); err != nil {
+			return fmt.Errorf("invalid video config: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -108,20 +220,176 @@ func (c *Config) mergeWithDefaults(config *ModelConfig) *ModelConfig {
 		return config
 	}
 
-	merged := *config
+	return c.DefaultModelConfig.overlay(config)
+}
 
-	if merged.MaxTokens == 0 {
-		merged.MaxTokens = c.DefaultModelConfig.MaxTokens
+// overlay returns a copy of the config with every field set in override
+// replacing the corresponding field
+func (mc *ModelConfig) overlay(override *ModelConfig) *ModelConfig {
+	merged := *mc
+
+	if override.MaxTokens != 0 {
+		merged.MaxTokens = override.MaxTokens
+	}
+	if override.Temperature != 0 {
+		merged.Temperature = override.Temperature
+	}
+	if override.TopP != 0 {
+		merged.TopP = override.TopP
+	}
+	if override.TopK != 0 {
+		merged.TopK = override.TopK
+	}
+	if len(override.StopSequences) > 0 {
+		merged.StopSequences = override.StopSequences
+	}
+	if override.UseConverse {
+		merged.UseConverse = true
 	}
-	if merged.Temperature == 0 {
-		merged.Temperature = c.DefaultModelConfig.Temperature
+	if override.ThinkingBudget != 0 {
+		merged.ThinkingBudget = override.ThinkingBudget
 	}
-	if merged.TopP == 0 {
-		merged.TopP = c.DefaultModelConfig.TopP
+	if override.CacheSystem {
+		merged.CacheSystem = true
 	}
-	if len(merged.StopSequences) == 0 {
-		merged.StopSequences = c.DefaultModelConfig.StopSequences
+	if override.CacheTools {
+		merged.CacheTools = true
+	}
+	if override.Image != nil {
+		merged.Image = merged.imageConfig().overlay(override.Image)
+	}
+	if override.Video != nil {
+		merged.Video = merged.videoConfig().overlay(override.Video)
+	}
+	if override.GuardrailID != "" {
+		merged.GuardrailID = override.GuardrailID
+		merged.GuardrailVersion = override.GuardrailVersion
+	} else if override.GuardrailVersion != "" {
+		merged.GuardrailVersion = override.GuardrailVersion
+	}
+	if override.GuardrailTrace != "" {
+		merged.GuardrailTrace = override.GuardrailTrace
 	}
 
 	return &merged
 }
+
+// imageConfig returns the image settings, which may be unset
+func (mc *ModelConfig) imageConfig() *ImageConfig {
+	if mc.Image == nil {
+		return &ImageConfig{}
+	}
+	return mc.Image
+}
+
+// videoConfig returns the video settings, which may be unset
+func (mc *ModelConfig) videoConfig() *VideoConfig {
+	if mc.Video == nil {
+		return &VideoConfig{}
+	}
+	return mc.Video
+}
+
+// requestConfig merges the config attached to a GenKit request over base.
+// It accepts GenKit's common config, a Bedrock ModelConfig, ImageConfig or
+// VideoConfig, or the JSON object form of the first two, as sent by the
+// Developer UI. Typed configs replace only their non-zero fields; in the JSON
+// form every key present wins, so an explicit 0 or false can unset a value.
+func requestConfig(base *ModelConfig, config any) (*ModelConfig, error) {
+	switch c := config.(type) {
+	case nil:
+		return base.overlay(&ModelConfig{}), nil
+	case *ModelConfig:
+		if c == nil {
+			return base.overlay(&ModelConfig{}), nil
+		}
+		return base.overlay(c), nil
+	case ModelConfig:
+		return base.overlay(&c), nil
+	case *ai.GenerationCommonConfig:
+		if c == nil {
+			return base.overlay(&ModelConfig{}), nil
+		}
+		return base.overlay(commonConfig(c)), nil
+	case ai.GenerationCommonConfig:
+		return base.overlay(commonConfig(&c)), nil
+	case *ImageConfig:
+		return base.overlay(&ModelConfig{Image: c}), nil
+	case ImageConfig:
+		return base.overlay(&ModelConfig{Image: &c}), nil
+	case *VideoConfig:
+		return base.overlay(&ModelConfig{Video: c}), nil
+	case VideoConfig:
+		return base.overlay(&ModelConfig{Video: &c}), nil
+	case map[string]any:
+		data, err := json.Marshal(c)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request config: %w", err)
+		}
+		return base.overlayJSON(data)
+	default:
+		return nil, fmt.Errorf("unsupported config type %T", config)
+	}
+}
+
+// overlayJSON returns a copy of the config with every key present in a JSON
+// object replacing the corresponding field. Bedrock keys are snake_case and
+// GenKit's are camelCase, so both can be read from the same object, with
+// Bedrock keys taking precedence.
+func (mc *ModelConfig) overlayJSON(data []byte) (*ModelConfig, error) {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to decode request config: %w", err)
+	}
+	var common ai.GenerationCommonConfig
+	if err := json.Unmarshal(data, &common); err != nil {
+		return nil, fmt.Errorf("failed to decode request config: %w", err)
+	}
+
+	// Decoding fills the copy in place, so it must not share the slice and
+	// nested configs of mc
+	merged := *mc
+	merged.StopSequences = slices.Clone(mc.StopSequences)
+	if mc.Image != nil {
+		image := *mc.Image
+		merged.Image = &image
+	}
+	if mc.Video != nil {
+		video := *mc.Video
+		merged.Video = &video
+	}
+
+	if _, ok := keys["maxOutputTokens"]; ok {
+		merged.MaxTokens = common.MaxOutputTokens
+	}
+	if _, ok := keys["topP"]; ok {
+		merged.TopP = common.TopP
+	}
+	if _, ok := keys["topK"]; ok {
+		merged.TopK = common.TopK
+	}
+	if _, ok := keys["stopSequences"]; ok {
+		merged.StopSequences = common.StopSequences
+	}
+
+	// A new guardrail does not inherit the version of the old one
+	if _, ok := keys["guardrail_id"]; ok {
+		merged.GuardrailVersion = ""
+	}
+
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return nil, fmt.Errorf("failed to decode request config: %w", err)
+	}
+	return &merged, nil
+}
+
+// commonConfig converts GenKit's common generation config to a ModelConfig
+func commonConfig(c *ai.GenerationCommonConfig) *ModelConfig {
+	return &ModelConfig{
+		MaxTokens:     c.MaxOutputTokens,
+		Temperature:   c.Temperature,
+		TopP:          c.TopP,
+		TopK:          c.TopK,
+		StopSequences: c.StopSequences,
+	}
+}
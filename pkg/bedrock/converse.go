@@ -0,0 +1,443 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/firebase/genkit/go/ai"
+)
+
+// generateConverse runs a generation through the Converse or ConverseStream
+// operation, which works for any Converse-capable model regardless of family
+func (m *Model) generateConverse(ctx context.Context, req *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+	input, err := m.convertConverseRequest(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert request: %w", err)
+	}
+
+	if cb != nil {
+		return m.generateConverseStream(ctx, input, cb)
+	}
+
+	output, err := m.client.runtime.Converse(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("bedrock converse failed: %w", err)
+	}
+
+	response, err := convertConverseResponse(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert response: %w", err)
+	}
+
+	return response, nil
+}
+
+// generateConverseStream runs a generation through ConverseStream and
+// forwards each text delta to the callback as it arrives
+func (m *Model) generateConverseStream(ctx context.Context, input *bedrockruntime.ConverseInput, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+	result, err := m.client.runtime.ConverseStream(ctx, &bedrockruntime.ConverseStreamInput{
+		ModelId:                      input.ModelId,
+		Messages:                     input.Messages,
+		System:                       input.System,
+		InferenceConfig:              input.InferenceConfig,
+		ToolConfig:                   input.ToolConfig,
+		AdditionalModelRequestFields: input.AdditionalModelRequestFields,
+		GuardrailConfig:              m.guardrailStreamConfig(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bedrock converse stream failed: %w", err)
+	}
+
+	stream := result.GetStream()
+	defer stream.Close()
+
+	response, err := readConverseStream(ctx, stream.Events(), cb)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := stream.Err(); err != nil {
+		return nil, fmt.Errorf("bedrock stream failed: %w", err)
+	}
+
+	return response, nil
+}
+
+// convertConverseRequest maps a GenKit request onto typed Converse structures
+func (m *Model) convertConverseRequest(req *ai.ModelRequest) (*bedrockruntime.ConverseInput, error) {
+	if len(req.Messages) == 0 {
+		return nil, fmt.Errorf("no messages in request")
+	}
+
+	input := &bedrockruntime.ConverseInput{
+		ModelId: aws.String(m.modelID),
+		InferenceConfig: &types.InferenceConfiguration{
+			MaxTokens: aws.Int32(int32(m.config.MaxTokens)),
+		},
+		GuardrailConfig: m.guardrailConfig(),
+	}
+
+	// Converse has no common top-k or thinking settings, so they go in the
+	// family's native request fields
+	var fields map[string]any
+	switch {
+	case isClaudeModel(m.foundationModel()) && m.config.ThinkingBudget > 0:
+		// Extended thinking fixes the sampling parameters
+		thinking, err := claudeThinking(m.config.ThinkingBudget, req.ToolChoice)
+		if err != nil {
+			return nil, err
+		}
+		fields = map[string]any{"thinking": thinking}
+	default:
+		input.InferenceConfig.Temperature = aws.Float32(float32(m.config.Temperature))
+
+		if m.config.TopP > 0 {
+			input.InferenceConfig.TopP = aws.Float32(float32(m.config.TopP))
+		}
+
+		if m.config.TopK > 0 {
+			switch {
+			case isClaudeModel(m.foundationModel()):
+				fields = map[string]any{"top_k": m.config.TopK}
+			case isNovaModel(m.foundationModel()):
+				fields = map[string]any{"inferenceConfig": map[string]any{"topK": m.config.TopK}}
+			}
+		}
+	}
+
+	if fields != nil {
+		input.AdditionalModelRequestFields = document.NewLazyDocument(fields)
+	}
+
+	if len(m.config.StopSequences) > 0 {
+		input.InferenceConfig.StopSequences = m.config.StopSequences
+	}
+
+	system, conversation, err := splitSystemMessages(req.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := m.prepareMedia(conversation)
+	if err != nil {
+		return nil, err
+	}
+
+	if system != "" {
+		input.System = []types.SystemContentBlock{
+			&types.SystemContentBlockMemberText{Value: system},
+		}
+		if m.cacheSystem(req.Messages) {
+			input.System = append(input.System, &types.SystemContentBlockMemberCachePoint{
+				Value: converseCachePoint(),
+			})
+		}
+	}
+
+	for _, msg := range conversation {
+		if len(msg.Content) == 0 {
+			continue
+		}
+
+		content, err := convertConverseParts(msg, docs)
+		if err != nil {
+			return nil, err
+		}
+
+		role := types.ConversationRoleUser
+		if msg.Role == ai.RoleModel {
+			role = types.ConversationRoleAssistant
+		}
+
+		input.Messages = append(input.Messages, types.Message{
+			Role:    role,
+			Content: content,
+		})
+	}
+
+	// ToolChoiceNone is only enforced by omitting the tools, which Converse
+	// rejects once the history has tool blocks
+	if len(req.Tools) > 0 && (req.ToolChoice != ai.ToolChoiceNone || hasToolParts(req.Messages)) {
+		input.ToolConfig = m.convertConverseTools(req.Tools, req.ToolChoice)
+	}
+
+	return input, nil
+}
+
+// convertConverseParts converts GenKit message parts to Converse content blocks
+func convertConverseParts(msg *ai.Message, docs documents) ([]types.ContentBlock, error) {
+	var blocks []types.ContentBlock
+	for i, part := range msg.Content {
+		switch {
+		case part.IsText():
+			blocks = append(blocks, &types.ContentBlockMemberText{Value: part.Text})
+		case docs[part] != nil:
+			doc := docs[part]
+			blocks = append(blocks, &types.ContentBlockMemberDocument{
+				Value: types.DocumentBlock{
+					Format: types.DocumentFormat(doc.format),
+					Name:   aws.String(doc.name),
+					Source: &types.DocumentSourceMemberBytes{Value: doc.data},
+				},
+			})
+		case isVideoPart(part):
+			video, err := converseVideoBlock(part)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, video)
+		case part.IsMedia():
+			image, format, err := decodeImage(part, converseMaxImageBytes)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, &types.ContentBlockMemberImage{
+				Value: types.ImageBlock{
+					Format: types.ImageFormat(format),
+					Source: &types.ImageSourceMemberBytes{Value: image.data},
+				},
+			})
+		case part.IsReasoning():
+			blocks = append(blocks, &types.ContentBlockMemberReasoningContent{
+				Value: converseReasoningBlock(part),
+			})
+		case part.IsToolRequest():
+			blocks = append(blocks, &types.ContentBlockMemberToolUse{
+				Value: types.ToolUseBlock{
+					ToolUseId: aws.String(part.ToolRequest.Ref),
+					Name:      aws.String(part.ToolRequest.Name),
+					Input:     document.NewLazyDocument(toolInput(part.ToolRequest.Input)),
+				},
+			})
+		case part.IsToolResponse():
+			blocks = append(blocks, &types.ContentBlockMemberToolResult{
+				Value: types.ToolResultBlock{
+					ToolUseId: aws.String(part.ToolResponse.Ref),
+					Content: []types.ToolResultContentBlock{
+						&types.ToolResultContentBlockMemberJson{
+							Value: document.NewLazyDocument(jsonObject(part.ToolResponse.Output)),
+						},
+					},
+				},
+			})
+		default:
+			return nil, fmt.Errorf("unsupported part kind %d for Converse", part.Kind)
+		}
+
+		if cachePointAfter(msg, i) {
+			blocks = append(blocks, &types.ContentBlockMemberCachePoint{Value: converseCachePoint()})
+		}
+	}
+	return blocks, nil
+}
+
+// converseReasoningBlock converts a reasoning part back to the reasoning
+// block it came from. Redacted reasoning is carried as base64, matching the
+// form Claude returns it in through InvokeModel.
+func converseReasoningBlock(part *ai.Part) types.ReasoningContentBlock {
+	if data, ok := part.Metadata["redactedData"].(string); ok {
+		if redacted, err := base64.StdEncoding.DecodeString(data); err == nil {
+			return &types.ReasoningContentBlockMemberRedactedContent{Value: redacted}
+		}
+	}
+
+	block := types.ReasoningTextBlock{Text: aws.String(part.Text)}
+	if signature := reasoningSignature(part); signature != "" {
+		block.Signature = aws.String(signature)
+	}
+	return &types.ReasoningContentBlockMemberReasoningText{Value: block}
+}
+
+// convertConverseTools converts GenKit tool definitions to a Converse tool
+// configuration. Converse has no "none" tool choice, so ai.ToolChoiceNone only
+// leaves the choice unset; the model can still call the tools, which Converse
+// requires whenever the history holds tool blocks.
+func (m *Model) convertConverseTools(tools []*ai.ToolDefinition, choice ai.ToolChoice) *types.ToolConfiguration {
+	config := &types.ToolConfiguration{}
+	for i, tool := range tools {
+		config.Tools = append(config.Tools, &types.ToolMemberToolSpec{
+			Value: types.ToolSpecification{
+				Name:        aws.String(tool.Name),
+				Description: aws.String(tool.Description),
+				InputSchema: &types.ToolInputSchemaMemberJson{
+					Value: document.NewLazyDocument(toolSchema(tool.InputSchema)),
+				},
+			},
+		})
+
+		if m.cacheToolAfter(tools, i) {
+			config.Tools = append(config.Tools, &types.ToolMemberCachePoint{Value: converseCachePoint()})
+		}
+	}
+
+	switch choice {
+	case ai.ToolChoiceRequired:
+		config.ToolChoice = &types.ToolChoiceMemberAny{}
+	case ai.ToolChoiceAuto:
+		config.ToolChoice = &types.ToolChoiceMemberAuto{}
+	}
+
+	return config
+}
+
+// convertConverseResponse converts a Converse output to GenKit format
+func convertConverseResponse(output *bedrockruntime.ConverseOutput) (*ai.ModelResponse, error) {
+	msg, ok := output.Output.(*types.ConverseOutputMemberMessage)
+	if !ok {
+		return nil, fmt.Errorf("no message in Converse response")
+	}
+
+	var content []*ai.Part
+	for _, block := range msg.Value.Content {
+		switch b := block.(type) {
+		case *types.ContentBlockMemberText:
+			content = append(content, ai.NewTextPart(b.Value))
+		case *types.ContentBlockMemberReasoningContent:
+			switch r := b.Value.(type) {
+			case *types.ReasoningContentBlockMemberReasoningText:
+				content = append(content, ai.NewReasoningPart(aws.ToString(r.Value.Text), []byte(aws.ToString(r.Value.Signature))))
+			case *types.ReasoningContentBlockMemberRedactedContent:
+				part := ai.NewReasoningPart("", nil)
+				part.Metadata["redactedData"] = base64.StdEncoding.EncodeToString(r.Value)
+				content = append(content, part)
+			}
+		case *types.ContentBlockMemberToolUse:
+			input, err := decodeDocument(b.Value.Input)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode tool input: %w", err)
+			}
+			content = append(content, ai.NewToolRequestPart(&ai.ToolRequest{
+				Name:  aws.ToString(b.Value.Name),
+				Ref:   aws.ToString(b.Value.ToolUseId),
+				Input: input,
+			}))
+		}
+	}
+
+	if len(content) == 0 {
+		return nil, fmt.Errorf("no content in Converse response")
+	}
+
+	response := &ai.ModelResponse{
+		Message: &ai.Message{
+			Role:    "model",
+			Content: content,
+		},
+		Usage:         &ai.GenerationUsage{},
+		FinishReason:  finishReason(string(output.StopReason)),
+		FinishMessage: string(output.StopReason),
+	}
+
+	if output.Usage != nil {
+		response.Usage = converseUsage(output.Usage)
+	}
+
+	var trace any
+	if output.Trace != nil && output.Trace.Guardrail != nil {
+		trace = output.Trace.Guardrail
+	}
+	applyGuardrail(response, converseGuardrailAction(output.StopReason), trace)
+
+	return response, nil
+}
+
+// readConverseStream consumes ConverseStream events until the channel is closed
+func readConverseStream(ctx context.Context, events <-chan types.ConverseStreamOutput, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+	state := &streamState{}
+
+	for event := range events {
+		switch e := event.(type) {
+		case *types.ConverseStreamOutputMemberContentBlockStart:
+			if start, ok := e.Value.Start.(*types.ContentBlockStartMemberToolUse); ok {
+				if err := state.startToolUse(aws.ToString(start.Value.ToolUseId), aws.ToString(start.Value.Name)); err != nil {
+					return nil, err
+				}
+			}
+		case *types.ConverseStreamOutputMemberContentBlockDelta:
+			switch delta := e.Value.Delta.(type) {
+			case *types.ContentBlockDeltaMemberText:
+				if err := state.emitText(ctx, delta.Value, cb); err != nil {
+					return nil, err
+				}
+			case *types.ContentBlockDeltaMemberToolUse:
+				state.appendToolInput(aws.ToString(delta.Value.Input))
+			case *types.ContentBlockDeltaMemberReasoningContent:
+				switch r := delta.Value.(type) {
+				case *types.ReasoningContentBlockDeltaMemberText:
+					state.appendReasoning(r.Value)
+					if err := state.emitReasoning(ctx, cb); err != nil {
+						return nil, err
+					}
+				case *types.ReasoningContentBlockDeltaMemberSignature:
+					state.signature = r.Value
+				case *types.ReasoningContentBlockDeltaMemberRedactedContent:
+					state.redacted = base64.StdEncoding.EncodeToString(r.Value)
+				}
+			}
+		case *types.ConverseStreamOutputMemberContentBlockStop:
+			if err := state.stopBlock(); err != nil {
+				return nil, err
+			}
+		case *types.ConverseStreamOutputMemberMessageStop:
+			state.stopReason = string(e.Value.StopReason)
+			state.guardrailAction = converseGuardrailAction(e.Value.StopReason)
+		case *types.ConverseStreamOutputMemberMetadata:
+			if e.Value.Usage != nil {
+				state.inputTokens = int(aws.ToInt32(e.Value.Usage.InputTokens))
+				state.outputTokens = int(aws.ToInt32(e.Value.Usage.OutputTokens))
+				state.cacheRead = int(aws.ToInt32(e.Value.Usage.CacheReadInputTokens))
+				state.cacheWrite = int(aws.ToInt32(e.Value.Usage.CacheWriteInputTokens))
+			}
+			if e.Value.Trace != nil && e.Value.Trace.Guardrail != nil {
+				state.guardrailTrace = e.Value.Trace.Guardrail
+			}
+		}
+	}
+
+	return state.response()
+}
+
+// smithyDocument is a Smithy document from any AWS service client
+type smithyDocument interface {
+	MarshalSmithyDocument() ([]byte, error)
+}
+
+// decodeDocument converts a Smithy document to plain Go values. Documents are
+// round-tripped through JSON because lazy documents cannot be unmarshaled
+// directly.
+func decodeDocument(doc smithyDocument) (any, error) {
+	if doc == nil {
+		return nil, nil
+	}
+
+	data, err := doc.MarshalSmithyDocument()
+	if err != nil {
+		return nil, err
+	}
+
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// converseUsage converts Converse token usage to GenKit usage
+func converseUsage(usage *types.TokenUsage) *ai.GenerationUsage {
+	input := int(aws.ToInt32(usage.InputTokens))
+	output := int(aws.ToInt32(usage.OutputTokens))
+	return cacheUsage(&ai.GenerationUsage{
+		InputTokens:  input,
+		OutputTokens: output,
+		TotalTokens:  input + output,
+	}, int(aws.ToInt32(usage.CacheReadInputTokens)), int(aws.ToInt32(usage.CacheWriteInputTokens)))
+}
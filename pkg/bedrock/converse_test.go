@@ -0,0 +1,461 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Model_UseConverse(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *Config
+		modelID  string
+		expected bool
+	}{
+		{
+			name:     "disabled by default",
+			config:   &Config{},
+			modelID:  "amazon.nova-pro-v1:0",
+			expected: false,
+		},
+		{
+			name:     "enabled globally",
+			config:   &Config{UseConverse: true},
+			modelID:  "mistral.mistral-large-2407-v1:0",
+			expected: true,
+		},
+		{
+			name: "enabled per model",
+			config: &Config{
+				ModelConfigs: map[string]*ModelConfig{
+					"amazon.nova-pro-v1:0": {UseConverse: true},
+				},
+			},
+			modelID:  "amazon.nova-pro-v1:0",
+			expected: true,
+		},
+		{
+			name: "enabled for a different model",
+			config: &Config{
+				ModelConfigs: map[string]*ModelConfig{
+					"amazon.nova-pro-v1:0": {UseConverse: true},
+				},
+			},
+			modelID:  "amazon.nova-lite-v1:0",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{config: tt.config}
+			assert.Equal(t, tt.expected, client.Model(tt.modelID).useConverse)
+		})
+	}
+}
+
+func TestModel_convertConverseRequest(t *testing.T) {
+	model := &Model{
+		modelID: "mistral.mistral-large-2407-v1:0",
+		config: &ModelConfig{
+			MaxTokens:     1024,
+			Temperature:   0.5,
+			TopP:          0.9,
+			StopSequences: []string{"END"},
+		},
+	}
+
+	req := &ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewSystemTextMessage("You are terse."),
+			ai.NewUserTextMessage("What's the weather in Paris?"),
+			ai.NewModelMessage(ai.NewToolRequestPart(&ai.ToolRequest{
+				Name:  "weather",
+				Ref:   "tool-1",
+				Input: map[string]any{"city": "Paris"},
+			})),
+			ai.NewMessage(ai.RoleTool, nil, ai.NewToolResponsePart(&ai.ToolResponse{
+				Name:   "weather",
+				Ref:    "tool-1",
+				Output: "sunny",
+			})),
+		},
+		Tools: []*ai.ToolDefinition{
+			{
+				Name:        "weather",
+				Description: "Look up the weather",
+				InputSchema: map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"city": map[string]any{"type": "string"}},
+				},
+			},
+		},
+		ToolChoice: ai.ToolChoiceRequired,
+	}
+
+	input, err := model.convertConverseRequest(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, "mistral.mistral-large-2407-v1:0", aws.ToString(input.ModelId))
+	require.NotNil(t, input.InferenceConfig)
+	assert.Equal(t, int32(1024), aws.ToInt32(input.InferenceConfig.MaxTokens))
+	assert.Equal(t, float32(0.5), aws.ToFloat32(input.InferenceConfig.Temperature))
+	assert.Equal(t, float32(0.9), aws.ToFloat32(input.InferenceConfig.TopP))
+	assert.Equal(t, []string{"END"}, input.InferenceConfig.StopSequences)
+
+	require.Len(t, input.System, 1)
+	assert.Equal(t, "You are terse.", input.System[0].(*types.SystemContentBlockMemberText).Value)
+
+	require.Len(t, input.Messages, 3)
+	assert.Equal(t, types.ConversationRoleUser, input.Messages[0].Role)
+	assert.Equal(t, "What's the weather in Paris?", input.Messages[0].Content[0].(*types.ContentBlockMemberText).Value)
+
+	assert.Equal(t, types.ConversationRoleAssistant, input.Messages[1].Role)
+	toolUse := input.Messages[1].Content[0].(*types.ContentBlockMemberToolUse)
+	assert.Equal(t, "weather", aws.ToString(toolUse.Value.Name))
+	assert.Equal(t, "tool-1", aws.ToString(toolUse.Value.ToolUseId))
+
+	assert.Equal(t, types.ConversationRoleUser, input.Messages[2].Role)
+	toolResult := input.Messages[2].Content[0].(*types.ContentBlockMemberToolResult)
+	assert.Equal(t, "tool-1", aws.ToString(toolResult.Value.ToolUseId))
+	output, err := decodeDocument(toolResult.Value.Content[0].(*types.ToolResultContentBlockMemberJson).Value)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"result": "sunny"}, output)
+
+	require.NotNil(t, input.ToolConfig)
+	require.Len(t, input.ToolConfig.Tools, 1)
+	spec := input.ToolConfig.Tools[0].(*types.ToolMemberToolSpec)
+	assert.Equal(t, "weather", aws.ToString(spec.Value.Name))
+	assert.IsType(t, &types.ToolChoiceMemberAny{}, input.ToolConfig.ToolChoice)
+}
+
+func TestModel_convertConverseRequest_ToolChoiceNone(t *testing.T) {
+	model := &Model{modelID: "amazon.nova-pro-v1:0", config: &ModelConfig{}}
+	tools := []*ai.ToolDefinition{{Name: "weather"}}
+
+	input, err := model.convertConverseRequest(&ai.ModelRequest{
+		Messages:   []*ai.Message{ai.NewUserTextMessage("Hi")},
+		Tools:      tools,
+		ToolChoice: ai.ToolChoiceNone,
+	})
+	require.NoError(t, err)
+	assert.Nil(t, input.ToolConfig)
+
+	// A history with tool blocks is rejected without the specs, so they are
+	// sent with no tool choice and None is not enforced
+	input, err = model.convertConverseRequest(&ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewUserTextMessage("What's the weather in Paris?"),
+			ai.NewModelMessage(ai.NewToolRequestPart(&ai.ToolRequest{Name: "weather", Ref: "tool-1"})),
+			ai.NewMessage(ai.RoleTool, nil, ai.NewToolResponsePart(&ai.ToolResponse{Name: "weather", Ref: "tool-1", Output: "sunny"})),
+		},
+		Tools:      tools,
+		ToolChoice: ai.ToolChoiceNone,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, input.ToolConfig)
+	assert.Len(t, input.ToolConfig.Tools, 1)
+	assert.Nil(t, input.ToolConfig.ToolChoice)
+}
+
+func TestModel_convertConverseRequest_Video(t *testing.T) {
+	model := &Model{modelID: "amazon.nova-lite-v1:0", config: &ModelConfig{UseConverse: true}}
+
+	s3Video := ai.NewMediaPart("video/mp4", "s3://moderation-inbox/clips/0001.mp4")
+	s3Video.Metadata = map[string]any{"bucketOwner": "111122223333"}
+
+	for _, tt := range []struct {
+		name     string
+		part     *ai.Part
+		expected types.VideoBlock
+	}{
+		{
+			name: "inline",
+			part: ai.NewMediaPart("", "data:video/webm;base64,AAEC"),
+			expected: types.VideoBlock{
+				Format: types.VideoFormatWebm,
+				Source: &types.VideoSourceMemberBytes{Value: []byte{0, 1, 2}},
+			},
+		},
+		{
+			name: "s3",
+			part: s3Video,
+			expected: types.VideoBlock{
+				Format: types.VideoFormatMp4,
+				Source: &types.VideoSourceMemberS3Location{Value: types.S3Location{
+					Uri:         aws.String("s3://moderation-inbox/clips/0001.mp4"),
+					BucketOwner: aws.String("111122223333"),
+				}},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			input, err := model.convertConverseRequest(&ai.ModelRequest{
+				Messages: []*ai.Message{ai.NewUserMessage(tt.part, ai.NewTextPart("Describe the clip"))},
+			})
+			require.NoError(t, err)
+
+			require.Len(t, input.Messages, 1)
+			require.Len(t, input.Messages[0].Content, 2)
+			assert.Equal(t, &types.ContentBlockMemberVideo{Value: tt.expected}, input.Messages[0].Content[0])
+		})
+	}
+}
+
+func TestModel_convertConverseRequest_NoMessages(t *testing.T) {
+	model := &Model{modelID: "amazon.nova-pro-v1:0", config: &ModelConfig{}}
+
+	_, err := model.convertConverseRequest(&ai.ModelRequest{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no messages in request")
+}
+
+func TestModel_convertConverseRequest_SystemMidConversation(t *testing.T) {
+	model := &Model{modelID: "amazon.nova-pro-v1:0", config: &ModelConfig{}}
+
+	_, err := model.convertConverseRequest(&ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewUserTextMessage("Hi"),
+			ai.NewSystemTextMessage("Be terse."),
+		},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must precede the conversation")
+}
+
+func TestModel_convertConverseRequest_TopK(t *testing.T) {
+	req := &ai.ModelRequest{Messages: []*ai.Message{ai.NewUserTextMessage("Hi")}}
+
+	claude := &Model{modelID: "anthropic.claude-3-haiku-20240307-v1:0", config: &ModelConfig{TopK: 50}}
+	input, err := claude.convertConverseRequest(req)
+	require.NoError(t, err)
+	fields, err := decodeDocument(input.AdditionalModelRequestFields)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"top_k": float64(50)}, fields)
+
+	nova := &Model{modelID: "amazon.nova-lite-v1:0", config: &ModelConfig{TopK: 50}}
+	input, err = nova.convertConverseRequest(req)
+	require.NoError(t, err)
+	fields, err = decodeDocument(input.AdditionalModelRequestFields)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"inferenceConfig": map[string]any{"topK": float64(50)}}, fields)
+}
+
+func TestModel_convertConverseRequest_Thinking(t *testing.T) {
+	model := &Model{
+		modelID: "us.anthropic.claude-sonnet-4-20250514-v1:0",
+		config:  &ModelConfig{MaxTokens: 8192, Temperature: 0.5, TopK: 50, ThinkingBudget: 2048},
+	}
+
+	redacted := ai.NewReasoningPart("", nil)
+	redacted.Metadata["redactedData"] = "aGlkZGVu"
+
+	input, err := model.convertConverseRequest(&ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewUserTextMessage("Hi"),
+			ai.NewModelMessage(ai.NewReasoningPart("Greet back.", []byte("sig-1")), redacted, ai.NewTextPart("Hello!")),
+			ai.NewUserTextMessage("How are you?"),
+		},
+	})
+	require.NoError(t, err)
+
+	fields, err := decodeDocument(input.AdditionalModelRequestFields)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"thinking": map[string]any{"type": "enabled", "budget_tokens": float64(2048)},
+	}, fields)
+	assert.Nil(t, input.InferenceConfig.Temperature)
+	assert.Nil(t, input.InferenceConfig.TopP)
+
+	content := input.Messages[1].Content
+	require.Len(t, content, 3)
+	assert.Equal(t, &types.ContentBlockMemberReasoningContent{
+		Value: &types.ReasoningContentBlockMemberReasoningText{Value: types.ReasoningTextBlock{
+			Text:      aws.String("Greet back."),
+			Signature: aws.String("sig-1"),
+		}},
+	}, content[0])
+	assert.Equal(t, &types.ContentBlockMemberReasoningContent{
+		Value: &types.ReasoningContentBlockMemberRedactedContent{Value: []byte("hidden")},
+	}, content[1])
+}
+
+func TestConvertConverseResponse_Reasoning(t *testing.T) {
+	output := &bedrockruntime.ConverseOutput{
+		Output: &types.ConverseOutputMemberMessage{
+			Value: types.Message{
+				Role: types.ConversationRoleAssistant,
+				Content: []types.ContentBlock{
+					&types.ContentBlockMemberReasoningContent{
+						Value: &types.ReasoningContentBlockMemberReasoningText{Value: types.ReasoningTextBlock{
+							Text:      aws.String("The user greets me."),
+							Signature: aws.String("sig-1"),
+						}},
+					},
+					&types.ContentBlockMemberReasoningContent{
+						Value: &types.ReasoningContentBlockMemberRedactedContent{Value: []byte("hidden")},
+					},
+					&types.ContentBlockMemberText{Value: "Hello!"},
+				},
+			},
+		},
+		StopReason: types.StopReasonEndTurn,
+	}
+
+	result, err := convertConverseResponse(output)
+	require.NoError(t, err)
+
+	require.Len(t, result.Message.Content, 3)
+	assert.True(t, result.Message.Content[0].IsReasoning())
+	assert.Equal(t, "The user greets me.", result.Message.Content[0].Text)
+	assert.Equal(t, "sig-1", reasoningSignature(result.Message.Content[0]))
+	assert.True(t, result.Message.Content[1].IsReasoning())
+	assert.Equal(t, "aGlkZGVu", result.Message.Content[1].Metadata["redactedData"])
+	assert.Equal(t, "Hello!", result.Message.Content[2].Text)
+}
+
+func TestConvertConverseResponse(t *testing.T) {
+	output := &bedrockruntime.ConverseOutput{
+		Output: &types.ConverseOutputMemberMessage{
+			Value: types.Message{
+				Role: types.ConversationRoleAssistant,
+				Content: []types.ContentBlock{
+					&types.ContentBlockMemberText{Value: "Let me check."},
+					&types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+						Name:      aws.String("weather"),
+						ToolUseId: aws.String("tool-1"),
+						Input:     document.NewLazyDocument(map[string]any{"city": "Paris"}),
+					}},
+				},
+			},
+		},
+		StopReason: types.StopReasonToolUse,
+		Usage: &types.TokenUsage{
+			InputTokens:  aws.Int32(20),
+			OutputTokens: aws.Int32(15),
+			TotalTokens:  aws.Int32(35),
+		},
+	}
+
+	result, err := convertConverseResponse(output)
+	require.NoError(t, err)
+
+	require.NotNil(t, result.Message)
+	require.Len(t, result.Message.Content, 2)
+	assert.Equal(t, "Let me check.", result.Message.Content[0].Text)
+	require.True(t, result.Message.Content[1].IsToolRequest())
+	assert.Equal(t, "weather", result.Message.Content[1].ToolRequest.Name)
+	assert.Equal(t, "tool-1", result.Message.Content[1].ToolRequest.Ref)
+	assert.Equal(t, map[string]any{"city": "Paris"}, result.Message.Content[1].ToolRequest.Input)
+
+	assert.Equal(t, 20, result.Usage.InputTokens)
+	assert.Equal(t, 15, result.Usage.OutputTokens)
+	assert.Equal(t, 35, result.Usage.TotalTokens)
+	assert.Equal(t, ai.FinishReasonStop, result.FinishReason)
+	assert.Equal(t, "tool_use", result.FinishMessage)
+}
+
+func TestReadConverseStream(t *testing.T) {
+	events := make(chan types.ConverseStreamOutput, 10)
+	events <- &types.ConverseStreamOutputMemberMessageStart{}
+	events <- &types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+		ContentBlockIndex: aws.Int32(0),
+		Delta:             &types.ContentBlockDeltaMemberText{Value: "Checking"},
+	}}
+	events <- &types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+		ContentBlockIndex: aws.Int32(0),
+		Delta:             &types.ContentBlockDeltaMemberText{Value: " now."},
+	}}
+	events <- &types.ConverseStreamOutputMemberContentBlockStop{}
+	events <- &types.ConverseStreamOutputMemberContentBlockStart{Value: types.ContentBlockStartEvent{
+		ContentBlockIndex: aws.Int32(1),
+		Start: &types.ContentBlockStartMemberToolUse{Value: types.ToolUseBlockStart{
+			Name:      aws.String("weather"),
+			ToolUseId: aws.String("tool-1"),
+		}},
+	}}
+	events <- &types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+		ContentBlockIndex: aws.Int32(1),
+		Delta:             &types.ContentBlockDeltaMemberToolUse{Value: types.ToolUseBlockDelta{Input: aws.String(`{"city":`)}},
+	}}
+	events <- &types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+		ContentBlockIndex: aws.Int32(1),
+		Delta:             &types.ContentBlockDeltaMemberToolUse{Value: types.ToolUseBlockDelta{Input: aws.String(`"Paris"}`)}},
+	}}
+	events <- &types.ConverseStreamOutputMemberContentBlockStop{}
+	events <- &types.ConverseStreamOutputMemberMessageStop{Value: types.MessageStopEvent{StopReason: types.StopReasonToolUse}}
+	events <- &types.ConverseStreamOutputMemberMetadata{Value: types.ConverseStreamMetadataEvent{
+		Usage: &types.TokenUsage{InputTokens: aws.Int32(9), OutputTokens: aws.Int32(6)},
+	}}
+	close(events)
+
+	var deltas []string
+	cb := func(_ context.Context, chunk *ai.ModelResponseChunk) error {
+		deltas = append(deltas, chunk.Content[0].Text)
+		return nil
+	}
+
+	result, err := readConverseStream(context.Background(), events, cb)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Checking", " now."}, deltas)
+	require.Len(t, result.Message.Content, 2)
+	assert.Equal(t, "Checking now.", result.Message.Content[0].Text)
+	require.True(t, result.Message.Content[1].IsToolRequest())
+	assert.Equal(t, "weather", result.Message.Content[1].ToolRequest.Name)
+	assert.Equal(t, map[string]any{"city": "Paris"}, result.Message.Content[1].ToolRequest.Input)
+	assert.Equal(t, 9, result.Usage.InputTokens)
+	assert.Equal(t, 6, result.Usage.OutputTokens)
+	assert.Equal(t, 15, result.Usage.TotalTokens)
+	assert.Equal(t, ai.FinishReasonStop, result.FinishReason)
+}
+
+func TestReadConverseStream_Reasoning(t *testing.T) {
+	events := make(chan types.ConverseStreamOutput, 10)
+	events <- &types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+		Delta: &types.ContentBlockDeltaMemberReasoningContent{Value: &types.ReasoningContentBlockDeltaMemberText{Value: "Think"}},
+	}}
+	events <- &types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+		Delta: &types.ContentBlockDeltaMemberReasoningContent{Value: &types.ReasoningContentBlockDeltaMemberText{Value: "ing."}},
+	}}
+	events <- &types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+		Delta: &types.ContentBlockDeltaMemberReasoningContent{Value: &types.ReasoningContentBlockDeltaMemberSignature{Value: "sig-1"}},
+	}}
+	events <- &types.ConverseStreamOutputMemberContentBlockStop{}
+	events <- &types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+		Delta: &types.ContentBlockDeltaMemberText{Value: "Done."},
+	}}
+	events <- &types.ConverseStreamOutputMemberContentBlockStop{}
+	close(events)
+
+	var chunks []*ai.Part
+	cb := func(_ context.Context, chunk *ai.ModelResponseChunk) error {
+		chunks = append(chunks, chunk.Content...)
+		return nil
+	}
+
+	result, err := readConverseStream(context.Background(), events, cb)
+	require.NoError(t, err)
+
+	require.Len(t, chunks, 3)
+	assert.True(t, chunks[0].IsReasoning())
+	assert.Equal(t, "Think", chunks[0].Text)
+	assert.Equal(t, "Done.", chunks[2].Text)
+
+	require.Len(t, result.Message.Content, 2)
+	assert.True(t, result.Message.Content[0].IsReasoning())
+	assert.Equal(t, "Thinking.", result.Message.Content[0].Text)
+	assert.Equal(t, "sig-1", reasoningSignature(result.Message.Content[0]))
+	assert.Equal(t, "Done.", result.Message.Content[1].Text)
+}
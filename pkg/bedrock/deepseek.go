@@ -0,0 +1,202 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// DeepSeek-R1 chat template tokens. The prompt ends inside an open think
+// tag, so the output is the reasoning, a closing tag and then the answer.
+const (
+	deepSeekBegin     = "<｜begin▁of▁sentence｜>"
+	deepSeekEnd       = "<｜end▁of▁sentence｜>"
+	deepSeekUser      = "<｜User｜>"
+	deepSeekAssistant = "<｜Assistant｜>"
+	deepSeekThink     = "<think>\n"
+	deepSeekThinkEnd  = "</think>"
+)
+
+// DeepSeek-specific request conversion
+func (m *Model) convertDeepSeekRequest(req *ai.ModelRequest) ([]byte, error) {
+	if len(req.Messages) == 0 {
+		return nil, fmt.Errorf("no messages in request")
+	}
+
+	if _, err := m.prepareMedia(req.Messages); err != nil {
+		return nil, err
+	}
+
+	system, conversation, err := splitSystemMessages(req.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt, err := formatDeepSeekPrompt(system, conversation)
+	if err != nil {
+		return nil, err
+	}
+
+	deepSeekReq := map[string]interface{}{
+		"prompt":      prompt,
+		"max_tokens":  m.config.MaxTokens,
+		"temperature": m.config.Temperature,
+	}
+
+	if m.config.TopP > 0 {
+		deepSeekReq["top_p"] = m.config.TopP
+	}
+
+	if len(m.config.StopSequences) > 0 {
+		deepSeekReq["stop"] = m.config.StopSequences
+	}
+
+	return json.Marshal(deepSeekReq)
+}
+
+// formatDeepSeekPrompt renders a conversation in the DeepSeek-R1 chat
+// template. Earlier reasoning is left out of the history, as DeepSeek
+// recommends, and the prompt always primes a new reasoning turn, so it cannot
+// continue a trailing model message.
+func formatDeepSeekPrompt(system string, messages []*ai.Message) (string, error) {
+	if len(messages) == 0 || messages[len(messages)-1].Role == ai.RoleModel {
+		return "", fmt.Errorf("conversation must end with a user message for DeepSeek-R1")
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString(deepSeekBegin)
+	prompt.WriteString(system)
+
+	for _, msg := range messages {
+		var text strings.Builder
+		for _, part := range msg.Content {
+			switch {
+			case part.IsText():
+				text.WriteString(part.Text)
+			case part.IsReasoning():
+				// Earlier reasoning is not resent
+			default:
+				return "", fmt.Errorf("unsupported part kind %d for DeepSeek", part.Kind)
+			}
+		}
+
+		if msg.Role == ai.RoleModel {
+			prompt.WriteString(deepSeekAssistant)
+			prompt.WriteString(text.String())
+			prompt.WriteString(deepSeekEnd)
+		} else {
+			prompt.WriteString(deepSeekUser)
+			prompt.WriteString(text.String())
+		}
+	}
+
+	prompt.WriteString(deepSeekAssistant)
+	prompt.WriteString(deepSeekThink)
+	return prompt.String(), nil
+}
+
+// deepSeekSplitter separates DeepSeek-R1 reasoning from its answer as output
+// arrives. The prompt opens a think block, so everything before the closing
+// tag is reasoning, including all of the output when the block is never
+// closed (for example when max_tokens cuts it short). Reasoning is trimmed of
+// surrounding whitespace and the answer of leading newlines; whitespace and a
+// partial closing tag at the end of a write are held back until the next
+// write or flush shows what they belong to.
+type deepSeekSplitter struct {
+	answering bool
+	started   bool
+	pending   string
+}
+
+// write consumes the next piece of output and returns the reasoning and
+// answer that are ready
+func (d *deepSeekSplitter) write(text string) (reasoning, answer string) {
+	if d.answering {
+		if !d.started {
+			text = strings.TrimLeft(text, "\n")
+			d.started = text != ""
+		}
+		return "", text
+	}
+
+	text = d.pending + text
+	d.pending = ""
+	if !d.started {
+		text = strings.TrimLeftFunc(text, unicode.IsSpace)
+		text = strings.TrimLeftFunc(strings.TrimPrefix(text, "<think>"), unicode.IsSpace)
+		if text == "" {
+			return "", ""
+		}
+		d.started = true
+	}
+
+	if before, after, found := strings.Cut(text, deepSeekThinkEnd); found {
+		d.answering = true
+		d.started = false
+		_, answer = d.write(after)
+		return strings.TrimRightFunc(before, unicode.IsSpace), answer
+	}
+
+	held := 0
+	for n := len(deepSeekThinkEnd) - 1; n > 0; n-- {
+		if strings.HasSuffix(text, deepSeekThinkEnd[:n]) {
+			held = n
+			break
+		}
+	}
+	reasoning = strings.TrimRightFunc(text[:len(text)-held], unicode.IsSpace)
+	d.pending = text[len(reasoning):]
+	return reasoning, ""
+}
+
+// flush returns the reasoning held back at the end of the output
+func (d *deepSeekSplitter) flush() string {
+	reasoning := strings.TrimRightFunc(d.pending, unicode.IsSpace)
+	d.pending = ""
+	return reasoning
+}
+
+// DeepSeek-specific response conversion
+func (m *Model) convertDeepSeekResponse(body []byte) (*ai.ModelResponse, error) {
+	var deepSeekResp struct {
+		Choices []struct {
+			Text       string `json:"text"`
+			StopReason string `json:"stop_reason"`
+		} `json:"choices"`
+	}
+
+	if err := json.Unmarshal(body, &deepSeekResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal DeepSeek response: %w", err)
+	}
+
+	if len(deepSeekResp.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in DeepSeek response")
+	}
+
+	choice := deepSeekResp.Choices[0]
+	var splitter deepSeekSplitter
+	reasoning, answer := splitter.write(choice.Text)
+	reasoning += splitter.flush()
+
+	var content []*ai.Part
+	if reasoning != "" {
+		content = append(content, ai.NewReasoningPart(reasoning, nil))
+	}
+	content = append(content, ai.NewTextPart(answer))
+
+	return &ai.ModelResponse{
+		Message: &ai.Message{
+			Role:    "model",
+			Content: content,
+		},
+		Usage:         &ai.GenerationUsage{},
+		FinishReason:  finishReason(choice.StopReason),
+		FinishMessage: choice.StopReason,
+	}, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatDeepSeekPrompt(t *testing.T) {
+	prompt, err := formatDeepSeekPrompt("Be brief.", []*ai.Message{
+		ai.NewUserTextMessage("What is 2 + 2?"),
+		ai.NewModelMessage(ai.NewReasoningPart("Simple addition.", nil), ai.NewTextPart("4")),
+		ai.NewUserTextMessage("And 3 + 3?"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t,
+		"<｜begin▁of▁sentence｜>Be brief.<｜User｜>What is 2 + 2?<｜Assistant｜>4<｜end▁of▁sentence｜>"+
+			"<｜User｜>And 3 + 3?<｜Assistant｜><think>\n",
+		prompt)
+
+	_, err = formatDeepSeekPrompt("", []*ai.Message{
+		ai.NewUserTextMessage("Count to three."),
+		ai.NewModelTextMessage("One,"),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must end with a user message")
+}
+
+func TestModel_convertDeepSeekRequest(t *testing.T) {
+	model := &Model{
+		modelID: "us.deepseek.r1-v1:0",
+		config:  &ModelConfig{MaxTokens: 4096, Temperature: 0.6, TopP: 0.95, StopSequences: []string{"###"}},
+	}
+
+	result, err := model.convertRequest(&ai.ModelRequest{
+		Messages: []*ai.Message{ai.NewUserTextMessage("Hi")},
+	})
+	require.NoError(t, err)
+
+	var deepSeekReq map[string]interface{}
+	require.NoError(t, json.Unmarshal(result, &deepSeekReq))
+	assert.Equal(t, "<｜begin▁of▁sentence｜><｜User｜>Hi<｜Assistant｜><think>\n", deepSeekReq["prompt"])
+	assert.Equal(t, float64(4096), deepSeekReq["max_tokens"])
+	assert.Equal(t, 0.6, deepSeekReq["temperature"])
+	assert.Equal(t, 0.95, deepSeekReq["top_p"])
+	assert.Equal(t, []interface{}{"###"}, deepSeekReq["stop"])
+}
+
+func TestModel_convertDeepSeekResponse(t *testing.T) {
+	model := &Model{modelID: "us.deepseek.r1-v1:0", config: &ModelConfig{}}
+
+	tests := []struct {
+		name          string
+		text          string
+		wantReasoning string
+		wantAnswer    string
+	}{
+		{
+			name:          "reasoning and answer",
+			text:          "The user wants a sum.\n2 + 2 = 4.\n</think>\n\nThe answer is 4.",
+			wantReasoning: "The user wants a sum.\n2 + 2 = 4.",
+			wantAnswer:    "The answer is 4.",
+		},
+		{
+			name:          "unclosed reasoning",
+			text:          "<think>\nThe user wants a sum. 2 + 2 </",
+			wantReasoning: "The user wants a sum. 2 + 2 </",
+		},
+		{
+			name:       "empty reasoning",
+			text:       "\n</think>\n\nThe answer is 4.",
+			wantAnswer: "The answer is 4.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(map[string]any{
+				"choices": []map[string]any{{"text": tt.text, "stop_reason": "stop"}},
+			})
+			require.NoError(t, err)
+
+			result, err := model.convertResponse(body)
+			require.NoError(t, err)
+
+			content := result.Message.Content
+			if tt.wantReasoning != "" {
+				require.Len(t, content, 2)
+				assert.True(t, content[0].IsReasoning())
+				assert.Equal(t, tt.wantReasoning, content[0].Text)
+				content = content[1:]
+			}
+			require.Len(t, content, 1)
+			assert.Equal(t, tt.wantAnswer, content[0].Text)
+			assert.Equal(t, ai.FinishReasonStop, result.FinishReason)
+		})
+	}
+
+	_, err := model.convertResponse([]byte(`{"choices":[]}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no choices")
+}
+
+func TestHeaderUsage(t *testing.T) {
+	model := &Model{modelID: "us.deepseek.r1-v1:0", config: &ModelConfig{}}
+	result, err := model.convertResponse([]byte(`{"choices":[{"text":"Sum.</think>4","stop_reason":"stop"}]}`))
+	require.NoError(t, err)
+
+	header := http.Header{}
+	header.Set("X-Amzn-Bedrock-Input-Token-Count", "12")
+	header.Set("X-Amzn-Bedrock-Output-Token-Count", "5")
+	headerUsage(result.Usage, header)
+	assert.Equal(t, &ai.GenerationUsage{InputTokens: 12, OutputTokens: 5, TotalTokens: 17}, result.Usage)
+
+	// Counts from the body take precedence
+	usage := &ai.GenerationUsage{InputTokens: 3, OutputTokens: 1, TotalTokens: 4}
+	headerUsage(usage, header)
+	assert.Equal(t, 3, usage.InputTokens)
+}
@@ -0,0 +1,155 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// Document limits enforced by Bedrock per request
+const (
+	maxDocuments     = 5
+	maxDocumentBytes = 4_500_000
+)
+
+// documentFormats maps supported document MIME types to Bedrock format names
+var documentFormats = map[string]string{
+	"application/pdf":    "pdf",
+	"text/csv":           "csv",
+	"application/msword": "doc",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": "docx",
+	"application/vnd.ms-excel": "xls",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": "xlsx",
+	"text/html":     "html",
+	"text/plain":    "txt",
+	"text/markdown": "md",
+}
+
+// documentData is a validated document part ready to be sent to Bedrock
+type documentData struct {
+	*mediaData
+	name   string
+	format string
+}
+
+// documents holds the validated documents of a request, keyed by part
+type documents map[*ai.Part]*documentData
+
+// prepareMedia validates the media parts of a conversation against what the
+// model accepts and decodes its documents, assigning each a unique name as
+// Bedrock requires
+func (m *Model) prepareMedia(messages []*ai.Message) (documents, error) {
+	docs := documents{}
+	names := map[string]bool{}
+	videos := 0
+
+	for _, msg := range messages {
+		for _, part := range msg.Content {
+			if !part.IsMedia() {
+				continue
+			}
+
+			if isVideoPart(part) {
+				if !m.acceptsVideo() {
+					return nil, fmt.Errorf("model %s does not accept video input", m.modelID)
+				}
+				if videos++; videos > maxVideosPerPrompt {
+					return nil, fmt.Errorf("request has more than %d video", maxVideosPerPrompt)
+				}
+				continue
+			}
+
+			media, err := decodeMedia(part)
+			if err != nil {
+				return nil, err
+			}
+
+			format, ok := documentFormats[media.mimeType]
+			if !ok {
+				if !isVisionModel(m.foundationModel()) {
+					return nil, fmt.Errorf("model %s does not accept media input", m.modelID)
+				}
+				continue
+			}
+
+			if !m.acceptsDocuments() {
+				return nil, fmt.Errorf("model %s does not accept document input", m.modelID)
+			}
+
+			if len(docs) == maxDocuments {
+				return nil, fmt.Errorf("request has more than %d documents", maxDocuments)
+			}
+
+			if len(media.data) > maxDocumentBytes {
+				return nil, fmt.Errorf("document is %d bytes, exceeding the %d byte limit", len(media.data), maxDocumentBytes)
+			}
+
+			name := sanitizeDocumentName(documentName(part))
+			if name == "" {
+				name = fmt.Sprintf("document-%d", len(docs)+1)
+			}
+			base := name
+			for i := 2; names[name]; i++ {
+				name = fmt.Sprintf("%s (%d)", base, i)
+			}
+			names[name] = true
+
+			docs[part] = &documentData{mediaData: media, name: name, format: format}
+		}
+	}
+
+	return docs, nil
+}
+
+// acceptsDocuments reports whether the model accepts document input
+func (m *Model) acceptsDocuments() bool {
+	if m.useConverse {
+		return true
+	}
+	foundation := m.foundationModel()
+	return (isClaudeModel(foundation) || isNovaModel(foundation)) && isVisionModel(foundation)
+}
+
+// documentName returns the caller-supplied file name of a document part
+func documentName(part *ai.Part) string {
+	for _, key := range []string{"name", "filename"} {
+		if name, ok := part.Metadata[key].(string); ok && name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// sanitizeDocumentName reduces a file name to the characters Bedrock allows
+// in document names: alphanumerics, single spaces, hyphens, parentheses and
+// square brackets
+func sanitizeDocumentName(name string) string {
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(name), "."))
+	for _, format := range documentFormats {
+		if ext == format {
+			name = strings.TrimSuffix(name, path.Ext(name))
+			break
+		}
+	}
+
+	sanitized := strings.Map(func(r rune) rune {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			return r
+		case unicode.IsSpace(r):
+			return ' '
+		case strings.ContainsRune("-()[]", r):
+			return r
+		default:
+			return '-'
+		}
+	}, name)
+
+	return strings.Join(strings.Fields(sanitized), " ")
+}
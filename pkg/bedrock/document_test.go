@@ -0,0 +1,178 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// documentPart returns a media part carrying the given document
+func documentPart(mimeType, name, contents string) *ai.Part {
+	part := ai.NewMediaPart(mimeType, base64.StdEncoding.EncodeToString([]byte(contents)))
+	if name != "" {
+		part.Metadata = map[string]any{"name": name}
+	}
+	return part
+}
+
+func TestSanitizeDocumentName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"contract.pdf", "contract"},
+		{"Q3_report final.v2.xlsx", "Q3-report final-v2"},
+		{"  notes \t (draft)  [v1].md", "notes (draft) [v1]"},
+		{"résumé.docx", "r-sum-"},
+		{"archive.tar", "archive-tar"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, sanitizeDocumentName(tt.input))
+		})
+	}
+}
+
+func TestModel_prepareMedia(t *testing.T) {
+	model := &Model{modelID: "anthropic.claude-3-5-sonnet-20241022-v2:0", config: &ModelConfig{}}
+
+	pdf := documentPart("application/pdf", "contract.pdf", "%PDF-1.7")
+	pdfAgain := documentPart("application/pdf", "contract.pdf", "%PDF-1.7")
+	csv := documentPart("text/csv", "", "a,b\n1,2\n")
+	image := ai.NewMediaPart("image/png", pngHeader)
+
+	docs, err := model.prepareMedia([]*ai.Message{
+		ai.NewUserMessage(ai.NewTextPart("Compare these"), pdf, pdfAgain, csv, image),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, docs, 3)
+	assert.Equal(t, "contract", docs[pdf].name)
+	assert.Equal(t, "pdf", docs[pdf].format)
+	assert.Equal(t, "contract (2)", docs[pdfAgain].name)
+	assert.Equal(t, "document-3", docs[csv].name)
+	assert.Equal(t, "csv", docs[csv].format)
+	assert.Nil(t, docs[image])
+}
+
+func TestModel_prepareMedia_Limits(t *testing.T) {
+	model := &Model{modelID: "amazon.nova-pro-v1:0", config: &ModelConfig{}}
+
+	t.Run("too many documents", func(t *testing.T) {
+		var parts []*ai.Part
+		for i := 0; i <= maxDocuments; i++ {
+			parts = append(parts, documentPart("text/plain", "", "hello"))
+		}
+		_, err := model.prepareMedia([]*ai.Message{ai.NewUserMessage(parts...)})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "more than 5 documents")
+	})
+
+	t.Run("document too large", func(t *testing.T) {
+		part := documentPart("application/pdf", "big.pdf", strings.Repeat("x", maxDocumentBytes+1))
+		_, err := model.prepareMedia([]*ai.Message{ai.NewUserMessage(part)})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "byte limit")
+	})
+
+	t.Run("model without document support", func(t *testing.T) {
+		llama := &Model{modelID: "meta.llama3-2-90b-instruct-v1:0", config: &ModelConfig{}}
+		_, err := llama.prepareMedia([]*ai.Message{
+			ai.NewUserMessage(documentPart("application/pdf", "a.pdf", "%PDF")),
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not accept document input")
+	})
+}
+
+func TestModel_convertRequest_Document(t *testing.T) {
+	req := &ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewUserMessage(
+				ai.NewTextPart("Summarise the attachments"),
+				documentPart("application/pdf", "contract.pdf", "%PDF-1.7"),
+				documentPart("text/csv", "sales.csv", "a,b\n1,2\n"),
+			),
+		},
+	}
+
+	t.Run("claude", func(t *testing.T) {
+		model := &Model{modelID: "anthropic.claude-3-5-sonnet-20241022-v2:0", config: &ModelConfig{}}
+		result, err := model.convertClaudeRequest(req)
+		require.NoError(t, err)
+
+		var claudeReq map[string]interface{}
+		require.NoError(t, json.Unmarshal(result, &claudeReq))
+		blocks := claudeReq["messages"].([]interface{})[0].(map[string]interface{})["content"].([]interface{})
+		require.Len(t, blocks, 3)
+		assert.Equal(t, map[string]interface{}{
+			"type":  "document",
+			"title": "contract",
+			"source": map[string]interface{}{
+				"type":       "base64",
+				"media_type": "application/pdf",
+				"data":       base64.StdEncoding.EncodeToString([]byte("%PDF-1.7")),
+			},
+		}, blocks[1])
+		assert.Equal(t, map[string]interface{}{
+			"type":  "document",
+			"title": "sales",
+			"source": map[string]interface{}{
+				"type":       "text",
+				"media_type": "text/plain",
+				"data":       "a,b\n1,2\n",
+			},
+		}, blocks[2])
+	})
+
+	t.Run("claude rejects office formats", func(t *testing.T) {
+		model := &Model{modelID: "anthropic.claude-3-5-sonnet-20241022-v2:0", config: &ModelConfig{}}
+		_, err := model.convertClaudeRequest(&ai.ModelRequest{
+			Messages: []*ai.Message{ai.NewUserMessage(documentPart(
+				"application/vnd.openxmlformats-officedocument.wordprocessingml.document", "memo.docx", "PK"))},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "enable UseConverse")
+	})
+
+	t.Run("nova", func(t *testing.T) {
+		model := &Model{modelID: "amazon.nova-pro-v1:0", config: &ModelConfig{}}
+		result, err := model.convertNovaRequest(req)
+		require.NoError(t, err)
+
+		var novaReq map[string]interface{}
+		require.NoError(t, json.Unmarshal(result, &novaReq))
+		blocks := novaReq["messages"].([]interface{})[0].(map[string]interface{})["content"].([]interface{})
+		require.Len(t, blocks, 3)
+		assert.Equal(t, map[string]interface{}{
+			"document": map[string]interface{}{
+				"format": "csv",
+				"name":   "sales",
+				"source": map[string]interface{}{"bytes": base64.StdEncoding.EncodeToString([]byte("a,b\n1,2\n"))},
+			},
+		}, blocks[2])
+	})
+
+	t.Run("converse", func(t *testing.T) {
+		model := &Model{modelID: "meta.llama3-1-70b-instruct-v1:0", config: &ModelConfig{}, useConverse: true}
+		input, err := model.convertConverseRequest(req)
+		require.NoError(t, err)
+
+		doc := input.Messages[0].Content[1].(*types.ContentBlockMemberDocument)
+		assert.Equal(t, types.DocumentFormatPdf, doc.Value.Format)
+		assert.Equal(t, "contract", aws.ToString(doc.Value.Name))
+		assert.Equal(t, []byte("%PDF-1.7"), doc.Value.Source.(*types.DocumentSourceMemberBytes).Value)
+	})
+}
@@ -0,0 +1,464 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/firebase/genkit/go/ai"
+)
+
+// cohereMaxEmbedTexts is the number of texts Cohere Embed accepts per call
+const cohereMaxEmbedTexts = 96
+
+// EmbedderConfig holds configuration for a specific embedding model
+type EmbedderConfig struct {
+	// Dimensions is the length of the output vectors (Titan Text Embeddings
+	// V2: 256, 512 or 1024; Titan Multimodal Embeddings: 256, 384 or 1024)
+	Dimensions int `json:"dimensions,omitempty"`
+
+	// Normalize controls whether Titan Text Embeddings V2 returns unit
+	// vectors. The model normalizes unless this is set to false.
+	Normalize *bool `json:"normalize,omitempty"`
+
+	// InputType tells Cohere Embed how the vectors will be used:
+	// search_document (the default), search_query, classification or
+	// clustering
+	InputType string `json:"input_type,omitempty"`
+
+	// Truncate controls how Cohere Embed handles texts longer than its
+	// context: NONE, START or END
+	Truncate string `json:"truncate,omitempty"`
+}
+
+// Validate validates an embedder configuration
+func (ec *EmbedderConfig) Validate() error {
+	switch ec.Dimensions {
+	case 0, 256, 384, 512, 1024:
+	default:
+		return errors.New("dimensions must be 256, 384, 512 or 1024")
+	}
+
+	switch ec.InputType {
+	case "", "search_document", "search_query", "classification", "clustering":
+	default:
+		return fmt.Errorf("unsupported input_type %q", ec.InputType)
+	}
+
+	switch ec.Truncate {
+	case "", "NONE", "START", "END":
+	default:
+		return fmt.Errorf("unsupported truncate %q", ec.Truncate)
+	}
+
+	return nil
+}
+
+// overlay returns a copy of the config with every field set in override
+// replacing the corresponding field
+func (ec *EmbedderConfig) overlay(override *EmbedderConfig) *EmbedderConfig {
+	merged := *ec
+
+	if override.Dimensions != 0 {
+		merged.Dimensions = override.Dimensions
+	}
+	if override.Normalize != nil {
+		merged.Normalize = override.Normalize
+	}
+	if override.InputType != "" {
+		merged.InputType = override.InputType
+	}
+	if override.Truncate != "" {
+		merged.Truncate = override.Truncate
+	}
+
+	return &merged
+}
+
+// embedRequestConfig converts the options attached to a GenKit embed
+// request to an EmbedderConfig
+func embedRequestConfig(options any) (*EmbedderConfig, error) {
+	switch o := options.(type) {
+	case nil:
+		return &EmbedderConfig{}, nil
+	case *EmbedderConfig:
+		if o == nil {
+			return &EmbedderConfig{}, nil
+		}
+		return o, nil
+	case EmbedderConfig:
+		return &o, nil
+	case map[string]any:
+		data, err := json.Marshal(o)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode embed options: %w", err)
+		}
+		var config EmbedderConfig
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("failed to decode embed options: %w", err)
+		}
+		return &config, nil
+	default:
+		return nil, fmt.Errorf("unsupported embed options type %T", options)
+	}
+}
+
+// Embedder represents a Bedrock embedding model compatible with GenKit
+type Embedder struct {
+	client     *Client
+	modelID    string
+	foundation string
+	config     *EmbedderConfig
+}
+
+// Embedder returns a GenKit-compatible embedder for the given model ID
+func (c *Client) Embedder(modelID string) *Embedder {
+	config := c.config.EmbedderConfigs[modelID]
+	if config == nil {
+		config = &EmbedderConfig{}
+	}
+
+	// Malformed IDs are reported by Config.Validate
+	foundation, _ := c.config.ResolveModel(modelID)
+
+	return &Embedder{
+		client:     c,
+		modelID:    modelID,
+		foundation: foundation,
+		config:     config,
+	}
+}
+
+// Dimensions reports the length of the vectors the embedder returns, or 0
+// when it is not known
+func (e *Embedder) Dimensions() int {
+	switch {
+	case e.config.Dimensions > 0:
+		return e.config.Dimensions
+	case isTitanEmbedV2Model(e.foundationModel()):
+		return 1024
+	case isTitanEmbedModel(e.foundationModel()):
+		return 1536
+	case isTitanMultimodalEmbedModel(e.foundationModel()):
+		return 1024
+	case isCohereEmbedModel(e.foundationModel()):
+		return 1024
+	default:
+		return 0
+	}
+}
+
+// Supports reports the GenKit capabilities of this embedder
+func (e *Embedder) Supports() *ai.EmbedderSupports {
+	input := []string{"text"}
+	if isTitanMultimodalEmbedModel(e.foundationModel()) {
+		input = append(input, "image")
+	}
+
+	return &ai.EmbedderSupports{
+		Input:        input,
+		Multilingual: isTitanEmbedV2Model(e.foundationModel()) || strings.Contains(strings.ToLower(e.foundationModel()), "multilingual"),
+	}
+}
+
+// Embed implements GenKit's embedding interface
+func (e *Embedder) Embed(ctx context.Context, req *ai.EmbedRequest) (*ai.EmbedResponse, error) {
+	override, err := embedRequestConfig(req.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	config := e.config.overlay(override)
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid embed options: %w", err)
+	}
+
+	// Multimodal documents are embedded whole, one per call
+	if isTitanMultimodalEmbedModel(e.foundationModel()) {
+		return e.embedTitanMultimodal(ctx, req.Input, config)
+	}
+
+	texts := make([]string, 0, len(req.Input))
+	for i, doc := range req.Input {
+		text, err := documentText(doc)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+		texts = append(texts, text)
+	}
+
+	switch {
+	case isTitanEmbedModel(e.foundationModel()):
+		return e.embedTitan(ctx, texts, config)
+	case isCohereEmbedModel(e.foundationModel()):
+		return e.embedCohere(ctx, texts, config)
+	default:
+		return nil, fmt.Errorf("unsupported embedding model: %s", e.modelID)
+	}
+}
+
+// invoke calls the embedding model with a JSON request body
+func (e *Embedder) invoke(ctx context.Context, body []byte) ([]byte, error) {
+	result, err := e.client.runtime.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
+		ModelId:     aws.String(e.modelID),
+		ContentType: aws.String("application/json"),
+		Accept:      aws.String("application/json"),
+		Body:        body,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bedrock invoke failed: %w", err)
+	}
+	return result.Body, nil
+}
+
+// documentText joins the text parts of a document
+func documentText(doc *ai.Document) (string, error) {
+	var text strings.Builder
+	for _, part := range doc.Content {
+		if !part.IsText() {
+			return "", fmt.Errorf("unsupported part kind %d for text embedding", part.Kind)
+		}
+		text.WriteString(part.Text)
+	}
+	return text.String(), nil
+}
+
+// embedTitan embeds each text with its own call, as Titan takes one input
+// per request
+func (e *Embedder) embedTitan(ctx context.Context, texts []string, config *EmbedderConfig) (*ai.EmbedResponse, error) {
+	resp := &ai.EmbedResponse{}
+	for _, text := range texts {
+		body, err := convertTitanEmbedRequest(e.foundationModel(), text, config)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := e.invoke(ctx, body)
+		if err != nil {
+			return nil, err
+		}
+
+		embedding, err := convertTitanEmbedResponse(result)
+		if err != nil {
+			return nil, err
+		}
+		resp.Embeddings = append(resp.Embeddings, embedding)
+	}
+	return resp, nil
+}
+
+// convertTitanEmbedRequest builds a Titan Text Embeddings request. Only V2
+// accepts dimensions and normalization.
+func convertTitanEmbedRequest(modelID, text string, config *EmbedderConfig) ([]byte, error) {
+	titanReq := map[string]interface{}{
+		"inputText": text,
+	}
+
+	if isTitanEmbedV2Model(modelID) {
+		if config.Dimensions == 384 {
+			return nil, fmt.Errorf("dimensions must be 256, 512 or 1024 for Titan Text Embeddings V2")
+		}
+		if config.Dimensions > 0 {
+			titanReq["dimensions"] = config.Dimensions
+		}
+		if config.Normalize != nil {
+			titanReq["normalize"] = *config.Normalize
+		}
+	} else if config.Dimensions > 0 || config.Normalize != nil {
+		return nil, fmt.Errorf("dimensions and normalize are only supported by Titan Text Embeddings V2")
+	}
+
+	return json.Marshal(titanReq)
+}
+
+// convertTitanEmbedResponse parses a Titan Embeddings response
+func convertTitanEmbedResponse(body []byte) (*ai.Embedding, error) {
+	var titanResp struct {
+		Embedding           []float32 `json:"embedding"`
+		InputTextTokenCount int       `json:"inputTextTokenCount"`
+	}
+
+	if err := json.Unmarshal(body, &titanResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Titan embedding response: %w", err)
+	}
+
+	if len(titanResp.Embedding) == 0 {
+		return nil, fmt.Errorf("no embedding in Titan response")
+	}
+
+	return &ai.Embedding{
+		Embedding: titanResp.Embedding,
+		Metadata:  map[string]any{"inputTokens": titanResp.InputTextTokenCount},
+	}, nil
+}
+
+// embedTitanMultimodal embeds each document with its own call
+func (e *Embedder) embedTitanMultimodal(ctx context.Context, docs []*ai.Document, config *EmbedderConfig) (*ai.EmbedResponse, error) {
+	resp := &ai.EmbedResponse{}
+	for i, doc := range docs {
+		body, err := convertTitanMultimodalEmbedRequest(doc, config)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+
+		result, err := e.invoke(ctx, body)
+		if err != nil {
+			return nil, err
+		}
+
+		embedding, err := convertTitanEmbedResponse(result)
+		if err != nil {
+			return nil, err
+		}
+		resp.Embeddings = append(resp.Embeddings, embedding)
+	}
+	return resp, nil
+}
+
+// convertTitanMultimodalEmbedRequest builds a Titan Multimodal Embeddings
+// request from a document holding text, one PNG or JPEG image, or both
+func convertTitanMultimodalEmbedRequest(doc *ai.Document, config *EmbedderConfig) ([]byte, error) {
+	var text strings.Builder
+	var image *mediaData
+	for _, part := range doc.Content {
+		switch {
+		case part.IsText():
+			text.WriteString(part.Text)
+		case part.IsMedia():
+			if image != nil {
+				return nil, fmt.Errorf("titan multimodal embeddings accept one image per document")
+			}
+			media, format, err := decodeImage(part, titanMaxImageBytes)
+			if err != nil {
+				return nil, err
+			}
+			if format != "png" && format != "jpeg" {
+				return nil, fmt.Errorf("unsupported image type %q for Titan, use PNG or JPEG", media.mimeType)
+			}
+			image = media
+		default:
+			return nil, fmt.Errorf("unsupported part kind %d for multimodal embedding", part.Kind)
+		}
+	}
+
+	if text.Len() == 0 && image == nil {
+		return nil, fmt.Errorf("document has no text or image to embed")
+	}
+
+	titanReq := map[string]interface{}{}
+	if text.Len() > 0 {
+		titanReq["inputText"] = text.String()
+	}
+	if image != nil {
+		titanReq["inputImage"] = image.base64()
+	}
+
+	switch config.Dimensions {
+	case 0:
+	case 256, 384, 1024:
+		titanReq["embeddingConfig"] = map[string]interface{}{
+			"outputEmbeddingLength": config.Dimensions,
+		}
+	default:
+		return nil, fmt.Errorf("dimensions must be 256, 384 or 1024 for Titan Multimodal Embeddings")
+	}
+
+	if config.Normalize != nil || config.InputType != "" || config.Truncate != "" {
+		return nil, fmt.Errorf("only dimensions are supported by Titan Multimodal Embeddings")
+	}
+
+	return json.Marshal(titanReq)
+}
+
+// embedCohere embeds the texts in batches of up to 96
+func (e *Embedder) embedCohere(ctx context.Context, texts []string, config *EmbedderConfig) (*ai.EmbedResponse, error) {
+	resp := &ai.EmbedResponse{}
+	for start := 0; start < len(texts); start += cohereMaxEmbedTexts {
+		end := min(start+cohereMaxEmbedTexts, len(texts))
+
+		body, err := convertCohereEmbedRequest(texts[start:end], config)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := e.invoke(ctx, body)
+		if err != nil {
+			return nil, err
+		}
+
+		embeddings, err := convertCohereEmbedResponse(result, end-start)
+		if err != nil {
+			return nil, err
+		}
+		resp.Embeddings = append(resp.Embeddings, embeddings...)
+	}
+	return resp, nil
+}
+
+// convertCohereEmbedRequest builds a Cohere Embed request for a batch of texts
+func convertCohereEmbedRequest(texts []string, config *EmbedderConfig) ([]byte, error) {
+	if config.Dimensions > 0 || config.Normalize != nil {
+		return nil, fmt.Errorf("dimensions and normalize are not supported by Cohere Embed")
+	}
+
+	inputType := config.InputType
+	if inputType == "" {
+		inputType = "search_document"
+	}
+
+	cohereReq := map[string]interface{}{
+		"texts":      texts,
+		"input_type": inputType,
+	}
+
+	if config.Truncate != "" {
+		cohereReq["truncate"] = config.Truncate
+	}
+
+	return json.Marshal(cohereReq)
+}
+
+// convertCohereEmbedResponse parses a Cohere Embed response, which must
+// hold one vector per text sent
+func convertCohereEmbedResponse(body []byte, count int) ([]*ai.Embedding, error) {
+	var cohereResp struct {
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+
+	if err := json.Unmarshal(body, &cohereResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Cohere embedding response: %w", err)
+	}
+
+	if len(cohereResp.Embeddings) != count {
+		return nil, fmt.Errorf("cohere returned %d embeddings for %d texts", len(cohereResp.Embeddings), count)
+	}
+
+	embeddings := make([]*ai.Embedding, 0, count)
+	for _, vector := range cohereResp.Embeddings {
+		embeddings = append(embeddings, &ai.Embedding{Embedding: vector})
+	}
+	return embeddings, nil
+}
+
+func isTitanEmbedModel(modelID string) bool {
+	return strings.Contains(strings.ToLower(modelID), "titan-embed-text")
+}
+
+func isTitanMultimodalEmbedModel(modelID string) bool {
+	return strings.Contains(strings.ToLower(modelID), "titan-embed-image")
+}
+
+func isTitanEmbedV2Model(modelID string) bool {
+	return strings.Contains(strings.ToLower(modelID), "titan-embed-text-v2")
+}
+
+func isCohereEmbedModel(modelID string) bool {
+	return strings.Contains(strings.ToLower(modelID), "cohere.embed")
+}
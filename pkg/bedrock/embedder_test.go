@@ -0,0 +1,319 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbedderConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *EmbedderConfig
+		wantErr string
+	}{
+		{
+			name:   "empty",
+			config: &EmbedderConfig{},
+		},
+		{
+			name:   "titan v2",
+			config: &EmbedderConfig{Dimensions: 512, Normalize: aws.Bool(false)},
+		},
+		{
+			name:   "cohere",
+			config: &EmbedderConfig{InputType: "search_query", Truncate: "END"},
+		},
+		{
+			name:    "invalid dimensions",
+			config:  &EmbedderConfig{Dimensions: 768},
+			wantErr: "dimensions must be 256, 384, 512 or 1024",
+		},
+		{
+			name:    "invalid input type",
+			config:  &EmbedderConfig{InputType: "query"},
+			wantErr: `unsupported input_type "query"`,
+		},
+		{
+			name:    "invalid truncate",
+			config:  &EmbedderConfig{Truncate: "MIDDLE"},
+			wantErr: `unsupported truncate "MIDDLE"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("plugin config", func(t *testing.T) {
+		config := &Config{
+			Models:          []string{"anthropic.claude-3-5-sonnet-20241022-v2:0"},
+			EmbedderConfigs: map[string]*EmbedderConfig{"amazon.titan-embed-text-v2:0": {Dimensions: 100}},
+		}
+		err := config.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid config for embedder amazon.titan-embed-text-v2:0")
+	})
+}
+
+func TestEmbedRequestConfig(t *testing.T) {
+	config, err := embedRequestConfig(map[string]any{"dimensions": 256, "normalize": false})
+	require.NoError(t, err)
+	assert.Equal(t, 256, config.Dimensions)
+	require.NotNil(t, config.Normalize)
+	assert.False(t, *config.Normalize)
+
+	base := &EmbedderConfig{InputType: "search_document", Truncate: "END"}
+	merged := base.overlay(&EmbedderConfig{InputType: "search_query"})
+	assert.Equal(t, &EmbedderConfig{InputType: "search_query", Truncate: "END"}, merged)
+
+	_, err = embedRequestConfig("search_query")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported embed options type string")
+}
+
+func TestClient_Embedder(t *testing.T) {
+	client := &Client{config: &Config{
+		EmbedderConfigs: map[string]*EmbedderConfig{"amazon.titan-embed-text-v2:0": {Dimensions: 256}},
+	}}
+
+	tests := []struct {
+		modelID          string
+		wantDimensions   int
+		wantMultilingual bool
+	}{
+		{"amazon.titan-embed-text-v2:0", 256, true},
+		{"amazon.titan-embed-text-v1", 1536, false},
+		{"cohere.embed-english-v3", 1024, false},
+		{"cohere.embed-multilingual-v3", 1024, true},
+		{"amazon.titan-embed-image-v1", 1024, false},
+		{"AMAZON.TITAN-EMBED-TEXT-V1", 1536, false},
+		{"COHERE.EMBED-MULTILINGUAL-V3", 1024, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.modelID, func(t *testing.T) {
+			embedder := client.Embedder(tt.modelID)
+			assert.Equal(t, tt.wantDimensions, embedder.Dimensions())
+			assert.Equal(t, tt.wantMultilingual, embedder.Supports().Multilingual)
+		})
+	}
+
+	t.Run("multimodal input", func(t *testing.T) {
+		assert.Equal(t, []string{"text", "image"}, client.Embedder("amazon.titan-embed-image-v1").Supports().Input)
+		assert.Equal(t, []string{"text", "image"}, client.Embedder("AMAZON.TITAN-EMBED-IMAGE-V1").Supports().Input)
+		assert.Equal(t, []string{"text"}, client.Embedder("amazon.titan-embed-text-v2:0").Supports().Input)
+	})
+
+	t.Run("unsupported model", func(t *testing.T) {
+		_, err := client.Embedder("amazon.titan-text-express-v1").Embed(context.Background(), &ai.EmbedRequest{
+			Input: []*ai.Document{ai.DocumentFromText("hello", nil)},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported embedding model")
+	})
+
+	t.Run("non-text document", func(t *testing.T) {
+		_, err := client.Embedder("cohere.embed-english-v3").Embed(context.Background(), &ai.EmbedRequest{
+			Input: []*ai.Document{{Content: []*ai.Part{ai.NewMediaPart("image/png", "data:image/png;base64,AAAA")}}},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "document 0")
+	})
+}
+
+func TestConvertTitanEmbedRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		modelID string
+		config  *EmbedderConfig
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{
+			name:    "v1",
+			modelID: "amazon.titan-embed-text-v1",
+			config:  &EmbedderConfig{},
+			want:    map[string]interface{}{"inputText": "hello"},
+		},
+		{
+			name:    "v2 with options",
+			modelID: "amazon.titan-embed-text-v2:0",
+			config:  &EmbedderConfig{Dimensions: 512, Normalize: aws.Bool(true)},
+			want:    map[string]interface{}{"inputText": "hello", "dimensions": float64(512), "normalize": true},
+		},
+		{
+			name:    "v2 with multimodal dimensions",
+			modelID: "amazon.titan-embed-text-v2:0",
+			config:  &EmbedderConfig{Dimensions: 384},
+			wantErr: "dimensions must be 256, 512 or 1024",
+		},
+		{
+			name:    "v1 with dimensions",
+			modelID: "amazon.titan-embed-text-v1",
+			config:  &EmbedderConfig{Dimensions: 512},
+			wantErr: "only supported by Titan Text Embeddings V2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := convertTitanEmbedRequest(tt.modelID, "hello", tt.config)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var titanReq map[string]interface{}
+			require.NoError(t, json.Unmarshal(body, &titanReq))
+			assert.Equal(t, tt.want, titanReq)
+		})
+	}
+}
+
+func TestConvertTitanEmbedResponse(t *testing.T) {
+	embedding, err := convertTitanEmbedResponse([]byte(`{"embedding":[0.25,-0.5,1],"inputTextTokenCount":3}`))
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0.25, -0.5, 1}, embedding.Embedding)
+	assert.Equal(t, 3, embedding.Metadata["inputTokens"])
+
+	_, err = convertTitanEmbedResponse([]byte(`{"embedding":[]}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no embedding")
+}
+
+func TestConvertCohereEmbedRequest(t *testing.T) {
+	body, err := convertCohereEmbedRequest([]string{"first", "second"}, &EmbedderConfig{Truncate: "END"})
+	require.NoError(t, err)
+
+	var cohereReq map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &cohereReq))
+	assert.Equal(t, map[string]interface{}{
+		"texts":      []interface{}{"first", "second"},
+		"input_type": "search_document",
+		"truncate":   "END",
+	}, cohereReq)
+
+	_, err = convertCohereEmbedRequest([]string{"first"}, &EmbedderConfig{Dimensions: 256})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not supported by Cohere Embed")
+}
+
+func TestConvertCohereEmbedResponse(t *testing.T) {
+	body := []byte(`{"id":"abc","embeddings":[[0.1,0.2],[0.3,0.4]],"texts":["first","second"]}`)
+
+	embeddings, err := convertCohereEmbedResponse(body, 2)
+	require.NoError(t, err)
+	require.Len(t, embeddings, 2)
+	assert.Equal(t, []float32{0.3, 0.4}, embeddings[1].Embedding)
+
+	_, err = convertCohereEmbedResponse(body, 3)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "2 embeddings for 3 texts")
+}
+
+func TestConvertTitanMultimodalEmbedRequest(t *testing.T) {
+	png := "data:image/png;base64," + base64.StdEncoding.EncodeToString([]byte("\x89PNG\r\n\x1a\n"))
+	gif := "data:image/gif;base64," + base64.StdEncoding.EncodeToString([]byte("GIF89a"))
+
+	tests := []struct {
+		name    string
+		doc     *ai.Document
+		config  *EmbedderConfig
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{
+			name:   "text only",
+			doc:    ai.DocumentFromText("red running shoes", nil),
+			config: &EmbedderConfig{},
+			want:   map[string]interface{}{"inputText": "red running shoes"},
+		},
+		{
+			name:   "image only",
+			doc:    &ai.Document{Content: []*ai.Part{ai.NewMediaPart("image/png", png)}},
+			config: &EmbedderConfig{Dimensions: 384},
+			want: map[string]interface{}{
+				"inputImage":      "iVBORw0KGgo=",
+				"embeddingConfig": map[string]interface{}{"outputEmbeddingLength": float64(384)},
+			},
+		},
+		{
+			name: "text and image",
+			doc: &ai.Document{Content: []*ai.Part{
+				ai.NewTextPart("red running shoes"),
+				ai.NewMediaPart("image/png", png),
+			}},
+			config: &EmbedderConfig{},
+			want:   map[string]interface{}{"inputText": "red running shoes", "inputImage": "iVBORw0KGgo="},
+		},
+		{
+			name:    "unsupported image format",
+			doc:     &ai.Document{Content: []*ai.Part{ai.NewMediaPart("image/gif", gif)}},
+			config:  &EmbedderConfig{},
+			wantErr: `unsupported image type "image/gif" for Titan`,
+		},
+		{
+			name: "two images",
+			doc: &ai.Document{Content: []*ai.Part{
+				ai.NewMediaPart("image/png", png),
+				ai.NewMediaPart("image/png", png),
+			}},
+			config:  &EmbedderConfig{},
+			wantErr: "one image per document",
+		},
+		{
+			name:    "empty document",
+			doc:     &ai.Document{},
+			config:  &EmbedderConfig{},
+			wantErr: "no text or image",
+		},
+		{
+			name:    "unsupported dimensions",
+			doc:     ai.DocumentFromText("red running shoes", nil),
+			config:  &EmbedderConfig{Dimensions: 512},
+			wantErr: "dimensions must be 256, 384 or 1024",
+		},
+		{
+			name:    "text embedding options",
+			doc:     ai.DocumentFromText("red running shoes", nil),
+			config:  &EmbedderConfig{InputType: "search_query"},
+			wantErr: "only dimensions are supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := convertTitanMultimodalEmbedRequest(tt.doc, tt.config)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var titanReq map[string]interface{}
+			require.NoError(t, json.Unmarshal(body, &titanReq))
+			assert.Equal(t, tt.want, titanReq)
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/firebase/genkit/go/ai"
+)
+
+// Keys of the guardrail results in ModelResponse.Custom. The action is
+// "INTERVENED" when the guardrail blocked or masked content, and the trace
+// holds its assessment when GuardrailTrace is enabled.
+const (
+	CustomGuardrailAction = "guardrailAction"
+	CustomGuardrailTrace  = "guardrailTrace"
+)
+
+// GuardrailActionIntervened is the guardrail action reported when a
+// guardrail blocked or masked content
+const GuardrailActionIntervened = "INTERVENED"
+
+// Guardrail trace settings
+const (
+	GuardrailTraceEnabled     = "enabled"
+	GuardrailTraceDisabled    = "disabled"
+	GuardrailTraceEnabledFull = "enabled_full"
+)
+
+// validateGuardrail checks the guardrail settings of a model config
+func (mc *ModelConfig) validateGuardrail() error {
+	if mc.GuardrailID == "" && mc.GuardrailVersion != "" {
+		return errors.New("guardrail_version requires guardrail_id")
+	}
+
+	if mc.GuardrailID != "" && mc.GuardrailVersion == "" {
+		return errors.New("guardrail_version is required with guardrail_id (a version number or DRAFT)")
+	}
+
+	switch mc.GuardrailTrace {
+	case "", GuardrailTraceEnabled, GuardrailTraceDisabled, GuardrailTraceEnabledFull:
+	default:
+		return fmt.Errorf("guardrail_trace must be %s, %s or %s", GuardrailTraceEnabled, GuardrailTraceDisabled, GuardrailTraceEnabledFull)
+	}
+
+	return nil
+}
+
+// guardrailConfig returns the Converse guardrail settings, or nil when no
+// guardrail is configured
+func (m *Model) guardrailConfig() *types.GuardrailConfiguration {
+	if m.config.GuardrailID == "" {
+		return nil
+	}
+
+	return &types.GuardrailConfiguration{
+		GuardrailIdentifier: aws.String(m.config.GuardrailID),
+		GuardrailVersion:    aws.String(m.config.GuardrailVersion),
+		Trace:               types.GuardrailTrace(m.config.GuardrailTrace),
+	}
+}
+
+// guardrailStreamConfig returns the ConverseStream guardrail settings, or nil
+// when no guardrail is configured
+func (m *Model) guardrailStreamConfig() *types.GuardrailStreamConfiguration {
+	config := m.guardrailConfig()
+	if config == nil {
+		return nil
+	}
+
+	return &types.GuardrailStreamConfiguration{
+		GuardrailIdentifier: config.GuardrailIdentifier,
+		GuardrailVersion:    config.GuardrailVersion,
+		Trace:               config.Trace,
+	}
+}
+
+// guardrailInvokeTrace returns the InvokeModel trace setting, which is the
+// upper-case form of the Converse one
+func (m *Model) guardrailInvokeTrace() types.Trace {
+	return types.Trace(strings.ToUpper(m.config.GuardrailTrace))
+}
+
+// invokeGuardrail is the guardrail metadata Bedrock adds to InvokeModel
+// response bodies and to the last chunk of a response stream
+type invokeGuardrail struct {
+	Action string `json:"amazon-bedrock-guardrailAction"`
+	Trace  *struct {
+		Guardrail map[string]any `json:"guardrail"`
+	} `json:"amazon-bedrock-trace"`
+}
+
+// parseInvokeGuardrail reads the guardrail metadata from an InvokeModel
+// body, returning nil when there is none
+func parseInvokeGuardrail(body []byte) (*invokeGuardrail, error) {
+	if !bytes.Contains(body, []byte(`"amazon-bedrock-guardrailAction"`)) &&
+		!bytes.Contains(body, []byte(`"amazon-bedrock-trace"`)) {
+		return nil, nil
+	}
+
+	var guardrail invokeGuardrail
+	if err := json.Unmarshal(body, &guardrail); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal guardrail metadata: %w", err)
+	}
+	return &guardrail, nil
+}
+
+// trace returns the guardrail assessment, or nil when there is none
+func (g *invokeGuardrail) trace() any {
+	if g.Trace == nil || g.Trace.Guardrail == nil {
+		return nil
+	}
+	return g.Trace.Guardrail
+}
+
+// applyGuardrail records a guardrail's action and trace on a response. An
+// intervention always finishes the response as blocked.
+func applyGuardrail(resp *ai.ModelResponse, action string, trace any) {
+	if action == "" && trace == nil {
+		return
+	}
+
+	custom, _ := resp.Custom.(map[string]any)
+	if custom == nil {
+		custom = map[string]any{}
+	}
+
+	if action != "" {
+		custom[CustomGuardrailAction] = action
+	}
+	if trace != nil {
+		custom[CustomGuardrailTrace] = trace
+	}
+	resp.Custom = custom
+
+	if action == GuardrailActionIntervened {
+		resp.FinishReason = ai.FinishReasonBlocked
+	}
+}
+
+// converseGuardrailAction returns the guardrail action implied by a
+// Converse stop reason
+func converseGuardrailAction(stopReason types.StopReason) string {
+	if stopReason == types.StopReasonGuardrailIntervened {
+		return GuardrailActionIntervened
+	}
+	return ""
+}
+
+// GuardrailIntervened reports whether a guardrail blocked or masked content
+// in a response
+func GuardrailIntervened(resp *ai.ModelResponse) bool {
+	if resp == nil {
+		return false
+	}
+	custom, _ := resp.Custom.(map[string]any)
+	return custom[CustomGuardrailAction] == GuardrailActionIntervened
+}
@@ -0,0 +1,272 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelConfig_Validate_Guardrail(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *ModelConfig
+		wantErr string
+	}{
+		{
+			name:   "guardrail with version",
+			config: &ModelConfig{GuardrailID: "gr-compliance", GuardrailVersion: "3", GuardrailTrace: GuardrailTraceEnabled},
+		},
+		{
+			name:   "draft guardrail",
+			config: &ModelConfig{GuardrailID: "gr-compliance", GuardrailVersion: "DRAFT"},
+		},
+		{
+			name:    "missing version",
+			config:  &ModelConfig{GuardrailID: "gr-compliance"},
+			wantErr: "guardrail_version is required",
+		},
+		{
+			name:    "version without guardrail",
+			config:  &ModelConfig{GuardrailVersion: "1"},
+			wantErr: "guardrail_version requires guardrail_id",
+		},
+		{
+			name:    "unknown trace",
+			config:  &ModelConfig{GuardrailID: "gr-compliance", GuardrailVersion: "1", GuardrailTrace: "verbose"},
+			wantErr: "guardrail_trace must be",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestModel_forRequest_Guardrail(t *testing.T) {
+	model := &Model{
+		modelID: "amazon.nova-pro-v1:0",
+		config:  &ModelConfig{MaxTokens: 512, GuardrailID: "gr-compliance", GuardrailVersion: "1"},
+	}
+
+	t.Run("request trace", func(t *testing.T) {
+		got, err := model.forRequest(&ai.ModelRequest{
+			Config: map[string]any{"guardrail_trace": "enabled"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "gr-compliance", got.config.GuardrailID)
+		assert.Equal(t, "1", got.config.GuardrailVersion)
+		assert.Equal(t, GuardrailTraceEnabled, got.config.GuardrailTrace)
+	})
+
+	t.Run("configured guardrail cannot be changed", func(t *testing.T) {
+		for name, config := range map[string]any{
+			"empty id":             map[string]any{"guardrail_id": ""},
+			"empty id and version": map[string]any{"guardrail_id": "", "guardrail_version": ""},
+			"different id":         map[string]any{"guardrail_id": "gr-lenient", "guardrail_version": "1"},
+			"different version":    map[string]any{"guardrail_version": "DRAFT"},
+			"typed":                &ModelConfig{GuardrailID: "gr-lenient", GuardrailVersion: "1"},
+		} {
+			_, err := model.forRequest(&ai.ModelRequest{Config: config})
+			require.Error(t, err, name)
+			assert.Contains(t, err.Error(), "cannot be changed per request", name)
+		}
+	})
+
+	t.Run("request guardrail without a configured one", func(t *testing.T) {
+		unguarded := &Model{modelID: "amazon.nova-pro-v1:0", config: &ModelConfig{MaxTokens: 512}}
+		got, err := unguarded.forRequest(&ai.ModelRequest{
+			Config: &ModelConfig{GuardrailID: "gr-strict", GuardrailVersion: "DRAFT"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "gr-strict", got.config.GuardrailID)
+		assert.Equal(t, "DRAFT", got.config.GuardrailVersion)
+
+		_, err = unguarded.forRequest(&ai.ModelRequest{Config: &ModelConfig{GuardrailID: "gr-strict"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "guardrail_version is required")
+	})
+}
+
+func TestModel_Generate_GuardrailUnsupported(t *testing.T) {
+	for _, modelID := range []string{"amazon.nova-canvas-v1:0", "amazon.nova-reel-v1:1"} {
+		t.Run(modelID, func(t *testing.T) {
+			model := &Model{
+				modelID: modelID,
+				config:  &ModelConfig{GuardrailID: "gr-compliance", GuardrailVersion: "1"},
+			}
+			_, err := model.Generate(context.Background(), &ai.ModelRequest{
+				Messages: []*ai.Message{ai.NewUserTextMessage("A fox in the snow")},
+			}, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "guardrails are not supported for model "+modelID)
+		})
+	}
+}
+
+func TestModel_convertConverseRequest_Guardrail(t *testing.T) {
+	req := &ai.ModelRequest{Messages: []*ai.Message{ai.NewUserTextMessage("Hello")}}
+
+	model := &Model{
+		modelID: "amazon.nova-pro-v1:0",
+		config: &ModelConfig{
+			MaxTokens:        512,
+			GuardrailID:      "gr-compliance",
+			GuardrailVersion: "1",
+			GuardrailTrace:   GuardrailTraceEnabledFull,
+		},
+	}
+
+	input, err := model.convertConverseRequest(req)
+	require.NoError(t, err)
+	require.NotNil(t, input.GuardrailConfig)
+	assert.Equal(t, "gr-compliance", aws.ToString(input.GuardrailConfig.GuardrailIdentifier))
+	assert.Equal(t, "1", aws.ToString(input.GuardrailConfig.GuardrailVersion))
+	assert.Equal(t, types.GuardrailTraceEnabledFull, input.GuardrailConfig.Trace)
+
+	stream := model.guardrailStreamConfig()
+	require.NotNil(t, stream)
+	assert.Equal(t, "gr-compliance", aws.ToString(stream.GuardrailIdentifier))
+	assert.Equal(t, types.TraceEnabledFull, model.guardrailInvokeTrace())
+
+	model.config = &ModelConfig{MaxTokens: 512}
+	input, err = model.convertConverseRequest(req)
+	require.NoError(t, err)
+	assert.Nil(t, input.GuardrailConfig)
+	assert.Nil(t, model.guardrailStreamConfig())
+}
+
+func TestModel_convertResponse_Guardrail(t *testing.T) {
+	model := &Model{modelID: "anthropic.claude-3-5-sonnet-20241022-v2:0", config: &ModelConfig{}}
+
+	t.Run("intervened", func(t *testing.T) {
+		body := `{
+			"content": [{"type": "text", "text": "Sorry, I can't help with that."}],
+			"stop_reason": "end_turn",
+			"usage": {"input_tokens": 12, "output_tokens": 8},
+			"amazon-bedrock-guardrailAction": "INTERVENED",
+			"amazon-bedrock-trace": {"guardrail": {"input": {"gr-compliance": {"topicPolicy": {"topics": [{"name": "Investments", "action": "BLOCKED"}]}}}}}
+		}`
+
+		resp, err := model.convertResponse([]byte(body))
+		require.NoError(t, err)
+		assert.Equal(t, ai.FinishReasonBlocked, resp.FinishReason)
+		assert.True(t, GuardrailIntervened(resp))
+
+		custom := resp.Custom.(map[string]any)
+		trace := custom[CustomGuardrailTrace].(map[string]any)
+		assert.Contains(t, trace, "input")
+	})
+
+	t.Run("passed", func(t *testing.T) {
+		body := `{
+			"content": [{"type": "text", "text": "Hello!"}],
+			"stop_reason": "end_turn",
+			"usage": {"input_tokens": 12, "output_tokens": 2},
+			"amazon-bedrock-guardrailAction": "NONE"
+		}`
+
+		resp, err := model.convertResponse([]byte(body))
+		require.NoError(t, err)
+		assert.Equal(t, ai.FinishReasonStop, resp.FinishReason)
+		assert.False(t, GuardrailIntervened(resp))
+		assert.Equal(t, "NONE", resp.Custom.(map[string]any)[CustomGuardrailAction])
+	})
+
+	t.Run("no guardrail", func(t *testing.T) {
+		body := `{"content": [{"type": "text", "text": "Hello!"}], "stop_reason": "end_turn"}`
+
+		resp, err := model.convertResponse([]byte(body))
+		require.NoError(t, err)
+		assert.Nil(t, resp.Custom)
+		assert.False(t, GuardrailIntervened(resp))
+	})
+
+	t.Run("keeps family custom data", func(t *testing.T) {
+		cohere := &Model{modelID: "cohere.command-r-v1:0", config: &ModelConfig{}}
+		body := `{
+			"text": "Blocked.",
+			"finish_reason": "COMPLETE",
+			"citations": [{"start": 0, "end": 7, "text": "Blocked", "document_ids": ["doc_0"]}],
+			"amazon-bedrock-guardrailAction": "INTERVENED"
+		}`
+
+		resp, err := cohere.convertResponse([]byte(body))
+		require.NoError(t, err)
+		custom := resp.Custom.(map[string]any)
+		assert.Contains(t, custom, "citations")
+		assert.Equal(t, GuardrailActionIntervened, custom[CustomGuardrailAction])
+		assert.Equal(t, ai.FinishReasonBlocked, resp.FinishReason)
+	})
+}
+
+func TestModel_readStream_Guardrail(t *testing.T) {
+	model := &Model{modelID: "amazon.nova-pro-v1:0", config: &ModelConfig{}}
+	events := chunkEvents(
+		`{"contentBlockDelta":{"delta":{"text":"Sorry."},"contentBlockIndex":0}}`,
+		`{"messageStop":{"stopReason":"end_turn"},"amazon-bedrock-guardrailAction":"INTERVENED","amazon-bedrock-trace":{"guardrail":{"outputs":[]}},"amazon-bedrock-invocationMetrics":{"inputTokenCount":10,"outputTokenCount":2}}`,
+	)
+
+	resp, err := model.readStream(context.Background(), events, func(context.Context, *ai.ModelResponseChunk) error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, ai.FinishReasonBlocked, resp.FinishReason)
+	assert.True(t, GuardrailIntervened(resp))
+	assert.Contains(t, resp.Custom.(map[string]any), CustomGuardrailTrace)
+	assert.Equal(t, 10, resp.Usage.InputTokens)
+}
+
+func TestConvertConverseResponse_Guardrail(t *testing.T) {
+	assessment := &types.GuardrailTraceAssessment{ActionReason: aws.String("Guardrail blocked.")}
+	output := &bedrockruntime.ConverseOutput{
+		Output: &types.ConverseOutputMemberMessage{Value: types.Message{
+			Role:    types.ConversationRoleAssistant,
+			Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: "Sorry, I can't help with that."}},
+		}},
+		StopReason: types.StopReasonGuardrailIntervened,
+		Trace:      &types.ConverseTrace{Guardrail: assessment},
+	}
+
+	resp, err := convertConverseResponse(output)
+	require.NoError(t, err)
+	assert.Equal(t, ai.FinishReasonBlocked, resp.FinishReason)
+	assert.True(t, GuardrailIntervened(resp))
+	assert.Equal(t, assessment, resp.Custom.(map[string]any)[CustomGuardrailTrace])
+}
+
+func TestReadConverseStream_Guardrail(t *testing.T) {
+	assessment := &types.GuardrailTraceAssessment{ActionReason: aws.String("Guardrail blocked.")}
+	events := make(chan types.ConverseStreamOutput, 4)
+	events <- &types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+		Delta: &types.ContentBlockDeltaMemberText{Value: "Sorry."},
+	}}
+	events <- &types.ConverseStreamOutputMemberContentBlockStop{}
+	events <- &types.ConverseStreamOutputMemberMessageStop{Value: types.MessageStopEvent{
+		StopReason: types.StopReasonGuardrailIntervened,
+	}}
+	events <- &types.ConverseStreamOutputMemberMetadata{Value: types.ConverseStreamMetadataEvent{
+		Trace: &types.ConverseStreamTrace{Guardrail: assessment},
+	}}
+	close(events)
+
+	resp, err := readConverseStream(context.Background(), events, func(context.Context, *ai.ModelResponseChunk) error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, ai.FinishReasonBlocked, resp.FinishReason)
+	assert.True(t, GuardrailIntervened(resp))
+	assert.Equal(t, assessment, resp.Custom.(map[string]any)[CustomGuardrailTrace])
+}
@@ -0,0 +1,526 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/firebase/genkit/go/ai"
+)
+
+// Image generation task types for Nova Canvas and Titan Image Generator
+const (
+	TaskTextImage         = "TEXT_IMAGE"
+	TaskImageVariation    = "IMAGE_VARIATION"
+	TaskInpainting        = "INPAINTING"
+	TaskOutpainting       = "OUTPAINTING"
+	TaskBackgroundRemoval = "BACKGROUND_REMOVAL"
+)
+
+// imageGenMaxImageBytes is the largest input image sent to an image
+// generation model, bounded by the InvokeModel payload limit
+const imageGenMaxImageBytes = 25_000_000
+
+// stabilityDefaultStrength is the SD3.5 image-to-image strength used when
+// none is configured, as the model requires one
+const stabilityDefaultStrength = 0.5
+
+// ImageConfig holds settings for image generation models
+type ImageConfig struct {
+	// TaskType selects the Nova Canvas or Titan task; TEXT_IMAGE by default
+	TaskType string `json:"task_type,omitempty"`
+
+	// Width and Height are the output size in pixels (Nova Canvas, Titan)
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+
+	// AspectRatio is the output shape for Stability models, such as "16:9"
+	AspectRatio string `json:"aspect_ratio,omitempty"`
+
+	// Seed makes generation repeatable; unset uses the model's default
+	Seed int `json:"seed,omitempty"`
+
+	// CfgScale controls how closely the image follows the prompt
+	// (Nova Canvas, Titan)
+	CfgScale float64 `json:"cfg_scale,omitempty"`
+
+	// NegativePrompt describes what the image should not contain
+	NegativePrompt string `json:"negative_prompt,omitempty"`
+
+	// NumberOfImages is how many images to generate (Nova Canvas, Titan: up
+	// to 5; Stability: 1)
+	NumberOfImages int `json:"number_of_images,omitempty"`
+
+	// Quality is standard or premium (Nova Canvas, Titan)
+	Quality string `json:"quality,omitempty"`
+
+	// MaskPrompt describes the region to edit when inpainting or
+	// outpainting, instead of a mask image
+	MaskPrompt string `json:"mask_prompt,omitempty"`
+
+	// OutpaintingMode is DEFAULT or PRECISE
+	OutpaintingMode string `json:"outpainting_mode,omitempty"`
+
+	// SimilarityStrength controls how closely a variation follows its
+	// source images (0.2 to 1.0)
+	SimilarityStrength float64 `json:"similarity_strength,omitempty"`
+
+	// Strength controls how much a Stability image-to-image generation
+	// departs from its source image (0.0 to 1.0). SD3.5 defaults to 0.5,
+	// Stable Image Ultra to the model default.
+	Strength float64 `json:"strength,omitempty"`
+
+	// OutputFormat is png, jpeg or webp for Stability models
+	OutputFormat string `json:"output_format,omitempty"`
+}
+
+// Validate validates an image configuration
+func (ic *ImageConfig) Validate() error {
+	switch ic.TaskType {
+	case "", TaskTextImage, TaskImageVariation, TaskInpainting, TaskOutpainting, TaskBackgroundRemoval:
+	default:
+		return fmt.Errorf("unsupported task_type %q", ic.TaskType)
+	}
+
+	if ic.Width < 0 || ic.Height < 0 {
+		return errors.New("width and height must be non-negative")
+	}
+
+	if ic.NumberOfImages < 0 || ic.NumberOfImages > 5 {
+		return errors.New("number_of_images must be between 1 and 5")
+	}
+
+	if ic.CfgScale < 0 || ic.Seed < 0 {
+		return errors.New("cfg_scale and seed must be non-negative")
+	}
+
+	if ic.SimilarityStrength != 0 && (ic.SimilarityStrength < 0.2 || ic.SimilarityStrength > 1) {
+		return errors.New("similarity_strength must be between 0.2 and 1.0")
+	}
+
+	if ic.Strength < 0 || ic.Strength > 1 {
+		return errors.New("strength must be between 0.0 and 1.0")
+	}
+
+	switch ic.OutputFormat {
+	case "", "png", "jpeg", "webp":
+	default:
+		return fmt.Errorf("unsupported output_format %q", ic.OutputFormat)
+	}
+
+	return nil
+}
+
+// overlay returns a copy of the config with every field set in override
+// replacing the corresponding field
+func (ic *ImageConfig) overlay(override *ImageConfig) *ImageConfig {
+	merged := *ic
+
+	if override.TaskType != "" {
+		merged.TaskType = override.TaskType
+	}
+	if override.Width != 0 {
+		merged.Width = override.Width
+	}
+	if override.Height != 0 {
+		merged.Height = override.Height
+	}
+	if override.AspectRatio != "" {
+		merged.AspectRatio = override.AspectRatio
+	}
+	if override.Seed != 0 {
+		merged.Seed = override.Seed
+	}
+	if override.CfgScale != 0 {
+		merged.CfgScale = override.CfgScale
+	}
+	if override.NegativePrompt != "" {
+		merged.NegativePrompt = override.NegativePrompt
+	}
+	if override.NumberOfImages != 0 {
+		merged.NumberOfImages = override.NumberOfImages
+	}
+	if override.Quality != "" {
+		merged.Quality = override.Quality
+	}
+	if override.MaskPrompt != "" {
+		merged.MaskPrompt = override.MaskPrompt
+	}
+	if override.OutpaintingMode != "" {
+		merged.OutpaintingMode = override.OutpaintingMode
+	}
+	if override.SimilarityStrength != 0 {
+		merged.SimilarityStrength = override.SimilarityStrength
+	}
+	if override.Strength != 0 {
+		merged.Strength = override.Strength
+	}
+	if override.OutputFormat != "" {
+		merged.OutputFormat = override.OutputFormat
+	}
+
+	return &merged
+}
+
+// generateImage runs an image generation, which returns all images from a
+// single call. Streaming callers receive them as one chunk.
+func (m *Model) generateImage(ctx context.Context, req *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+	var body []byte
+	var err error
+	if isStabilityModel(m.foundationModel()) {
+		body, err = m.convertStabilityRequest(req)
+	} else {
+		body, err = m.convertImageRequest(req)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert request: %w", err)
+	}
+
+	result, err := m.client.runtime.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
+		ModelId:     aws.String(m.modelID),
+		ContentType: aws.String("application/json"),
+		Accept:      aws.String("application/json"),
+		Body:        body,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bedrock invoke failed: %w", err)
+	}
+
+	var response *ai.ModelResponse
+	if isStabilityModel(m.foundationModel()) {
+		response, err = m.convertStabilityResponse(result.Body)
+	} else {
+		response, err = convertImageResponse(result.Body)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert response: %w", err)
+	}
+
+	if cb != nil {
+		if err := cb(ctx, &ai.ModelResponseChunk{
+			Role:    "model",
+			Content: response.Message.Content,
+		}); err != nil {
+			return nil, fmt.Errorf("callback failed: %w", err)
+		}
+	}
+
+	return response, nil
+}
+
+// imageInput extracts the prompt and input images from the last message of
+// an image generation request. Image models do not hold a conversation.
+func imageInput(req *ai.ModelRequest) (string, []*mediaData, error) {
+	if len(req.Messages) == 0 {
+		return "", nil, fmt.Errorf("no messages in request")
+	}
+
+	var prompt strings.Builder
+	var images []*mediaData
+	for _, part := range req.Messages[len(req.Messages)-1].Content {
+		switch {
+		case part.IsText():
+			prompt.WriteString(part.Text)
+		case part.IsMedia():
+			image, _, err := decodeImage(part, imageGenMaxImageBytes)
+			if err != nil {
+				return "", nil, err
+			}
+			images = append(images, image)
+		default:
+			return "", nil, fmt.Errorf("unsupported part kind %d for image generation", part.Kind)
+		}
+	}
+
+	return strings.TrimSpace(prompt.String()), images, nil
+}
+
+// Nova Canvas and Titan Image Generator request conversion. The first input
+// image is the source image and a second one, if any, the mask.
+func (m *Model) convertImageRequest(req *ai.ModelRequest) ([]byte, error) {
+	prompt, images, err := imageInput(req)
+	if err != nil {
+		return nil, err
+	}
+
+	config := m.config.imageConfig()
+	taskType := config.TaskType
+	if taskType == "" {
+		taskType = TaskTextImage
+	}
+
+	if taskType == TaskBackgroundRemoval && strings.Contains(m.foundationModel(), "titan-image-generator-v1") {
+		return nil, fmt.Errorf("%s is not supported by %s", taskType, m.modelID)
+	}
+
+	needsImage := taskType != TaskTextImage
+	if needsImage && len(images) == 0 {
+		return nil, fmt.Errorf("%s requires an input image", taskType)
+	}
+	if !needsImage && len(images) > 0 {
+		return nil, fmt.Errorf("%s does not take an input image, set task_type", taskType)
+	}
+	if prompt == "" && taskType != TaskBackgroundRemoval && taskType != TaskImageVariation {
+		return nil, fmt.Errorf("%s requires a text prompt", taskType)
+	}
+
+	imageReq := map[string]interface{}{
+		"taskType": taskType,
+	}
+
+	params := map[string]interface{}{}
+	if prompt != "" && taskType != TaskBackgroundRemoval {
+		params["text"] = prompt
+	}
+	if config.NegativePrompt != "" && taskType != TaskBackgroundRemoval {
+		params["negativeText"] = config.NegativePrompt
+	}
+
+	switch taskType {
+	case TaskTextImage:
+		imageReq["textToImageParams"] = params
+	case TaskImageVariation:
+		sources := make([]string, 0, len(images))
+		for _, image := range images {
+			sources = append(sources, image.base64())
+		}
+		params["images"] = sources
+		if config.SimilarityStrength > 0 {
+			params["similarityStrength"] = config.SimilarityStrength
+		}
+		imageReq["imageVariationParams"] = params
+	case TaskInpainting, TaskOutpainting:
+		params["image"] = images[0].base64()
+		switch {
+		case config.MaskPrompt != "":
+			params["maskPrompt"] = config.MaskPrompt
+		case len(images) > 1:
+			params["maskImage"] = images[1].base64()
+		default:
+			return nil, fmt.Errorf("%s requires a mask_prompt or a mask image", taskType)
+		}
+		if taskType == TaskInpainting {
+			imageReq["inPaintingParams"] = params
+		} else {
+			if config.OutpaintingMode != "" {
+				params["outPaintingMode"] = config.OutpaintingMode
+			}
+			imageReq["outPaintingParams"] = params
+		}
+	case TaskBackgroundRemoval:
+		imageReq["backgroundRemovalParams"] = map[string]interface{}{
+			"image": images[0].base64(),
+		}
+		// Background removal takes no generation settings
+		return json.Marshal(imageReq)
+	}
+
+	generationConfig := map[string]interface{}{}
+	if config.NumberOfImages > 0 {
+		generationConfig["numberOfImages"] = config.NumberOfImages
+	}
+	if config.Width > 0 {
+		generationConfig["width"] = config.Width
+	}
+	if config.Height > 0 {
+		generationConfig["height"] = config.Height
+	}
+	if config.CfgScale > 0 {
+		generationConfig["cfgScale"] = config.CfgScale
+	}
+	if config.Seed > 0 {
+		generationConfig["seed"] = config.Seed
+	}
+	if config.Quality != "" {
+		generationConfig["quality"] = config.Quality
+	}
+	if len(generationConfig) > 0 {
+		imageReq["imageGenerationConfig"] = generationConfig
+	}
+
+	return json.Marshal(imageReq)
+}
+
+// Nova Canvas and Titan Image Generator response conversion. Both return
+// PNG images.
+func convertImageResponse(body []byte) (*ai.ModelResponse, error) {
+	var imageResp struct {
+		Images []string `json:"images"`
+		Error  string   `json:"error"`
+	}
+
+	if err := json.Unmarshal(body, &imageResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal image response: %w", err)
+	}
+
+	if imageResp.Error != "" {
+		return &ai.ModelResponse{
+			Message:       &ai.Message{Role: "model"},
+			FinishReason:  ai.FinishReasonBlocked,
+			FinishMessage: imageResp.Error,
+		}, nil
+	}
+
+	if len(imageResp.Images) == 0 {
+		return nil, fmt.Errorf("no images in image response")
+	}
+
+	return imagesResponse(imageResp.Images, "image/png"), nil
+}
+
+// imagesResponse wraps base64 images as data URI media parts
+func imagesResponse(images []string, mimeType string) *ai.ModelResponse {
+	content := make([]*ai.Part, 0, len(images))
+	for _, image := range images {
+		content = append(content, ai.NewMediaPart(mimeType, "data:"+mimeType+";base64,"+image))
+	}
+
+	return &ai.ModelResponse{
+		Message: &ai.Message{
+			Role:    "model",
+			Content: content,
+		},
+		Usage:        &ai.GenerationUsage{OutputImages: len(images)},
+		FinishReason: ai.FinishReasonStop,
+	}
+}
+
+// Stability-specific request conversion. Stability models return one image
+// per call and are sized by aspect ratio.
+func (m *Model) convertStabilityRequest(req *ai.ModelRequest) ([]byte, error) {
+	prompt, images, err := imageInput(req)
+	if err != nil {
+		return nil, err
+	}
+
+	config := m.config.imageConfig()
+	switch {
+	case prompt == "":
+		return nil, fmt.Errorf("stability models require a text prompt")
+	case len(images) > 1:
+		return nil, fmt.Errorf("stability models accept at most one input image")
+	case config.TaskType != "" && config.TaskType != TaskTextImage:
+		return nil, fmt.Errorf("task_type %s is not supported by Stability models", config.TaskType)
+	case config.NumberOfImages > 1:
+		return nil, fmt.Errorf("stability models generate one image per request")
+	case config.Width > 0 || config.Height > 0:
+		return nil, fmt.Errorf("stability models are sized by aspect_ratio, not width and height")
+	}
+
+	stabilityReq := map[string]interface{}{
+		"prompt": prompt,
+	}
+
+	if config.NegativePrompt != "" {
+		stabilityReq["negative_prompt"] = config.NegativePrompt
+	}
+	if config.Seed > 0 {
+		stabilityReq["seed"] = config.Seed
+	}
+	if config.OutputFormat != "" {
+		stabilityReq["output_format"] = config.OutputFormat
+	}
+
+	// SD3.5 names its mode and needs a strength for image to image; Stable
+	// Image Ultra infers the mode from the image and Core only takes text
+	sd3 := isStabilitySD3Model(m.foundationModel())
+	switch {
+	case len(images) > 0 && isStabilityCoreModel(m.foundationModel()):
+		return nil, fmt.Errorf("an input image is not supported by Stable Image Core")
+	case len(images) > 0:
+		stabilityReq["image"] = images[0].base64()
+		strength := config.Strength
+		if sd3 {
+			stabilityReq["mode"] = "image-to-image"
+			if strength == 0 {
+				strength = stabilityDefaultStrength
+			}
+		}
+		if strength > 0 {
+			stabilityReq["strength"] = strength
+		}
+	default:
+		if sd3 {
+			stabilityReq["mode"] = "text-to-image"
+		}
+		if config.AspectRatio != "" {
+			stabilityReq["aspect_ratio"] = config.AspectRatio
+		}
+	}
+
+	return json.Marshal(stabilityReq)
+}
+
+// Stability-specific response conversion
+func (m *Model) convertStabilityResponse(body []byte) (*ai.ModelResponse, error) {
+	var stabilityResp struct {
+		Images        []string  `json:"images"`
+		Seeds         []int     `json:"seeds"`
+		FinishReasons []*string `json:"finish_reasons"`
+	}
+
+	if err := json.Unmarshal(body, &stabilityResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Stability response: %w", err)
+	}
+
+	// A non-null finish reason means the image was filtered
+	for _, reason := range stabilityResp.FinishReasons {
+		if reason != nil {
+			return &ai.ModelResponse{
+				Message:       &ai.Message{Role: "model"},
+				FinishReason:  ai.FinishReasonBlocked,
+				FinishMessage: *reason,
+			}, nil
+		}
+	}
+
+	if len(stabilityResp.Images) == 0 {
+		return nil, fmt.Errorf("no images in Stability response")
+	}
+
+	mimeType := "image/png"
+	switch m.config.imageConfig().OutputFormat {
+	case "jpeg":
+		mimeType = "image/jpeg"
+	case "webp":
+		mimeType = "image/webp"
+	}
+
+	response := imagesResponse(stabilityResp.Images, mimeType)
+	if len(stabilityResp.Seeds) > 0 {
+		response.Custom = map[string]any{"seeds": stabilityResp.Seeds}
+	}
+	return response, nil
+}
+
+// isImageModel reports whether the model generates images
+func isImageModel(modelID string) bool {
+	return isCanvasModel(modelID) || isTitanImageModel(modelID) || isStabilityModel(modelID)
+}
+
+func isCanvasModel(modelID string) bool {
+	return strings.Contains(strings.ToLower(modelID), "nova-canvas")
+}
+
+func isTitanImageModel(modelID string) bool {
+	return strings.Contains(strings.ToLower(modelID), "titan-image-generator")
+}
+
+func isStabilityModel(modelID string) bool {
+	return strings.Contains(strings.ToLower(modelID), "stability.")
+}
+
+func isStabilitySD3Model(modelID string) bool {
+	return strings.Contains(strings.ToLower(modelID), "stability.sd3")
+}
+
+func isStabilityCoreModel(modelID string) bool {
+	return strings.Contains(strings.ToLower(modelID), "stability.stable-image-core")
+}
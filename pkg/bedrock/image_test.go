@@ -0,0 +1,376 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testImage returns an inline PNG media part and its base64 payload
+func testImage() (*ai.Part, string) {
+	encoded := base64.StdEncoding.EncodeToString([]byte("\x89PNG\r\n\x1a\n"))
+	return ai.NewMediaPart("image/png", "data:image/png;base64,"+encoded), encoded
+}
+
+func TestImageConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *ImageConfig
+		wantErr string
+	}{
+		{"empty", &ImageConfig{}, ""},
+		{"text image", &ImageConfig{TaskType: TaskTextImage, Width: 1024, Height: 1024, NumberOfImages: 2, CfgScale: 6.5}, ""},
+		{"unknown task", &ImageConfig{TaskType: "COLORIZE"}, `unsupported task_type "COLORIZE"`},
+		{"too many images", &ImageConfig{NumberOfImages: 6}, "number_of_images must be between 1 and 5"},
+		{"negative size", &ImageConfig{Width: -1}, "width and height must be non-negative"},
+		{"strength too high", &ImageConfig{Strength: 1.5}, "strength must be between 0.0 and 1.0"},
+		{"similarity strength too low", &ImageConfig{SimilarityStrength: 0.1}, "similarity_strength must be between 0.2 and 1.0"},
+		{"similarity strength minimum", &ImageConfig{SimilarityStrength: 0.2}, ""},
+		{"unknown output format", &ImageConfig{OutputFormat: "tiff"}, `unsupported output_format "tiff"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestModel_forRequest_ImageConfig(t *testing.T) {
+	base := &Model{
+		modelID: "amazon.nova-canvas-v1:0",
+		config:  &ModelConfig{Image: &ImageConfig{Width: 1280, Height: 720, Quality: "premium"}},
+	}
+
+	model, err := base.forRequest(&ai.ModelRequest{Config: &ImageConfig{Seed: 42, Width: 1024}})
+	require.NoError(t, err)
+	assert.Equal(t, &ImageConfig{Width: 1024, Height: 720, Quality: "premium", Seed: 42}, model.config.Image)
+	assert.Equal(t, 1280, base.config.Image.Width)
+
+	model, err = base.forRequest(&ai.ModelRequest{Config: map[string]any{"image": map[string]any{"number_of_images": 3}}})
+	require.NoError(t, err)
+	assert.Equal(t, 3, model.config.Image.NumberOfImages)
+
+	_, err = base.forRequest(&ai.ModelRequest{Config: &ImageConfig{NumberOfImages: 9}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid image config")
+}
+
+func TestModel_Supports_Image(t *testing.T) {
+	for _, modelID := range []string{"amazon.nova-canvas-v1:0", "amazon.titan-image-generator-v2:0", "stability.sd3-5-large-v1:0"} {
+		supports := (&Model{modelID: modelID}).Supports()
+		assert.Equal(t, []string{"media"}, supports.Output, modelID)
+		assert.True(t, supports.Media, modelID)
+		assert.False(t, supports.Tools, modelID)
+		assert.False(t, supports.Multiturn, modelID)
+	}
+}
+
+func TestModel_convertImageRequest(t *testing.T) {
+	image, encoded := testImage()
+
+	tests := []struct {
+		name    string
+		modelID string
+		config  *ImageConfig
+		content []*ai.Part
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{
+			name:    "text image",
+			modelID: "amazon.nova-canvas-v1:0",
+			config:  &ImageConfig{Width: 1024, Height: 1024, Seed: 7, CfgScale: 6.5, NumberOfImages: 2, NegativePrompt: "blurry"},
+			content: []*ai.Part{ai.NewTextPart("A lighthouse at dusk")},
+			want: map[string]interface{}{
+				"taskType": "TEXT_IMAGE",
+				"textToImageParams": map[string]interface{}{
+					"text":         "A lighthouse at dusk",
+					"negativeText": "blurry",
+				},
+				"imageGenerationConfig": map[string]interface{}{
+					"numberOfImages": float64(2),
+					"width":          float64(1024),
+					"height":         float64(1024),
+					"cfgScale":       6.5,
+					"seed":           float64(7),
+				},
+			},
+		},
+		{
+			name:    "image variation",
+			modelID: "amazon.titan-image-generator-v2:0",
+			config:  &ImageConfig{TaskType: TaskImageVariation, SimilarityStrength: 0.7},
+			content: []*ai.Part{image},
+			want: map[string]interface{}{
+				"taskType": "IMAGE_VARIATION",
+				"imageVariationParams": map[string]interface{}{
+					"images":             []interface{}{encoded},
+					"similarityStrength": 0.7,
+				},
+			},
+		},
+		{
+			name:    "inpainting with mask prompt",
+			modelID: "amazon.nova-canvas-v1:0",
+			config:  &ImageConfig{TaskType: TaskInpainting, MaskPrompt: "the sky"},
+			content: []*ai.Part{ai.NewTextPart("A starry sky"), image},
+			want: map[string]interface{}{
+				"taskType": "INPAINTING",
+				"inPaintingParams": map[string]interface{}{
+					"text":       "A starry sky",
+					"image":      encoded,
+					"maskPrompt": "the sky",
+				},
+			},
+		},
+		{
+			name:    "outpainting with mask image",
+			modelID: "amazon.nova-canvas-v1:0",
+			config:  &ImageConfig{TaskType: TaskOutpainting, OutpaintingMode: "PRECISE"},
+			content: []*ai.Part{ai.NewTextPart("A beach"), image, image},
+			want: map[string]interface{}{
+				"taskType": "OUTPAINTING",
+				"outPaintingParams": map[string]interface{}{
+					"text":            "A beach",
+					"image":           encoded,
+					"maskImage":       encoded,
+					"outPaintingMode": "PRECISE",
+				},
+			},
+		},
+		{
+			name:    "background removal",
+			modelID: "amazon.nova-canvas-v1:0",
+			config:  &ImageConfig{TaskType: TaskBackgroundRemoval, Width: 1024},
+			content: []*ai.Part{image},
+			want: map[string]interface{}{
+				"taskType":                "BACKGROUND_REMOVAL",
+				"backgroundRemovalParams": map[string]interface{}{"image": encoded},
+			},
+		},
+		{
+			name:    "background removal on titan v1",
+			modelID: "amazon.titan-image-generator-v1",
+			config:  &ImageConfig{TaskType: TaskBackgroundRemoval},
+			content: []*ai.Part{image},
+			wantErr: "BACKGROUND_REMOVAL is not supported",
+		},
+		{
+			name:    "inpainting without mask",
+			modelID: "amazon.nova-canvas-v1:0",
+			config:  &ImageConfig{TaskType: TaskInpainting},
+			content: []*ai.Part{ai.NewTextPart("A starry sky"), image},
+			wantErr: "requires a mask_prompt or a mask image",
+		},
+		{
+			name:    "variation without image",
+			modelID: "amazon.nova-canvas-v1:0",
+			config:  &ImageConfig{TaskType: TaskImageVariation},
+			content: []*ai.Part{ai.NewTextPart("More like this")},
+			wantErr: "requires an input image",
+		},
+		{
+			name:    "text image with image",
+			modelID: "amazon.nova-canvas-v1:0",
+			content: []*ai.Part{ai.NewTextPart("A lighthouse"), image},
+			wantErr: "does not take an input image",
+		},
+		{
+			name:    "text image without prompt",
+			modelID: "amazon.nova-canvas-v1:0",
+			content: []*ai.Part{ai.NewTextPart("  ")},
+			wantErr: "requires a text prompt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &Model{modelID: tt.modelID, config: &ModelConfig{Image: tt.config}}
+			body, err := model.convertImageRequest(&ai.ModelRequest{
+				Messages: []*ai.Message{ai.NewUserMessage(tt.content...)},
+			})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var imageReq map[string]interface{}
+			require.NoError(t, json.Unmarshal(body, &imageReq))
+			assert.Equal(t, tt.want, imageReq)
+		})
+	}
+}
+
+func TestConvertImageResponse(t *testing.T) {
+	result, err := convertImageResponse([]byte(`{"images":["aW1nMQ==","aW1nMg=="]}`))
+	require.NoError(t, err)
+
+	require.Len(t, result.Message.Content, 2)
+	assert.True(t, result.Message.Content[0].IsMedia())
+	assert.Equal(t, "image/png", result.Message.Content[0].ContentType)
+	assert.Equal(t, "data:image/png;base64,aW1nMQ==", result.Message.Content[0].Text)
+	assert.Equal(t, 2, result.Usage.OutputImages)
+	assert.Equal(t, ai.FinishReasonStop, result.FinishReason)
+
+	result, err = convertImageResponse([]byte(`{"images":[],"error":"This request has been blocked by our content filters."}`))
+	require.NoError(t, err)
+	assert.Equal(t, ai.FinishReasonBlocked, result.FinishReason)
+	assert.Contains(t, result.FinishMessage, "content filters")
+
+	_, err = convertImageResponse([]byte(`{"images":[]}`))
+	require.Error(t, err)
+}
+
+func TestModel_convertStabilityRequest(t *testing.T) {
+	image, encoded := testImage()
+
+	tests := []struct {
+		name    string
+		modelID string
+		config  *ImageConfig
+		content []*ai.Part
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{
+			name:    "sd3 text to image",
+			modelID: "stability.sd3-5-large-v1:0",
+			config:  &ImageConfig{AspectRatio: "16:9", Seed: 11, NegativePrompt: "text", OutputFormat: "jpeg"},
+			content: []*ai.Part{ai.NewTextPart("A fox in the snow")},
+			want: map[string]interface{}{
+				"prompt":          "A fox in the snow",
+				"mode":            "text-to-image",
+				"aspect_ratio":    "16:9",
+				"seed":            float64(11),
+				"negative_prompt": "text",
+				"output_format":   "jpeg",
+			},
+		},
+		{
+			name:    "ultra text to image",
+			modelID: "stability.stable-image-ultra-v1:1",
+			content: []*ai.Part{ai.NewTextPart("A fox in the snow")},
+			want:    map[string]interface{}{"prompt": "A fox in the snow"},
+		},
+		{
+			name:    "image to image",
+			modelID: "stability.sd3-5-large-v1:0",
+			config:  &ImageConfig{Strength: 0.6},
+			content: []*ai.Part{ai.NewTextPart("In watercolor"), image},
+			want: map[string]interface{}{
+				"prompt":   "In watercolor",
+				"mode":     "image-to-image",
+				"image":    encoded,
+				"strength": 0.6,
+			},
+		},
+		{
+			name:    "sd3 image to image default strength",
+			modelID: "stability.sd3-5-large-v1:0",
+			content: []*ai.Part{ai.NewTextPart("In watercolor"), image},
+			want: map[string]interface{}{
+				"prompt":   "In watercolor",
+				"mode":     "image-to-image",
+				"image":    encoded,
+				"strength": 0.5,
+			},
+		},
+		{
+			name:    "ultra image to image",
+			modelID: "stability.stable-image-ultra-v1:1",
+			config:  &ImageConfig{Strength: 0.3},
+			content: []*ai.Part{ai.NewTextPart("In watercolor"), image},
+			want: map[string]interface{}{
+				"prompt":   "In watercolor",
+				"image":    encoded,
+				"strength": 0.3,
+			},
+		},
+		{
+			name:    "ultra image to image default strength",
+			modelID: "stability.stable-image-ultra-v1:1",
+			content: []*ai.Part{ai.NewTextPart("In watercolor"), image},
+			want: map[string]interface{}{
+				"prompt": "In watercolor",
+				"image":  encoded,
+			},
+		},
+		{
+			name:    "core image to image",
+			modelID: "stability.stable-image-core-v1:1",
+			content: []*ai.Part{ai.NewTextPart("In watercolor"), image},
+			wantErr: "not supported by Stable Image Core",
+		},
+		{
+			name:    "several images",
+			modelID: "stability.stable-image-core-v1:1",
+			config:  &ImageConfig{NumberOfImages: 2},
+			content: []*ai.Part{ai.NewTextPart("A fox")},
+			wantErr: "one image per request",
+		},
+		{
+			name:    "pixel size",
+			modelID: "stability.stable-image-core-v1:1",
+			config:  &ImageConfig{Width: 1024},
+			content: []*ai.Part{ai.NewTextPart("A fox")},
+			wantErr: "sized by aspect_ratio",
+		},
+		{
+			name:    "canvas task",
+			modelID: "stability.sd3-5-large-v1:0",
+			config:  &ImageConfig{TaskType: TaskInpainting},
+			content: []*ai.Part{ai.NewTextPart("A fox"), image},
+			wantErr: "task_type INPAINTING is not supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &Model{modelID: tt.modelID, config: &ModelConfig{Image: tt.config}}
+			body, err := model.convertStabilityRequest(&ai.ModelRequest{
+				Messages: []*ai.Message{ai.NewUserMessage(tt.content...)},
+			})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var stabilityReq map[string]interface{}
+			require.NoError(t, json.Unmarshal(body, &stabilityReq))
+			assert.Equal(t, tt.want, stabilityReq)
+		})
+	}
+}
+
+func TestModel_convertStabilityResponse(t *testing.T) {
+	model := &Model{modelID: "stability.sd3-5-large-v1:0", config: &ModelConfig{Image: &ImageConfig{OutputFormat: "webp"}}}
+
+	result, err := model.convertStabilityResponse([]byte(`{"seeds":[11],"finish_reasons":[null],"images":["aW1n"]}`))
+	require.NoError(t, err)
+	require.Len(t, result.Message.Content, 1)
+	assert.Equal(t, "data:image/webp;base64,aW1n", result.Message.Content[0].Text)
+	assert.Equal(t, map[string]any{"seeds": []int{11}}, result.Custom)
+
+	result, err = model.convertStabilityResponse([]byte(`{"seeds":[11],"finish_reasons":["Filter reason: prompt"],"images":[]}`))
+	require.NoError(t, err)
+	assert.Equal(t, ai.FinishReasonBlocked, result.FinishReason)
+	assert.Equal(t, "Filter reason: prompt", result.FinishMessage)
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// Jamba-specific request conversion
+func (m *Model) convertJambaRequest(req *ai.ModelRequest) ([]byte, error) {
+	if len(req.Messages) == 0 {
+		return nil, fmt.Errorf("no messages in request")
+	}
+
+	if _, err := m.prepareMedia(req.Messages); err != nil {
+		return nil, err
+	}
+
+	messages := make([]map[string]interface{}, 0, len(req.Messages))
+	for _, msg := range req.Messages {
+		var text strings.Builder
+		for _, part := range msg.Content {
+			if !part.IsText() {
+				return nil, fmt.Errorf("unsupported part kind %d for Jamba", part.Kind)
+			}
+			text.WriteString(part.Text)
+		}
+
+		// Jamba rejects messages with empty content
+		if text.Len() == 0 {
+			continue
+		}
+
+		role := "user"
+		switch msg.Role {
+		case ai.RoleSystem:
+			role = "system"
+		case ai.RoleModel:
+			role = "assistant"
+		}
+
+		messages = append(messages, map[string]interface{}{
+			"role":    role,
+			"content": text.String(),
+		})
+	}
+
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("no messages with content in request")
+	}
+
+	jambaReq := map[string]interface{}{
+		"messages":    messages,
+		"max_tokens":  m.config.MaxTokens,
+		"temperature": m.config.Temperature,
+	}
+
+	if m.config.TopP > 0 {
+		jambaReq["top_p"] = m.config.TopP
+	}
+
+	if len(m.config.StopSequences) > 0 {
+		jambaReq["stop"] = m.config.StopSequences
+	}
+
+	return json.Marshal(jambaReq)
+}
+
+// Jamba-specific response conversion
+func (m *Model) convertJambaResponse(body []byte) (*ai.ModelResponse, error) {
+	var jambaResp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+			TotalTokens      int `json:"total_tokens"`
+		} `json:"usage"`
+	}
+
+	if err := json.Unmarshal(body, &jambaResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Jamba response: %w", err)
+	}
+
+	if len(jambaResp.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in Jamba response")
+	}
+
+	choice := jambaResp.Choices[0]
+	return &ai.ModelResponse{
+		Message: &ai.Message{
+			Role:    "model",
+			Content: []*ai.Part{ai.NewTextPart(choice.Message.Content)},
+		},
+		Usage: &ai.GenerationUsage{
+			InputTokens:  jambaResp.Usage.PromptTokens,
+			OutputTokens: jambaResp.Usage.CompletionTokens,
+			TotalTokens:  jambaResp.Usage.TotalTokens,
+		},
+		FinishReason:  finishReason(choice.FinishReason),
+		FinishMessage: choice.FinishReason,
+	}, nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModel_convertJambaRequest(t *testing.T) {
+	model := &Model{
+		modelID: "ai21.jamba-1-5-mini-v1:0",
+		config: &ModelConfig{
+			MaxTokens:     256,
+			Temperature:   0.4,
+			TopP:          0.95,
+			StopSequences: []string{"\n\n"},
+		},
+	}
+
+	result, err := model.convertRequest(&ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewSystemTextMessage("Be brief."),
+			ai.NewUserTextMessage("Name a city in Portugal."),
+			ai.NewModelTextMessage("Lisbon."),
+			ai.NewUserTextMessage("And one in Spain?"),
+		},
+	})
+	require.NoError(t, err)
+
+	var jambaReq map[string]interface{}
+	require.NoError(t, json.Unmarshal(result, &jambaReq))
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"role": "system", "content": "Be brief."},
+		map[string]interface{}{"role": "user", "content": "Name a city in Portugal."},
+		map[string]interface{}{"role": "assistant", "content": "Lisbon."},
+		map[string]interface{}{"role": "user", "content": "And one in Spain?"},
+	}, jambaReq["messages"])
+	assert.Equal(t, float64(256), jambaReq["max_tokens"])
+	assert.Equal(t, 0.4, jambaReq["temperature"])
+	assert.Equal(t, 0.95, jambaReq["top_p"])
+	assert.Equal(t, []interface{}{"\n\n"}, jambaReq["stop"])
+
+	_, err = model.convertRequest(&ai.ModelRequest{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no messages")
+}
+
+func TestModel_convertJambaRequest_EmptyMessages(t *testing.T) {
+	model := &Model{modelID: "ai21.jamba-1-5-mini-v1:0", config: &ModelConfig{}}
+
+	result, err := model.convertRequest(&ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewUserTextMessage("Name a city in Portugal."),
+			{Role: ai.RoleModel},
+			ai.NewModelTextMessage(""),
+			ai.NewUserTextMessage("And one in Spain?"),
+		},
+	})
+	require.NoError(t, err)
+
+	var jambaReq map[string]interface{}
+	require.NoError(t, json.Unmarshal(result, &jambaReq))
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"role": "user", "content": "Name a city in Portugal."},
+		map[string]interface{}{"role": "user", "content": "And one in Spain?"},
+	}, jambaReq["messages"])
+
+	_, err = model.convertRequest(&ai.ModelRequest{
+		Messages: []*ai.Message{{Role: ai.RoleUser}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no messages with content")
+
+	_, err = model.convertRequest(&ai.ModelRequest{
+		Messages: []*ai.Message{{
+			Role:    ai.RoleModel,
+			Content: []*ai.Part{ai.NewToolRequestPart(&ai.ToolRequest{Name: "lookup"})},
+		}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported part kind")
+}
+
+func TestModel_convertJambaResponse(t *testing.T) {
+	model := &Model{modelID: "ai21.jamba-1-5-large-v1:0", config: &ModelConfig{}}
+
+	result, err := model.convertResponse([]byte(`{
+		"id": "chatcmpl-1",
+		"choices": [{"index": 0, "message": {"role": "assistant", "content": "Madrid."}, "finish_reason": "stop"}],
+		"usage": {"prompt_tokens": 30, "completion_tokens": 3, "total_tokens": 33}
+	}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "Madrid.", result.Message.Content[0].Text)
+	assert.Equal(t, 30, result.Usage.InputTokens)
+	assert.Equal(t, 3, result.Usage.OutputTokens)
+	assert.Equal(t, 33, result.Usage.TotalTokens)
+	assert.Equal(t, ai.FinishReasonStop, result.FinishReason)
+
+	_, err = model.convertResponse([]byte(`{"choices": []}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no choices")
+}
@@ -0,0 +1,199 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// llamaTemplate identifies the prompt format a Llama model was trained on
+type llamaTemplate int
+
+const (
+	llama2Template llamaTemplate = iota
+	llama3Template
+	llama4Template
+)
+
+// llamaStopTokens are the end-of-turn markers of every template, which must
+// never reach the caller as generated text
+var llamaStopTokens = strings.NewReplacer(
+	"<|eot_id|>", "",
+	"<|end_of_text|>", "",
+	"<|eot|>", "",
+	"</s>", "",
+)
+
+// llamaTemplateFor selects the prompt template for a Llama model ID
+func llamaTemplateFor(modelID string) llamaTemplate {
+	id := strings.ToLower(modelID)
+	switch {
+	case strings.Contains(id, "llama2"):
+		return llama2Template
+	case strings.Contains(id, "llama4"):
+		return llama4Template
+	default:
+		return llama3Template
+	}
+}
+
+// llamaTurn is a single rendered conversation turn
+type llamaTurn struct {
+	role ai.Role
+	text string
+}
+
+// llamaPrompt renders the conversation in the model's chat template and
+// returns the prompt with any images it references, in order
+func (m *Model) llamaPrompt(messages []*ai.Message) (string, []string, error) {
+	template := llamaTemplateFor(m.foundationModel())
+
+	// Llama 2 only understands a system prompt at the start of the first
+	// instruction; later templates give the system role its own turns
+	var system string
+	if template == llama2Template {
+		var err error
+		if system, messages, err = splitSystemMessages(messages); err != nil {
+			return "", nil, err
+		}
+	}
+
+	turns, images, err := llamaTurns(messages)
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch template {
+	case llama2Template:
+		return formatLlama2Prompt(system, turns), images, nil
+	case llama4Template:
+		return formatHeaderPrompt(turns, "<|header_start|>", "<|header_end|>", "<|eot|>"), images, nil
+	default:
+		return formatHeaderPrompt(turns, "<|start_header_id|>", "<|end_header_id|>", "<|eot_id|>"), images, nil
+	}
+}
+
+// llamaTurns renders each message's parts to text, with adjacent text parts
+// on separate lines. Vision models take images alongside the prompt, with a
+// placeholder marking where each one belongs.
+func llamaTurns(messages []*ai.Message) ([]llamaTurn, []string, error) {
+	var turns []llamaTurn
+	var images []string
+
+	for _, msg := range messages {
+		if len(msg.Content) == 0 {
+			continue
+		}
+
+		var text strings.Builder
+		for i, part := range msg.Content {
+			switch {
+			case part.IsText():
+				// Text parts are separate paragraphs or lines
+				if i > 0 && msg.Content[i-1].IsText() {
+					text.WriteString("\n")
+				}
+				text.WriteString(part.Text)
+			case part.IsMedia() && msg.Role != ai.RoleSystem:
+				image, _, err := decodeImage(part, llamaMaxImageBytes)
+				if err != nil {
+					return nil, nil, err
+				}
+				images = append(images, image.base64())
+				text.WriteString("<|image|>")
+			default:
+				return nil, nil, fmt.Errorf("unsupported part kind %d for Llama", part.Kind)
+			}
+		}
+
+		role := msg.Role
+		if role == ai.RoleTool {
+			role = ai.RoleUser
+		}
+		turns = append(turns, llamaTurn{role: role, text: text.String()})
+	}
+
+	return turns, images, nil
+}
+
+// formatHeaderPrompt renders turns in the Llama 3 and Llama 4 format, where
+// each turn is introduced by a role header and closed by an end-of-turn
+// token. The prompt ends with an open assistant turn so the model answers
+// rather than continuing the user's text.
+func formatHeaderPrompt(turns []llamaTurn, headerStart, headerEnd, endOfTurn string) string {
+	var prompt strings.Builder
+	prompt.WriteString("<|begin_of_text|>")
+
+	header := func(role string) {
+		prompt.WriteString(headerStart)
+		prompt.WriteString(role)
+		prompt.WriteString(headerEnd)
+		prompt.WriteString("\n\n")
+	}
+
+	for i, turn := range turns {
+		header(llamaRole(turn.role))
+		prompt.WriteString(turn.text)
+		if isTrailingModel(turn.role, i, len(turns)) {
+			return prompt.String()
+		}
+		prompt.WriteString(endOfTurn)
+	}
+
+	header("assistant")
+	return prompt.String()
+}
+
+// formatLlama2Prompt renders turns in the Llama 2 [INST] format, with the
+// system prompt inside the first instruction. Consecutive user turns are
+// merged because each instruction must be followed by an answer.
+func formatLlama2Prompt(system string, turns []llamaTurn) string {
+	var merged []llamaTurn
+	for _, turn := range turns {
+		if n := len(merged); n > 0 && merged[n-1].role == turn.role {
+			merged[n-1].text += "\n\n" + turn.text
+			continue
+		}
+		merged = append(merged, turn)
+	}
+
+	var prompt strings.Builder
+	for i, turn := range merged {
+		if turn.role == ai.RoleModel {
+			prompt.WriteString(" ")
+			prompt.WriteString(turn.text)
+			if !isTrailingModel(turn.role, i, len(merged)) {
+				prompt.WriteString(" </s>")
+			}
+			continue
+		}
+
+		prompt.WriteString("<s>[INST] ")
+		if system != "" {
+			prompt.WriteString("<<SYS>>\n")
+			prompt.WriteString(system)
+			prompt.WriteString("\n<</SYS>>\n\n")
+			system = ""
+		}
+		prompt.WriteString(turn.text)
+		prompt.WriteString(" [/INST]")
+	}
+
+	return prompt.String()
+}
+
+// llamaRole maps a GenKit role to the role name used in Llama headers
+func llamaRole(role ai.Role) string {
+	switch role {
+	case ai.RoleModel:
+		return "assistant"
+	case ai.RoleSystem:
+		return "system"
+	default:
+		return "user"
+	}
+}
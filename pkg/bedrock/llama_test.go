@@ -0,0 +1,164 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+func TestLlamaTemplateFor(t *testing.T) {
+	tests := []struct {
+		modelID  string
+		expected llamaTemplate
+	}{
+		{"meta.llama2-13b-chat-v1", llama2Template},
+		{"meta.llama2-70b-chat-v1", llama2Template},
+		{"meta.llama3-8b-instruct-v1:0", llama3Template},
+		{"meta.llama3-1-405b-instruct-v1:0", llama3Template},
+		{"meta.llama3-2-90b-instruct-v1:0", llama3Template},
+		{"meta.llama3-3-70b-instruct-v1:0", llama3Template},
+		{"meta.llama4-maverick-17b-instruct-v1:0", llama4Template},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.modelID, func(t *testing.T) {
+			assert.Equal(t, tt.expected, llamaTemplateFor(tt.modelID))
+		})
+	}
+}
+
+func TestModel_convertLlamaRequest_Golden(t *testing.T) {
+	conversation := []*ai.Message{
+		ai.NewSystemTextMessage("You are a concise travel guide."),
+		ai.NewUserTextMessage("Name a city in Portugal."),
+		ai.NewModelTextMessage("Lisbon."),
+		ai.NewUserTextMessage("And one in Spain?"),
+	}
+
+	tests := []struct {
+		name     string
+		modelID  string
+		messages []*ai.Message
+	}{
+		{
+			name:     "llama3_single_turn",
+			modelID:  "meta.llama3-1-8b-instruct-v1:0",
+			messages: []*ai.Message{ai.NewUserTextMessage("Hello!")},
+		},
+		{
+			name:     "llama3_multi_turn",
+			modelID:  "meta.llama3-1-70b-instruct-v1:0",
+			messages: conversation,
+		},
+		{
+			name:    "llama3_prefill",
+			modelID: "meta.llama3-3-70b-instruct-v1:0",
+			messages: []*ai.Message{
+				ai.NewUserTextMessage("List three colours as JSON."),
+				ai.NewModelTextMessage("["),
+			},
+		},
+		{
+			name:    "llama3_late_system",
+			modelID: "meta.llama3-1-8b-instruct-v1:0",
+			messages: []*ai.Message{
+				ai.NewUserTextMessage("Hi."),
+				ai.NewModelTextMessage("Hello!"),
+				ai.NewSystemTextMessage("From now on, answer in French."),
+				ai.NewUserTextMessage("How are you?"),
+			},
+		},
+		{
+			name:    "llama3_vision",
+			modelID: "meta.llama3-2-11b-instruct-v1:0",
+			messages: []*ai.Message{
+				ai.NewUserMessage(
+					ai.NewMediaPart("image/png", pngHeader),
+					ai.NewTextPart("Describe this image."),
+				),
+			},
+		},
+		{
+			name:    "llama3_multi_part",
+			modelID: "meta.llama3-1-8b-instruct-v1:0",
+			messages: []*ai.Message{
+				ai.NewSystemMessage(ai.NewTextPart("You are a concise travel guide."), ai.NewTextPart("Answer in English.")),
+				ai.NewUserMessage(ai.NewTextPart("Plan a day in Lisbon."), ai.NewTextPart("I like museums.")),
+			},
+		},
+		{
+			name:     "llama2_multi_turn",
+			modelID:  "meta.llama2-13b-chat-v1",
+			messages: conversation,
+		},
+		{
+			name:    "llama2_prefill",
+			modelID: "meta.llama2-70b-chat-v1",
+			messages: []*ai.Message{
+				ai.NewSystemTextMessage("Answer with a single word."),
+				ai.NewUserTextMessage("Think of a fruit."),
+				ai.NewUserTextMessage("Now name it."),
+				ai.NewModelTextMessage("The fruit is"),
+			},
+		},
+		{
+			name:     "llama4_multi_turn",
+			modelID:  "meta.llama4-scout-17b-instruct-v1:0",
+			messages: conversation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &Model{modelID: tt.modelID, config: &ModelConfig{}}
+			result, err := model.convertLlamaRequest(&ai.ModelRequest{Messages: tt.messages})
+			require.NoError(t, err)
+
+			var llamaReq map[string]interface{}
+			require.NoError(t, json.Unmarshal(result, &llamaReq))
+			prompt := llamaReq["prompt"].(string)
+
+			golden := filepath.Join("testdata", "llama", tt.name+".golden")
+			if *updateGolden {
+				require.NoError(t, os.MkdirAll(filepath.Dir(golden), 0o755))
+				require.NoError(t, os.WriteFile(golden, []byte(prompt), 0o644))
+			}
+
+			expected, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), prompt)
+		})
+	}
+}
+
+func TestModel_convertLlamaRequest_Llama2LateSystem(t *testing.T) {
+	model := &Model{modelID: "meta.llama2-13b-chat-v1", config: &ModelConfig{}}
+
+	_, err := model.convertLlamaRequest(&ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewUserTextMessage("Hi."),
+			ai.NewSystemTextMessage("Answer in French."),
+		},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must precede the conversation")
+}
+
+func TestModel_convertLlamaResponse_StopTokens(t *testing.T) {
+	model := &Model{modelID: "meta.llama3-1-8b-instruct-v1:0", config: &ModelConfig{}}
+
+	result, err := model.convertLlamaResponse([]byte(`{"generation":"Madrid.<|eot_id|>","stop_reason":"stop"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "Madrid.", result.Message.Content[0].Text)
+}
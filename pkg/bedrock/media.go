@@ -0,0 +1,126 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// Per-image size limits enforced by Bedrock for each model family
+const (
+	claudeMaxImageBytes   = 3_750_000
+	novaMaxImageBytes     = 25_000_000
+	llamaMaxImageBytes    = 3_750_000
+	converseMaxImageBytes = 3_750_000
+	titanMaxImageBytes    = 25_000_000
+)
+
+// imageFormats maps supported image MIME types to Bedrock format names
+var imageFormats = map[string]string{
+	"image/png":  "png",
+	"image/jpeg": "jpeg",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+// mediaData is a decoded inline media part
+type mediaData struct {
+	mimeType string
+	data     []byte
+}
+
+// base64 returns the media bytes in standard base64 encoding
+func (d *mediaData) base64() string {
+	return base64.StdEncoding.EncodeToString(d.data)
+}
+
+// decodeMedia extracts the bytes and MIME type of a media part. The part
+// URL may be a data URI or raw base64 with the MIME type in ContentType;
+// remote URLs are not fetched.
+func decodeMedia(part *ai.Part) (*mediaData, error) {
+	url := part.Text
+	mimeType := part.ContentType
+
+	encoded := url
+	if strings.HasPrefix(url, "data:") {
+		header, payload, ok := strings.Cut(strings.TrimPrefix(url, "data:"), ",")
+		if !ok || !strings.HasSuffix(header, ";base64") {
+			return nil, fmt.Errorf("media data URI must be base64 encoded")
+		}
+		if uriType := strings.TrimSuffix(header, ";base64"); uriType != "" {
+			mimeType = uriType
+		}
+		encoded = payload
+	} else if strings.Contains(url, "://") {
+		return nil, fmt.Errorf("remote media URL %q is not supported, inline the data as base64", url)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode media data: %w", err)
+	}
+
+	// Fall back to content sniffing when the caller did not say what it is
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		mimeType = http.DetectContentType(data)
+	}
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+
+	return &mediaData{mimeType: strings.ToLower(strings.TrimSpace(mimeType)), data: data}, nil
+}
+
+// decodeImage decodes an image part and validates its type and size
+func decodeImage(part *ai.Part, maxBytes int) (*mediaData, string, error) {
+	media, err := decodeMedia(part)
+	if err != nil {
+		return nil, "", err
+	}
+
+	format, ok := imageFormats[media.mimeType]
+	if !ok {
+		return nil, "", fmt.Errorf("unsupported image type %q", media.mimeType)
+	}
+
+	if len(media.data) > maxBytes {
+		return nil, "", fmt.Errorf("image is %d bytes, exceeding the %d byte limit", len(media.data), maxBytes)
+	}
+
+	return media, format, nil
+}
+
+// isVisionModel reports whether the model accepts image input
+func isVisionModel(modelID string) bool {
+	id := strings.ToLower(modelID)
+
+	switch {
+	case isClaudeModel(id):
+		// Claude v2, Instant and 3.5 Haiku are text only
+		for _, family := range []string{
+			"claude-3-haiku", "claude-3-sonnet", "claude-3-opus", "claude-3-5-sonnet",
+			"claude-3-7-sonnet", "claude-sonnet-4", "claude-opus-4", "claude-haiku-4",
+		} {
+			if strings.Contains(id, family) {
+				return true
+			}
+		}
+		return false
+	case isNovaModel(id):
+		return strings.Contains(id, "nova-lite") ||
+			strings.Contains(id, "nova-pro") ||
+			strings.Contains(id, "nova-premier")
+	case isLlamaModel(id):
+		return strings.Contains(id, "llama3-2-11b") ||
+			strings.Contains(id, "llama3-2-90b") ||
+			strings.Contains(id, "llama4")
+	default:
+		return false
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pngHeader is the PNG file signature, enough for content sniffing
+const pngHeader = "iVBORw0KGgo="
+
+func TestDecodeMedia(t *testing.T) {
+	tests := []struct {
+		name         string
+		part         *ai.Part
+		wantMIME     string
+		wantData     string
+		wantErrorMsg string
+	}{
+		{
+			name:     "data URI",
+			part:     ai.NewMediaPart("", "data:image/jpeg;base64,aGVsbG8="),
+			wantMIME: "image/jpeg",
+			wantData: "hello",
+		},
+		{
+			name:     "raw base64 with content type",
+			part:     ai.NewMediaPart("image/webp", "aGVsbG8="),
+			wantMIME: "image/webp",
+			wantData: "hello",
+		},
+		{
+			name:     "content type is sniffed when missing",
+			part:     ai.NewMediaPart("", pngHeader),
+			wantMIME: "image/png",
+			wantData: "\x89PNG\r\n\x1a\n",
+		},
+		{
+			name:         "remote URL",
+			part:         ai.NewMediaPart("image/png", "https://example.com/cat.png"),
+			wantErrorMsg: "remote media URL",
+		},
+		{
+			name:         "data URI without base64",
+			part:         ai.NewMediaPart("", "data:image/png,rawbytes"),
+			wantErrorMsg: "must be base64 encoded",
+		},
+		{
+			name:         "invalid base64",
+			part:         ai.NewMediaPart("image/png", "not base64!"),
+			wantErrorMsg: "failed to decode media data",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			media, err := decodeMedia(tt.part)
+			if tt.wantErrorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErrorMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantMIME, media.mimeType)
+			assert.Equal(t, tt.wantData, string(media.data))
+		})
+	}
+}
+
+func TestDecodeImage(t *testing.T) {
+	_, format, err := decodeImage(ai.NewMediaPart("image/gif", "aGVsbG8="), claudeMaxImageBytes)
+	require.NoError(t, err)
+	assert.Equal(t, "gif", format)
+
+	_, _, err = decodeImage(ai.NewMediaPart("image/tiff", "aGVsbG8="), claudeMaxImageBytes)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported image type")
+
+	_, _, err = decodeImage(ai.NewMediaPart("image/png", "aGVsbG8="), 4)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeding the 4 byte limit")
+}
+
+func TestIsVisionModel(t *testing.T) {
+	tests := []struct {
+		modelID  string
+		expected bool
+	}{
+		{"anthropic.claude-3-5-sonnet-20241022-v2:0", true},
+		{"anthropic.claude-3-haiku-20240307-v1:0", true},
+		{"anthropic.claude-3-5-haiku-20241022-v1:0", false},
+		{"us.anthropic.claude-3-7-sonnet-20250219-v1:0", true},
+		{"anthropic.claude-sonnet-4-20250514-v1:0", true},
+		{"anthropic.claude-opus-4-1-20250805-v1:0", true},
+		{"anthropic.claude-v2:1", false},
+		{"anthropic.claude-instant-v1", false},
+		{"amazon.nova-lite-v1:0", true},
+		{"amazon.nova-pro-v1:0", true},
+		{"amazon.nova-micro-v1:0", false},
+		{"meta.llama3-2-11b-instruct-v1:0", true},
+		{"meta.llama3-2-90b-instruct-v1:0", true},
+		{"meta.llama3-2-3b-instruct-v1:0", false},
+		{"meta.llama3-1-70b-instruct-v1:0", false},
+		{"mistral.mistral-large-2407-v1:0", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.modelID, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isVisionModel(tt.modelID))
+		})
+	}
+}
+
+func imageRequest() *ai.ModelRequest {
+	return &ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewUserMessage(
+				ai.NewTextPart("What is this?"),
+				ai.NewMediaPart("", "data:image/png;base64,"+pngHeader),
+			),
+		},
+	}
+}
+
+func TestModel_convertRequest_Image(t *testing.T) {
+	t.Run("claude", func(t *testing.T) {
+		model := &Model{modelID: "anthropic.claude-3-haiku-20240307-v1:0", config: &ModelConfig{}}
+		result, err := model.convertClaudeRequest(imageRequest())
+		require.NoError(t, err)
+
+		var claudeReq map[string]interface{}
+		require.NoError(t, json.Unmarshal(result, &claudeReq))
+		blocks := claudeReq["messages"].([]interface{})[0].(map[string]interface{})["content"].([]interface{})
+		require.Len(t, blocks, 2)
+		assert.Equal(t, map[string]interface{}{
+			"type": "image",
+			"source": map[string]interface{}{
+				"type":       "base64",
+				"media_type": "image/png",
+				"data":       pngHeader,
+			},
+		}, blocks[1])
+	})
+
+	t.Run("nova", func(t *testing.T) {
+		model := &Model{modelID: "amazon.nova-lite-v1:0", config: &ModelConfig{}}
+		result, err := model.convertNovaRequest(imageRequest())
+		require.NoError(t, err)
+
+		var novaReq map[string]interface{}
+		require.NoError(t, json.Unmarshal(result, &novaReq))
+		blocks := novaReq["messages"].([]interface{})[0].(map[string]interface{})["content"].([]interface{})
+		require.Len(t, blocks, 2)
+		assert.Equal(t, map[string]interface{}{
+			"image": map[string]interface{}{
+				"format": "png",
+				"source": map[string]interface{}{"bytes": pngHeader},
+			},
+		}, blocks[1])
+	})
+
+	t.Run("llama", func(t *testing.T) {
+		model := &Model{modelID: "meta.llama3-2-11b-instruct-v1:0", config: &ModelConfig{}}
+		result, err := model.convertLlamaRequest(imageRequest())
+		require.NoError(t, err)
+
+		var llamaReq map[string]interface{}
+		require.NoError(t, json.Unmarshal(result, &llamaReq))
+		assert.Equal(t, []interface{}{pngHeader}, llamaReq["images"])
+		assert.Contains(t, llamaReq["prompt"], "What is this?<|image|>")
+	})
+
+	t.Run("converse", func(t *testing.T) {
+		model := &Model{modelID: "amazon.nova-pro-v1:0", config: &ModelConfig{}}
+		input, err := model.convertConverseRequest(imageRequest())
+		require.NoError(t, err)
+
+		image := input.Messages[0].Content[1].(*types.ContentBlockMemberImage)
+		assert.Equal(t, types.ImageFormatPng, image.Value.Format)
+		data, _ := base64.StdEncoding.DecodeString(pngHeader)
+		assert.Equal(t, data, image.Value.Source.(*types.ImageSourceMemberBytes).Value)
+	})
+}
+
+func TestModel_convertRequest_ImageRejected(t *testing.T) {
+	t.Run("text-only model", func(t *testing.T) {
+		model := &Model{modelID: "amazon.nova-micro-v1:0", config: &ModelConfig{}}
+		_, err := model.convertNovaRequest(imageRequest())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not accept media input")
+	})
+
+	t.Run("oversized image", func(t *testing.T) {
+		model := &Model{modelID: "anthropic.claude-3-haiku-20240307-v1:0", config: &ModelConfig{}}
+		big := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("x", claudeMaxImageBytes+1)))
+		_, err := model.convertClaudeRequest(&ai.ModelRequest{
+			Messages: []*ai.Message{ai.NewUserMessage(ai.NewMediaPart("image/png", big))},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "byte limit")
+	})
+}
@@ -0,0 +1,322 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// isMistralChatModel reports whether a Mistral model takes chat-style
+// messages and tools rather than an [INST] prompt. Mistral 7B and Mixtral
+// only complete text.
+func isMistralChatModel(modelID string) bool {
+	id := strings.ToLower(modelID)
+	for _, family := range []string{"mistral-large", "mistral-small", "pixtral-large"} {
+		if strings.Contains(id, family) {
+			return true
+		}
+	}
+	return false
+}
+
+// Mistral-specific request conversion
+func (m *Model) convertMistralRequest(req *ai.ModelRequest) ([]byte, error) {
+	if len(req.Messages) == 0 {
+		return nil, fmt.Errorf("no messages in request")
+	}
+
+	if _, err := m.prepareMedia(req.Messages); err != nil {
+		return nil, err
+	}
+
+	mistralReq := map[string]interface{}{
+		"max_tokens":  m.config.MaxTokens,
+		"temperature": m.config.Temperature,
+	}
+
+	if m.config.TopP > 0 {
+		mistralReq["top_p"] = m.config.TopP
+	}
+
+	if isMistralChatModel(m.foundationModel()) {
+		messages, err := mistralMessages(req.Messages)
+		if err != nil {
+			return nil, err
+		}
+		mistralReq["messages"] = messages
+
+		if len(req.Tools) > 0 {
+			mistralReq["tools"] = mistralTools(req.Tools)
+			mistralReq["tool_choice"] = mistralToolChoice(req.ToolChoice)
+		}
+
+		return json.Marshal(mistralReq)
+	}
+
+	system, conversation, err := splitSystemMessages(req.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt, err := formatMistralPrompt(system, conversation)
+	if err != nil {
+		return nil, err
+	}
+	mistralReq["prompt"] = prompt
+
+	if m.config.TopK > 0 {
+		mistralReq["top_k"] = m.config.TopK
+	}
+
+	if len(m.config.StopSequences) > 0 {
+		mistralReq["stop"] = m.config.StopSequences
+	}
+
+	return json.Marshal(mistralReq)
+}
+
+// formatMistralPrompt renders a conversation in the Mistral instruct format.
+// Mistral has no system role, so the system prompt opens the first
+// instruction, and consecutive user turns are merged into one instruction.
+func formatMistralPrompt(system string, messages []*ai.Message) (string, error) {
+	var prompt strings.Builder
+	prompt.WriteString("<s>")
+
+	var pending []string
+	if system != "" {
+		pending = append(pending, system)
+	}
+
+	flush := func() {
+		if len(pending) > 0 {
+			prompt.WriteString("[INST] ")
+			prompt.WriteString(strings.Join(pending, "\n\n"))
+			prompt.WriteString(" [/INST]")
+			pending = nil
+		}
+	}
+
+	for i, msg := range messages {
+		text, err := mistralText(msg)
+		if err != nil {
+			return "", err
+		}
+
+		if msg.Role != ai.RoleModel {
+			pending = append(pending, text)
+			continue
+		}
+
+		flush()
+		prompt.WriteString(" ")
+		prompt.WriteString(text)
+		if !isTrailingModel(msg.Role, i, len(messages)) {
+			prompt.WriteString("</s>")
+		}
+	}
+	flush()
+
+	return prompt.String(), nil
+}
+
+// mistralText joins the text parts of a message
+func mistralText(msg *ai.Message) (string, error) {
+	var text strings.Builder
+	for _, part := range msg.Content {
+		if !part.IsText() {
+			return "", fmt.Errorf("unsupported part kind %d for Mistral", part.Kind)
+		}
+		text.WriteString(part.Text)
+	}
+	return text.String(), nil
+}
+
+// mistralMessages converts messages to the Mistral chat format, where tool
+// calls ride on assistant messages and each tool result is its own message
+func mistralMessages(messages []*ai.Message) ([]map[string]interface{}, error) {
+	var result []map[string]interface{}
+	for _, msg := range messages {
+		if len(msg.Content) == 0 {
+			continue
+		}
+
+		var text strings.Builder
+		var toolCalls []map[string]interface{}
+		for _, part := range msg.Content {
+			switch {
+			case part.IsText():
+				text.WriteString(part.Text)
+			case part.IsToolRequest():
+				arguments, err := json.Marshal(toolInput(part.ToolRequest.Input))
+				if err != nil {
+					return nil, fmt.Errorf("failed to marshal input of tool %s: %w", part.ToolRequest.Name, err)
+				}
+				toolCalls = append(toolCalls, map[string]interface{}{
+					"id": part.ToolRequest.Ref,
+					"function": map[string]interface{}{
+						"name":      part.ToolRequest.Name,
+						"arguments": string(arguments),
+					},
+				})
+			case part.IsToolResponse():
+				output, err := json.Marshal(part.ToolResponse.Output)
+				if err != nil {
+					return nil, fmt.Errorf("failed to marshal output of tool %s: %w", part.ToolResponse.Name, err)
+				}
+				result = append(result, map[string]interface{}{
+					"role":         "tool",
+					"tool_call_id": part.ToolResponse.Ref,
+					"name":         part.ToolResponse.Name,
+					"content":      string(output),
+				})
+			default:
+				return nil, fmt.Errorf("unsupported part kind %d for Mistral", part.Kind)
+			}
+		}
+
+		if msg.Role == ai.RoleTool && text.Len() == 0 && len(toolCalls) == 0 {
+			continue
+		}
+
+		var role string
+		switch msg.Role {
+		case ai.RoleSystem:
+			role = "system"
+		case ai.RoleModel:
+			role = "assistant"
+		default:
+			role = "user"
+		}
+
+		message := map[string]interface{}{
+			"role":    role,
+			"content": text.String(),
+		}
+		if len(toolCalls) > 0 {
+			message["tool_calls"] = toolCalls
+		}
+		result = append(result, message)
+	}
+
+	return result, nil
+}
+
+// mistralTools converts GenKit tool definitions to Mistral function specs
+func mistralTools(tools []*ai.ToolDefinition) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(tools))
+	for _, tool := range tools {
+		result = append(result, map[string]interface{}{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":        tool.Name,
+				"description": tool.Description,
+				"parameters":  toolSchema(tool.InputSchema),
+			},
+		})
+	}
+	return result
+}
+
+// mistralToolChoice converts a GenKit tool choice to Mistral's tool_choice
+func mistralToolChoice(choice ai.ToolChoice) string {
+	switch choice {
+	case ai.ToolChoiceRequired:
+		return "any"
+	case ai.ToolChoiceNone:
+		return "none"
+	default:
+		return "auto"
+	}
+}
+
+// mistralToolCall is a function call returned by a Mistral chat model
+type mistralToolCall struct {
+	ID       string `json:"id"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// toolRequest converts the call to a GenKit tool request
+func (c *mistralToolCall) toolRequest() (*ai.ToolRequest, error) {
+	input := map[string]any{}
+	if c.Function.Arguments != "" {
+		if err := json.Unmarshal([]byte(c.Function.Arguments), &input); err != nil {
+			return nil, fmt.Errorf("failed to decode arguments of tool %s: %w", c.Function.Name, err)
+		}
+	}
+	return &ai.ToolRequest{Name: c.Function.Name, Ref: c.ID, Input: input}, nil
+}
+
+// Mistral-specific response conversion, covering both the completion
+// (outputs) and chat (choices) response shapes
+func (m *Model) convertMistralResponse(body []byte) (*ai.ModelResponse, error) {
+	var mistralResp struct {
+		Outputs []struct {
+			Text       string `json:"text"`
+			StopReason string `json:"stop_reason"`
+		} `json:"outputs"`
+		Choices []struct {
+			Message struct {
+				Content   string            `json:"content"`
+				ToolCalls []mistralToolCall `json:"tool_calls"`
+			} `json:"message"`
+			StopReason string `json:"stop_reason"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+
+	if err := json.Unmarshal(body, &mistralResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Mistral response: %w", err)
+	}
+
+	var content []*ai.Part
+	var stopReason string
+	switch {
+	case len(mistralResp.Choices) > 0:
+		choice := mistralResp.Choices[0]
+		if choice.Message.Content != "" {
+			content = append(content, ai.NewTextPart(choice.Message.Content))
+		}
+		for _, call := range choice.Message.ToolCalls {
+			toolReq, err := call.toolRequest()
+			if err != nil {
+				return nil, err
+			}
+			content = append(content, ai.NewToolRequestPart(toolReq))
+		}
+		stopReason = choice.StopReason
+	case len(mistralResp.Outputs) > 0:
+		content = append(content, ai.NewTextPart(mistralResp.Outputs[0].Text))
+		stopReason = mistralResp.Outputs[0].StopReason
+	default:
+		return nil, fmt.Errorf("no content in Mistral response")
+	}
+
+	if len(content) == 0 {
+		content = append(content, ai.NewTextPart(""))
+	}
+
+	return &ai.ModelResponse{
+		Message: &ai.Message{
+			Role:    "model",
+			Content: content,
+		},
+		Usage: &ai.GenerationUsage{
+			InputTokens:  mistralResp.Usage.PromptTokens,
+			OutputTokens: mistralResp.Usage.CompletionTokens,
+			TotalTokens:  mistralResp.Usage.PromptTokens + mistralResp.Usage.CompletionTokens,
+		},
+		FinishReason:  finishReason(stopReason),
+		FinishMessage: stopReason,
+	}, nil
+}
@@ -0,0 +1,277 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatMistralPrompt(t *testing.T) {
+	tests := []struct {
+		name     string
+		system   string
+		messages []*ai.Message
+		expected string
+	}{
+		{
+			name:     "single turn",
+			messages: []*ai.Message{ai.NewUserTextMessage("Hello!")},
+			expected: "<s>[INST] Hello! [/INST]",
+		},
+		{
+			name:     "system prompt",
+			system:   "Answer in French.",
+			messages: []*ai.Message{ai.NewUserTextMessage("Hello!")},
+			expected: "<s>[INST] Answer in French.\n\nHello! [/INST]",
+		},
+		{
+			name: "multi turn",
+			messages: []*ai.Message{
+				ai.NewUserTextMessage("Name a city in Portugal."),
+				ai.NewModelTextMessage("Lisbon."),
+				ai.NewUserTextMessage("And one in Spain?"),
+			},
+			expected: "<s>[INST] Name a city in Portugal. [/INST] Lisbon.</s>[INST] And one in Spain? [/INST]",
+		},
+		{
+			name: "prefill",
+			messages: []*ai.Message{
+				ai.NewUserTextMessage("Think of a fruit."),
+				ai.NewUserTextMessage("Now name it."),
+				ai.NewModelTextMessage("The fruit is"),
+			},
+			expected: "<s>[INST] Think of a fruit.\n\nNow name it. [/INST] The fruit is",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompt, err := formatMistralPrompt(tt.system, tt.messages)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, prompt)
+		})
+	}
+}
+
+func TestIsMistralChatModel(t *testing.T) {
+	tests := []struct {
+		modelID  string
+		expected bool
+	}{
+		{"mistral.mistral-large-2402-v1:0", true},
+		{"mistral.mistral-large-2407-v1:0", true},
+		{"mistral.mistral-small-2402-v1:0", true},
+		{"us.mistral.pixtral-large-2502-v1:0", true},
+		{"MISTRAL.MISTRAL-LARGE-2407-V1:0", true},
+		{"mistral.mistral-7b-instruct-v0:2", false},
+		{"mistral.mixtral-8x7b-instruct-v0:1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.modelID, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isMistralChatModel(tt.modelID))
+		})
+	}
+}
+
+func TestModel_convertMistralRequest_Instruct(t *testing.T) {
+	model := &Model{
+		modelID: "mistral.mixtral-8x7b-instruct-v0:1",
+		config: &ModelConfig{
+			MaxTokens:     512,
+			Temperature:   0.5,
+			TopP:          0.9,
+			TopK:          50,
+			StopSequences: []string{"###"},
+		},
+	}
+
+	result, err := model.convertMistralRequest(&ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewSystemTextMessage("Be brief."),
+			ai.NewUserTextMessage("Hello!"),
+		},
+	})
+	require.NoError(t, err)
+
+	var mistralReq map[string]interface{}
+	require.NoError(t, json.Unmarshal(result, &mistralReq))
+	assert.Equal(t, "<s>[INST] Be brief.\n\nHello! [/INST]", mistralReq["prompt"])
+	assert.Equal(t, float64(512), mistralReq["max_tokens"])
+	assert.Equal(t, 0.5, mistralReq["temperature"])
+	assert.Equal(t, 0.9, mistralReq["top_p"])
+	assert.Equal(t, float64(50), mistralReq["top_k"])
+	assert.Equal(t, []interface{}{"###"}, mistralReq["stop"])
+	assert.NotContains(t, mistralReq, "messages")
+}
+
+func TestModel_convertMistralRequest_Chat(t *testing.T) {
+	model := &Model{modelID: "mistral.mistral-large-2407-v1:0", config: &ModelConfig{MaxTokens: 256}}
+
+	result, err := model.convertMistralRequest(&ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewSystemTextMessage("You are a weather bot."),
+			ai.NewUserTextMessage("Weather in Paris?"),
+			{
+				Role: ai.RoleModel,
+				Content: []*ai.Part{ai.NewToolRequestPart(&ai.ToolRequest{
+					Name:  "get_weather",
+					Ref:   "call_1",
+					Input: map[string]any{"city": "Paris"},
+				})},
+			},
+			{
+				Role: ai.RoleTool,
+				Content: []*ai.Part{ai.NewToolResponsePart(&ai.ToolResponse{
+					Name:   "get_weather",
+					Ref:    "call_1",
+					Output: map[string]any{"temp": 21},
+				})},
+			},
+		},
+		Tools: []*ai.ToolDefinition{{
+			Name:        "get_weather",
+			Description: "Look up the weather",
+			InputSchema: map[string]any{"type": "object"},
+		}},
+		ToolChoice: ai.ToolChoiceRequired,
+	})
+	require.NoError(t, err)
+
+	var mistralReq map[string]interface{}
+	require.NoError(t, json.Unmarshal(result, &mistralReq))
+	assert.NotContains(t, mistralReq, "prompt")
+	assert.Equal(t, "any", mistralReq["tool_choice"])
+
+	messages := mistralReq["messages"].([]interface{})
+	require.Len(t, messages, 4)
+	assert.Equal(t, map[string]interface{}{"role": "system", "content": "You are a weather bot."}, messages[0])
+	assert.Equal(t, map[string]interface{}{"role": "user", "content": "Weather in Paris?"}, messages[1])
+	assert.Equal(t, map[string]interface{}{
+		"role":    "assistant",
+		"content": "",
+		"tool_calls": []interface{}{map[string]interface{}{
+			"id": "call_1",
+			"function": map[string]interface{}{
+				"name":      "get_weather",
+				"arguments": `{"city":"Paris"}`,
+			},
+		}},
+	}, messages[2])
+	assert.Equal(t, map[string]interface{}{
+		"role":         "tool",
+		"tool_call_id": "call_1",
+		"name":         "get_weather",
+		"content":      `{"temp":21}`,
+	}, messages[3])
+
+	tools := mistralReq["tools"].([]interface{})
+	require.Len(t, tools, 1)
+	assert.Equal(t, map[string]interface{}{
+		"type": "function",
+		"function": map[string]interface{}{
+			"name":        "get_weather",
+			"description": "Look up the weather",
+			"parameters":  map[string]interface{}{"type": "object"},
+		},
+	}, tools[0])
+}
+
+func TestModel_convertMistralRequest_Errors(t *testing.T) {
+	model := &Model{modelID: "mistral.mistral-7b-instruct-v0:2", config: &ModelConfig{}}
+
+	_, err := model.convertMistralRequest(&ai.ModelRequest{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no messages")
+
+	_, err = model.convertMistralRequest(&ai.ModelRequest{
+		Messages: []*ai.Message{ai.NewUserMessage(ai.NewMediaPart("image/png", pngHeader))},
+	})
+	require.Error(t, err)
+}
+
+func TestModel_convertMistralResponse(t *testing.T) {
+	tests := []struct {
+		name         string
+		modelID      string
+		body         string
+		expected     []*ai.Part
+		wantFinish   ai.FinishReason
+		wantInput    int
+		wantOutput   int
+		wantErrorMsg string
+	}{
+		{
+			name:       "outputs",
+			modelID:    "mistral.mistral-7b-instruct-v0:2",
+			body:       `{"outputs":[{"text":" Lisbon.","stop_reason":"stop"}]}`,
+			expected:   []*ai.Part{ai.NewTextPart(" Lisbon.")},
+			wantFinish: ai.FinishReasonStop,
+		},
+		{
+			name:       "outputs truncated",
+			modelID:    "mistral.mixtral-8x7b-instruct-v0:1",
+			body:       `{"outputs":[{"text":"Once upon","stop_reason":"length"}]}`,
+			expected:   []*ai.Part{ai.NewTextPart("Once upon")},
+			wantFinish: ai.FinishReasonLength,
+		},
+		{
+			name:    "choices with text",
+			modelID: "mistral.mistral-large-2407-v1:0",
+			body: `{"choices":[{"index":0,"message":{"role":"assistant","content":"Hello!"},"stop_reason":"stop"}],` +
+				`"usage":{"prompt_tokens":9,"completion_tokens":3}}`,
+			expected:   []*ai.Part{ai.NewTextPart("Hello!")},
+			wantFinish: ai.FinishReasonStop,
+			wantInput:  9,
+			wantOutput: 3,
+		},
+		{
+			name:    "choices with tool calls",
+			modelID: "mistral.mistral-large-2407-v1:0",
+			body: `{"choices":[{"index":0,"message":{"role":"assistant","content":"","tool_calls":[` +
+				`{"id":"call_1","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]},"stop_reason":"tool_calls"}]}`,
+			expected: []*ai.Part{ai.NewToolRequestPart(&ai.ToolRequest{
+				Name:  "get_weather",
+				Ref:   "call_1",
+				Input: map[string]any{"city": "Paris"},
+			})},
+			wantFinish: ai.FinishReasonStop,
+		},
+		{
+			name:         "malformed tool arguments",
+			modelID:      "mistral.mistral-large-2407-v1:0",
+			body:         `{"choices":[{"message":{"tool_calls":[{"id":"call_1","function":{"name":"f","arguments":"{"}}]}}]}`,
+			wantErrorMsg: "failed to decode arguments of tool f",
+		},
+		{
+			name:         "empty",
+			modelID:      "mistral.mistral-7b-instruct-v0:2",
+			body:         `{}`,
+			wantErrorMsg: "no content in Mistral response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &Model{modelID: tt.modelID, config: &ModelConfig{}}
+			result, err := model.convertResponse([]byte(tt.body))
+			if tt.wantErrorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErrorMsg)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expected, result.Message.Content)
+			assert.Equal(t, tt.wantFinish, result.FinishReason)
+			assert.Equal(t, tt.wantInput, result.Usage.InputTokens)
+			assert.Equal(t, tt.wantOutput, result.Usage.OutputTokens)
+		})
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ModelIDKind is the kind of resource a Bedrock model identifier names
+type ModelIDKind string
+
+// Model identifier kinds
+const (
+	// ModelIDFoundation is a foundation model, such as
+	// anthropic.claude-3-5-sonnet-20241022-v2:0
+	ModelIDFoundation ModelIDKind = "foundation-model"
+
+	// ModelIDInferenceProfile is a cross-region inference profile, such as
+	// us.anthropic.claude-3-5-sonnet-20241022-v2:0
+	ModelIDInferenceProfile ModelIDKind = "inference-profile"
+
+	// ModelIDApplicationProfile is an application inference profile ARN
+	ModelIDApplicationProfile ModelIDKind = "application-inference-profile"
+
+	// ModelIDProvisioned is a provisioned throughput ARN
+	ModelIDProvisioned ModelIDKind = "provisioned-model"
+)
+
+// inferenceProfileGeographies are the prefixes of cross-region inference
+// profile IDs
+var inferenceProfileGeographies = []string{"us", "us-gov", "eu", "apac", "jp", "au", "ca", "global"}
+
+// ModelIdentifier is a parsed Bedrock model ID, inference profile ID or ARN
+type ModelIdentifier struct {
+	// ID is the identifier as given
+	ID string
+
+	// Kind is the kind of resource the identifier names
+	Kind ModelIDKind
+
+	// Foundation is the foundation model ID behind the identifier. It is
+	// empty for application inference profiles and provisioned throughput,
+	// whose ARNs do not name their model.
+	Foundation string
+
+	// Geography is the prefix of a cross-region inference profile
+	Geography string
+
+	// Region and AccountID are set for ARNs
+	Region    string
+	AccountID string
+}
+
+// ParseModelID parses a foundation model ID, an inference profile ID or a
+// Bedrock model ARN
+func ParseModelID(id string) (*ModelIdentifier, error) {
+	if id == "" {
+		return nil, fmt.Errorf("model ID cannot be empty")
+	}
+	if strings.ContainsAny(id, " \t\r\n") {
+		return nil, fmt.Errorf("model ID %q contains whitespace", id)
+	}
+
+	if strings.HasPrefix(id, "arn:") {
+		return parseModelARN(id)
+	}
+
+	return parseModelName(id, id)
+}
+
+// parseModelName parses a foundation model or inference profile ID
+func parseModelName(id, name string) (*ModelIdentifier, error) {
+	geography, foundation, found := strings.Cut(name, ".")
+	if !found || geography == "" || foundation == "" {
+		return nil, fmt.Errorf("model ID %q must have the form provider.model", name)
+	}
+
+	for _, geo := range inferenceProfileGeographies {
+		if geography != geo {
+			continue
+		}
+		if _, model, found := strings.Cut(foundation, "."); !found || model == "" {
+			return nil, fmt.Errorf("inference profile %q must have the form %s.provider.model", name, geo)
+		}
+		return &ModelIdentifier{
+			ID:         id,
+			Kind:       ModelIDInferenceProfile,
+			Foundation: foundation,
+			Geography:  geo,
+		}, nil
+	}
+
+	return &ModelIdentifier{
+		ID:         id,
+		Kind:       ModelIDFoundation,
+		Foundation: name,
+	}, nil
+}
+
+// parseModelARN parses a Bedrock ARN of the form
+// arn:partition:bedrock:region:account:type/id
+func parseModelARN(arn string) (*ModelIdentifier, error) {
+	// Foundation model IDs contain colons, so the resource is everything
+	// after the fifth
+	fields := strings.SplitN(arn, ":", 6)
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("malformed ARN %q: expected arn:partition:bedrock:region:account:resource", arn)
+	}
+	partition, service, region, account, resource := fields[1], fields[2], fields[3], fields[4], fields[5]
+
+	switch partition {
+	case "aws", "aws-cn", "aws-us-gov":
+	default:
+		return nil, fmt.Errorf("malformed ARN %q: unknown partition %q", arn, partition)
+	}
+	if service != "bedrock" {
+		return nil, fmt.Errorf("malformed ARN %q: service must be bedrock, not %q", arn, service)
+	}
+	if region == "" {
+		return nil, fmt.Errorf("malformed ARN %q: region is missing", arn)
+	}
+
+	kind, name, found := strings.Cut(resource, "/")
+	if !found || name == "" {
+		return nil, fmt.Errorf("malformed ARN %q: resource must have the form type/id", arn)
+	}
+
+	// Foundation models belong to no account, everything else to one
+	if ModelIDKind(kind) == ModelIDFoundation {
+		if account != "" {
+			return nil, fmt.Errorf("malformed ARN %q: foundation model ARNs have no account ID", arn)
+		}
+	} else if !isAccountID(account) {
+		return nil, fmt.Errorf("malformed ARN %q: account ID must be 12 digits", arn)
+	}
+
+	var id *ModelIdentifier
+	switch ModelIDKind(kind) {
+	case ModelIDFoundation:
+		parsed, err := parseModelName(arn, name)
+		if err != nil {
+			return nil, err
+		}
+		if parsed.Kind != ModelIDFoundation {
+			return nil, fmt.Errorf("malformed ARN %q: %q is an inference profile, not a foundation model", arn, name)
+		}
+		id = parsed
+	case ModelIDInferenceProfile:
+		parsed, err := parseModelName(arn, name)
+		if err != nil {
+			return nil, err
+		}
+		if parsed.Kind != ModelIDInferenceProfile {
+			return nil, fmt.Errorf("malformed ARN %q: %q is not an inference profile ID", arn, name)
+		}
+		id = parsed
+	case ModelIDApplicationProfile, ModelIDProvisioned:
+		if strings.Contains(name, "/") {
+			return nil, fmt.Errorf("malformed ARN %q: resource ID cannot contain /", arn)
+		}
+		id = &ModelIdentifier{ID: arn, Kind: ModelIDKind(kind)}
+	default:
+		return nil, fmt.Errorf("malformed ARN %q: unsupported resource type %q", arn, kind)
+	}
+
+	id.Region = region
+	id.AccountID = account
+	return id, nil
+}
+
+// isAccountID reports whether s is a 12-digit AWS account ID
+func isAccountID(s string) bool {
+	if len(s) != 12 {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ResolveModel returns the foundation model ID behind a model identifier.
+// Application inference profiles and provisioned throughput are looked up in
+// BaseModels; when they are not there the result is empty.
+func (c *Config) ResolveModel(modelID string) (string, error) {
+	if base, exists := c.BaseModels[modelID]; exists {
+		id, err := ParseModelID(base)
+		if err != nil {
+			return "", fmt.Errorf("invalid base model for %s: %w", modelID, err)
+		}
+		return id.Foundation, nil
+	}
+
+	id, err := ParseModelID(modelID)
+	if err != nil {
+		return "", err
+	}
+	return id.Foundation, nil
+}
+
+// foundationModel returns the foundation model ID used to pick the request
+// format and capabilities, falling back to the model ID itself
+func (m *Model) foundationModel() string {
+	if m.foundation != "" {
+		return m.foundation
+	}
+	return m.modelID
+}
+
+// foundationModel returns the foundation model ID behind the embedder
+func (e *Embedder) foundationModel() string {
+	if e.foundation != "" {
+		return e.foundation
+	}
+	return e.modelID
+}
@@ -0,0 +1,247 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"context"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseModelID(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		want    *ModelIdentifier
+		wantErr string
+	}{
+		{
+			name: "foundation model",
+			id:   "anthropic.claude-3-5-sonnet-20241022-v2:0",
+			want: &ModelIdentifier{
+				ID:         "anthropic.claude-3-5-sonnet-20241022-v2:0",
+				Kind:       ModelIDFoundation,
+				Foundation: "anthropic.claude-3-5-sonnet-20241022-v2:0",
+			},
+		},
+		{
+			name: "us inference profile",
+			id:   "us.anthropic.claude-3-5-sonnet-20241022-v2:0",
+			want: &ModelIdentifier{
+				ID:         "us.anthropic.claude-3-5-sonnet-20241022-v2:0",
+				Kind:       ModelIDInferenceProfile,
+				Foundation: "anthropic.claude-3-5-sonnet-20241022-v2:0",
+				Geography:  "us",
+			},
+		},
+		{
+			name: "apac inference profile",
+			id:   "apac.amazon.nova-pro-v1:0",
+			want: &ModelIdentifier{
+				ID:         "apac.amazon.nova-pro-v1:0",
+				Kind:       ModelIDInferenceProfile,
+				Foundation: "amazon.nova-pro-v1:0",
+				Geography:  "apac",
+			},
+		},
+		{
+			name: "global inference profile",
+			id:   "global.anthropic.claude-sonnet-4-20250514-v1:0",
+			want: &ModelIdentifier{
+				ID:         "global.anthropic.claude-sonnet-4-20250514-v1:0",
+				Kind:       ModelIDInferenceProfile,
+				Foundation: "anthropic.claude-sonnet-4-20250514-v1:0",
+				Geography:  "global",
+			},
+		},
+		{
+			name: "foundation model ARN",
+			id:   "arn:aws:bedrock:us-east-1::foundation-model/meta.llama3-1-70b-instruct-v1:0",
+			want: &ModelIdentifier{
+				ID:         "arn:aws:bedrock:us-east-1::foundation-model/meta.llama3-1-70b-instruct-v1:0",
+				Kind:       ModelIDFoundation,
+				Foundation: "meta.llama3-1-70b-instruct-v1:0",
+				Region:     "us-east-1",
+			},
+		},
+		{
+			name: "inference profile ARN",
+			id:   "arn:aws:bedrock:eu-west-1:123456789012:inference-profile/eu.mistral.pixtral-large-2502-v1:0",
+			want: &ModelIdentifier{
+				ID:         "arn:aws:bedrock:eu-west-1:123456789012:inference-profile/eu.mistral.pixtral-large-2502-v1:0",
+				Kind:       ModelIDInferenceProfile,
+				Foundation: "mistral.pixtral-large-2502-v1:0",
+				Geography:  "eu",
+				Region:     "eu-west-1",
+				AccountID:  "123456789012",
+			},
+		},
+		{
+			name: "application inference profile ARN",
+			id:   "arn:aws:bedrock:us-west-2:123456789012:application-inference-profile/a1b2c3d4e5f6",
+			want: &ModelIdentifier{
+				ID:        "arn:aws:bedrock:us-west-2:123456789012:application-inference-profile/a1b2c3d4e5f6",
+				Kind:      ModelIDApplicationProfile,
+				Region:    "us-west-2",
+				AccountID: "123456789012",
+			},
+		},
+		{
+			name: "provisioned throughput ARN",
+			id:   "arn:aws-us-gov:bedrock:us-gov-west-1:123456789012:provisioned-model/abc123xyz",
+			want: &ModelIdentifier{
+				ID:        "arn:aws-us-gov:bedrock:us-gov-west-1:123456789012:provisioned-model/abc123xyz",
+				Kind:      ModelIDProvisioned,
+				Region:    "us-gov-west-1",
+				AccountID: "123456789012",
+			},
+		},
+		{name: "empty", id: "", wantErr: "model ID cannot be empty"},
+		{name: "whitespace", id: "anthropic.claude v2", wantErr: "contains whitespace"},
+		{name: "no provider", id: "claude-3-sonnet", wantErr: "must have the form provider.model"},
+		{name: "bare profile", id: "us.claude-3-sonnet", wantErr: "must have the form us.provider.model"},
+		{name: "short ARN", id: "arn:aws:bedrock:us-east-1", wantErr: "expected arn:partition:bedrock:region:account:resource"},
+		{name: "unknown partition", id: "arn:gcp:bedrock:us-east-1:123456789012:provisioned-model/abc", wantErr: `unknown partition "gcp"`},
+		{name: "wrong service", id: "arn:aws:sagemaker:us-east-1:123456789012:provisioned-model/abc", wantErr: "service must be bedrock"},
+		{name: "missing region", id: "arn:aws:bedrock::123456789012:provisioned-model/abc", wantErr: "region is missing"},
+		{name: "bad account", id: "arn:aws:bedrock:us-east-1:1234:provisioned-model/abc", wantErr: "account ID must be 12 digits"},
+		{name: "foundation ARN with account", id: "arn:aws:bedrock:us-east-1:123456789012:foundation-model/amazon.nova-pro-v1:0", wantErr: "foundation model ARNs have no account ID"},
+		{name: "profile as foundation ARN", id: "arn:aws:bedrock:us-east-1::foundation-model/us.amazon.nova-pro-v1:0", wantErr: "is an inference profile, not a foundation model"},
+		{name: "foundation as profile ARN", id: "arn:aws:bedrock:us-east-1:123456789012:inference-profile/amazon.nova-pro-v1:0", wantErr: "is not an inference profile ID"},
+		{name: "missing resource ID", id: "arn:aws:bedrock:us-east-1:123456789012:provisioned-model/", wantErr: "resource must have the form type/id"},
+		{name: "unsupported resource", id: "arn:aws:bedrock:us-east-1:123456789012:agent/abc", wantErr: `unsupported resource type "agent"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseModelID(tt.id)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConfig_ResolveModel(t *testing.T) {
+	profileARN := "arn:aws:bedrock:us-east-1:123456789012:application-inference-profile/a1b2c3d4e5f6"
+	config := &Config{
+		Models: []string{profileARN},
+		BaseModels: map[string]string{
+			profileARN: "us.anthropic.claude-3-5-haiku-20241022-v1:0",
+		},
+	}
+	require.NoError(t, config.Validate())
+
+	foundation, err := config.ResolveModel(profileARN)
+	require.NoError(t, err)
+	assert.Equal(t, "anthropic.claude-3-5-haiku-20241022-v1:0", foundation)
+
+	foundation, err = config.ResolveModel("eu.amazon.nova-lite-v1:0")
+	require.NoError(t, err)
+	assert.Equal(t, "amazon.nova-lite-v1:0", foundation)
+
+	foundation, err = config.ResolveModel("arn:aws:bedrock:us-east-1:123456789012:provisioned-model/abc123")
+	require.NoError(t, err)
+	assert.Empty(t, foundation)
+
+	_, err = config.ResolveModel("arn:aws:bedrock:us-east-1")
+	assert.Error(t, err)
+}
+
+func TestConfig_Validate_ModelIDs(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *Config
+		wantErr string
+	}{
+		{
+			name:   "inference profile",
+			config: &Config{Models: []string{"us.amazon.nova-pro-v1:0"}},
+		},
+		{
+			name:    "malformed ARN",
+			config:  &Config{Models: []string{"arn:aws:bedrock:us-east-1:provisioned-model/abc"}},
+			wantErr: "invalid model ID: malformed ARN",
+		},
+		{
+			name: "malformed base model key",
+			config: &Config{
+				Models:     []string{"amazon.nova-pro-v1:0"},
+				BaseModels: map[string]string{"arn:aws:bedrock:us-east-1:12:provisioned-model/abc": "amazon.nova-pro-v1:0"},
+			},
+			wantErr: "invalid base_models key",
+		},
+		{
+			name: "opaque base model",
+			config: &Config{
+				Models: []string{"amazon.nova-pro-v1:0"},
+				BaseModels: map[string]string{
+					"arn:aws:bedrock:us-east-1:123456789012:provisioned-model/abc": "arn:aws:bedrock:us-east-1:123456789012:provisioned-model/def",
+				},
+			},
+			wantErr: "must name a foundation model",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestClient_Model_Foundation(t *testing.T) {
+	profileARN := "arn:aws:bedrock:us-east-1:123456789012:application-inference-profile/a1b2c3d4e5f6"
+	provisionedARN := "arn:aws:bedrock:us-east-1:123456789012:provisioned-model/abc123"
+	client := &Client{config: &Config{
+		Models:     []string{profileARN, provisionedARN},
+		BaseModels: map[string]string{profileARN: "anthropic.claude-3-5-sonnet-20241022-v2:0"},
+	}}
+
+	t.Run("inference profile", func(t *testing.T) {
+		model := client.Model("us.amazon.nova-pro-v1:0")
+		assert.Equal(t, "amazon.nova-pro-v1:0", model.foundationModel())
+		assert.True(t, model.Supports().Tools)
+		assert.True(t, model.Supports().Media)
+	})
+
+	t.Run("mapped application profile", func(t *testing.T) {
+		model := client.Model(profileARN)
+		assert.Equal(t, "anthropic.claude-3-5-sonnet-20241022-v2:0", model.foundationModel())
+
+		body, err := model.convertRequest(&ai.ModelRequest{
+			Messages: []*ai.Message{ai.NewUserTextMessage("Hello")},
+		})
+		require.NoError(t, err)
+		assert.Contains(t, string(body), "anthropic_version")
+	})
+
+	t.Run("unmapped provisioned model", func(t *testing.T) {
+		model := client.Model(provisionedARN)
+		_, err := model.Generate(context.Background(), &ai.ModelRequest{
+			Messages: []*ai.Message{ai.NewUserTextMessage("Hello")},
+		}, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "add it to BaseModels or set UseConverse")
+	})
+
+	t.Run("embedder", func(t *testing.T) {
+		embedder := client.Embedder("arn:aws:bedrock:us-east-1::foundation-model/amazon.titan-embed-text-v2:0")
+		assert.Equal(t, "amazon.titan-embed-text-v2:0", embedder.foundationModel())
+		assert.Equal(t, 1024, embedder.Dimensions())
+	})
+}
@@ -0,0 +1,296 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/firebase/genkit/go/ai"
+)
+
+// Guardrail content sources
+const (
+	GuardrailSourceInput  = "INPUT"
+	GuardrailSourceOutput = "OUTPUT"
+)
+
+// Guardrail policies reported in assessments
+const (
+	GuardrailPolicyTopic                = "topic"
+	GuardrailPolicyContent              = "content"
+	GuardrailPolicyWord                 = "word"
+	GuardrailPolicySensitiveInformation = "sensitive_information"
+	GuardrailPolicyContextualGrounding  = "contextual_grounding"
+)
+
+// GuardrailInput is content to check against a guardrail
+type GuardrailInput struct {
+	// Source says whether the content is user input (INPUT, the default) or
+	// model or tool output (OUTPUT)
+	Source string `json:"source,omitempty"`
+
+	// Text holds the text blocks to check
+	Text []string `json:"text,omitempty"`
+
+	// Images holds PNG or JPEG images as base64 data URIs
+	Images []string `json:"images,omitempty"`
+
+	// FullOutput returns every assessment rather than only those that
+	// intervened
+	FullOutput bool `json:"full_output,omitempty"`
+}
+
+// GuardrailResult is the outcome of checking content against a guardrail
+type GuardrailResult struct {
+	// Action is NONE or GUARDRAIL_INTERVENED
+	Action string `json:"action"`
+
+	// Intervened is true when the guardrail blocked or masked content
+	Intervened bool `json:"intervened"`
+
+	// ActionReason explains the action, when Bedrock gives a reason
+	ActionReason string `json:"action_reason,omitempty"`
+
+	// Outputs is the content to use in place of the input when the guardrail
+	// intervened: the blocked message, or the text with sensitive
+	// information masked
+	Outputs []string `json:"outputs,omitempty"`
+
+	// Assessments lists the findings of each policy
+	Assessments []GuardrailAssessment `json:"assessments,omitempty"`
+}
+
+// GuardrailAssessment is a single policy finding
+type GuardrailAssessment struct {
+	// Policy is the guardrail policy that made the finding
+	Policy string `json:"policy"`
+
+	// Name is the denied topic or regex name
+	Name string `json:"name,omitempty"`
+
+	// Type is the content filter, PII entity, managed word list or
+	// grounding filter type
+	Type string `json:"type,omitempty"`
+
+	// Match is the matched text, for word and sensitive information policies
+	Match string `json:"match,omitempty"`
+
+	// Action is what the guardrail did, such as BLOCKED or ANONYMIZED
+	Action string `json:"action"`
+
+	// Detected is true when the finding triggered the policy
+	Detected bool `json:"detected"`
+
+	// Confidence is the content filter confidence
+	Confidence string `json:"confidence,omitempty"`
+
+	// Score and Threshold are the contextual grounding score and the
+	// threshold it is measured against
+	Score     float64 `json:"score,omitempty"`
+	Threshold float64 `json:"threshold,omitempty"`
+}
+
+// Guardrail checks content against a Bedrock guardrail outside of model calls
+type Guardrail struct {
+	client  *Client
+	id      string
+	version string
+}
+
+// Guardrail returns a guardrail for the given ID or ARN and version
+func (c *Client) Guardrail(id, version string) *Guardrail {
+	return &Guardrail{
+		client:  c,
+		id:      id,
+		version: version,
+	}
+}
+
+// Apply checks content against the guardrail with the ApplyGuardrail API
+func (g *Guardrail) Apply(ctx context.Context, input *GuardrailInput) (*GuardrailResult, error) {
+	req, err := g.convertGuardrailInput(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert guardrail input: %w", err)
+	}
+
+	output, err := g.client.runtime.ApplyGuardrail(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("bedrock apply guardrail failed: %w", err)
+	}
+
+	return convertGuardrailOutput(output), nil
+}
+
+// convertGuardrailInput builds an ApplyGuardrail request
+func (g *Guardrail) convertGuardrailInput(input *GuardrailInput) (*bedrockruntime.ApplyGuardrailInput, error) {
+	if g.id == "" || g.version == "" {
+		return nil, errors.New("guardrail ID and version are required")
+	}
+
+	if input == nil || len(input.Text)+len(input.Images) == 0 {
+		return nil, errors.New("no content to check")
+	}
+
+	source := types.GuardrailContentSourceInput
+	switch input.Source {
+	case "", GuardrailSourceInput:
+	case GuardrailSourceOutput:
+		source = types.GuardrailContentSourceOutput
+	default:
+		return nil, fmt.Errorf("source must be %s or %s, not %q", GuardrailSourceInput, GuardrailSourceOutput, input.Source)
+	}
+
+	var content []types.GuardrailContentBlock
+	for _, text := range input.Text {
+		content = append(content, &types.GuardrailContentBlockMemberText{
+			Value: types.GuardrailTextBlock{Text: aws.String(text)},
+		})
+	}
+
+	for i, image := range input.Images {
+		media, err := decodeMedia(&ai.Part{Text: image})
+		if err != nil {
+			return nil, fmt.Errorf("image %d: %w", i, err)
+		}
+
+		var format types.GuardrailImageFormat
+		switch media.mimeType {
+		case "image/png":
+			format = types.GuardrailImageFormatPng
+		case "image/jpeg":
+			format = types.GuardrailImageFormatJpeg
+		default:
+			return nil, fmt.Errorf("image %d: guardrails accept PNG or JPEG images, not %q", i, media.mimeType)
+		}
+
+		content = append(content, &types.GuardrailContentBlockMemberImage{
+			Value: types.GuardrailImageBlock{
+				Format: format,
+				Source: &types.GuardrailImageSourceMemberBytes{Value: media.data},
+			},
+		})
+	}
+
+	req := &bedrockruntime.ApplyGuardrailInput{
+		GuardrailIdentifier: aws.String(g.id),
+		GuardrailVersion:    aws.String(g.version),
+		Source:              source,
+		Content:             content,
+	}
+	if input.FullOutput {
+		req.OutputScope = types.GuardrailOutputScopeFull
+	}
+
+	return req, nil
+}
+
+// convertGuardrailOutput converts an ApplyGuardrail response to a result
+func convertGuardrailOutput(output *bedrockruntime.ApplyGuardrailOutput) *GuardrailResult {
+	result := &GuardrailResult{
+		Action:       string(output.Action),
+		Intervened:   output.Action == types.GuardrailActionGuardrailIntervened,
+		ActionReason: aws.ToString(output.ActionReason),
+	}
+
+	for _, out := range output.Outputs {
+		result.Outputs = append(result.Outputs, aws.ToString(out.Text))
+	}
+
+	for _, assessment := range output.Assessments {
+		result.Assessments = append(result.Assessments, guardrailAssessments(assessment)...)
+	}
+
+	return result
+}
+
+// guardrailAssessments flattens the findings of every policy in an
+// assessment. Automated reasoning findings are not included.
+func guardrailAssessments(a types.GuardrailAssessment) []GuardrailAssessment {
+	var findings []GuardrailAssessment
+
+	if a.TopicPolicy != nil {
+		for _, topic := range a.TopicPolicy.Topics {
+			findings = append(findings, GuardrailAssessment{
+				Policy:   GuardrailPolicyTopic,
+				Name:     aws.ToString(topic.Name),
+				Type:     string(topic.Type),
+				Action:   string(topic.Action),
+				Detected: aws.ToBool(topic.Detected),
+			})
+		}
+	}
+
+	if a.ContentPolicy != nil {
+		for _, filter := range a.ContentPolicy.Filters {
+			findings = append(findings, GuardrailAssessment{
+				Policy:     GuardrailPolicyContent,
+				Type:       string(filter.Type),
+				Action:     string(filter.Action),
+				Detected:   aws.ToBool(filter.Detected),
+				Confidence: string(filter.Confidence),
+			})
+		}
+	}
+
+	if a.WordPolicy != nil {
+		for _, word := range a.WordPolicy.CustomWords {
+			findings = append(findings, GuardrailAssessment{
+				Policy:   GuardrailPolicyWord,
+				Match:    aws.ToString(word.Match),
+				Action:   string(word.Action),
+				Detected: aws.ToBool(word.Detected),
+			})
+		}
+		for _, word := range a.WordPolicy.ManagedWordLists {
+			findings = append(findings, GuardrailAssessment{
+				Policy:   GuardrailPolicyWord,
+				Type:     string(word.Type),
+				Match:    aws.ToString(word.Match),
+				Action:   string(word.Action),
+				Detected: aws.ToBool(word.Detected),
+			})
+		}
+	}
+
+	if a.SensitiveInformationPolicy != nil {
+		for _, entity := range a.SensitiveInformationPolicy.PiiEntities {
+			findings = append(findings, GuardrailAssessment{
+				Policy:   GuardrailPolicySensitiveInformation,
+				Type:     string(entity.Type),
+				Match:    aws.ToString(entity.Match),
+				Action:   string(entity.Action),
+				Detected: aws.ToBool(entity.Detected),
+			})
+		}
+		for _, regex := range a.SensitiveInformationPolicy.Regexes {
+			findings = append(findings, GuardrailAssessment{
+				Policy:   GuardrailPolicySensitiveInformation,
+				Name:     aws.ToString(regex.Name),
+				Match:    aws.ToString(regex.Match),
+				Action:   string(regex.Action),
+				Detected: aws.ToBool(regex.Detected),
+			})
+		}
+	}
+
+	if a.ContextualGroundingPolicy != nil {
+		for _, filter := range a.ContextualGroundingPolicy.Filters {
+			findings = append(findings, GuardrailAssessment{
+				Policy:    GuardrailPolicyContextualGrounding,
+				Type:      string(filter.Type),
+				Action:    string(filter.Action),
+				Detected:  aws.ToBool(filter.Detected),
+				Score:     aws.ToFloat64(filter.Score),
+				Threshold: aws.ToFloat64(filter.Threshold),
+			})
+		}
+	}
+
+	return findings
+}
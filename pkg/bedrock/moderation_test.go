@@ -0,0 +1,137 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuardrail_convertGuardrailInput(t *testing.T) {
+	image, _ := testImage()
+	guardrail := (&Client{config: &Config{}}).Guardrail("gr-compliance", "2")
+
+	t.Run("text and image input", func(t *testing.T) {
+		req, err := guardrail.convertGuardrailInput(&GuardrailInput{
+			Text:   []string{"What stocks should I buy?"},
+			Images: []string{image.Text},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "gr-compliance", aws.ToString(req.GuardrailIdentifier))
+		assert.Equal(t, "2", aws.ToString(req.GuardrailVersion))
+		assert.Equal(t, types.GuardrailContentSourceInput, req.Source)
+		assert.Empty(t, req.OutputScope)
+		require.Len(t, req.Content, 2)
+
+		text := req.Content[0].(*types.GuardrailContentBlockMemberText)
+		assert.Equal(t, "What stocks should I buy?", aws.ToString(text.Value.Text))
+
+		img := req.Content[1].(*types.GuardrailContentBlockMemberImage)
+		assert.Equal(t, types.GuardrailImageFormatPng, img.Value.Format)
+		assert.Equal(t, []byte("\x89PNG\r\n\x1a\n"), img.Value.Source.(*types.GuardrailImageSourceMemberBytes).Value)
+	})
+
+	t.Run("output with full scope", func(t *testing.T) {
+		req, err := guardrail.convertGuardrailInput(&GuardrailInput{
+			Source:     GuardrailSourceOutput,
+			Text:       []string{"Call me on 555-0100."},
+			FullOutput: true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, types.GuardrailContentSourceOutput, req.Source)
+		assert.Equal(t, types.GuardrailOutputScopeFull, req.OutputScope)
+	})
+
+	errorTests := []struct {
+		name      string
+		guardrail *Guardrail
+		input     *GuardrailInput
+		wantErr   string
+	}{
+		{"no content", guardrail, &GuardrailInput{}, "no content to check"},
+		{"nil input", guardrail, nil, "no content to check"},
+		{"unknown source", guardrail, &GuardrailInput{Source: "TOOL", Text: []string{"hi"}}, "source must be INPUT or OUTPUT"},
+		{"gif image", guardrail, &GuardrailInput{Images: []string{"data:image/gif;base64,R0lGODlh"}}, "PNG or JPEG"},
+		{"remote image", guardrail, &GuardrailInput{Images: []string{"https://example.com/a.png"}}, "remote media URL"},
+		{"missing version", &Guardrail{id: "gr-compliance"}, &GuardrailInput{Text: []string{"hi"}}, "guardrail ID and version are required"},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.guardrail.convertGuardrailInput(tt.input)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestConvertGuardrailOutput(t *testing.T) {
+	output := &bedrockruntime.ApplyGuardrailOutput{
+		Action:       types.GuardrailActionGuardrailIntervened,
+		ActionReason: aws.String("Guardrail masked."),
+		Outputs:      []types.GuardrailOutputContent{{Text: aws.String("Call me on {PHONE}.")}},
+		Assessments: []types.GuardrailAssessment{{
+			TopicPolicy: &types.GuardrailTopicPolicyAssessment{Topics: []types.GuardrailTopic{{
+				Name: aws.String("Investments"), Type: types.GuardrailTopicTypeDeny,
+				Action: types.GuardrailTopicPolicyActionBlocked, Detected: aws.Bool(true),
+			}}},
+			ContentPolicy: &types.GuardrailContentPolicyAssessment{Filters: []types.GuardrailContentFilter{{
+				Type: types.GuardrailContentFilterTypeInsults, Confidence: types.GuardrailContentFilterConfidenceLow,
+				Action: types.GuardrailContentPolicyActionNone, Detected: aws.Bool(false),
+			}}},
+			WordPolicy: &types.GuardrailWordPolicyAssessment{
+				CustomWords: []types.GuardrailCustomWord{{
+					Match: aws.String("acme"), Action: types.GuardrailWordPolicyActionBlocked, Detected: aws.Bool(true),
+				}},
+				ManagedWordLists: []types.GuardrailManagedWord{{
+					Match: aws.String("darn"), Type: types.GuardrailManagedWordTypeProfanity,
+					Action: types.GuardrailWordPolicyActionBlocked, Detected: aws.Bool(true),
+				}},
+			},
+			SensitiveInformationPolicy: &types.GuardrailSensitiveInformationPolicyAssessment{
+				PiiEntities: []types.GuardrailPiiEntityFilter{{
+					Match: aws.String("555-0100"), Type: types.GuardrailPiiEntityTypePhone,
+					Action: types.GuardrailSensitiveInformationPolicyActionAnonymized, Detected: aws.Bool(true),
+				}},
+				Regexes: []types.GuardrailRegexFilter{{
+					Name: aws.String("account"), Match: aws.String("AC-1234"),
+					Action: types.GuardrailSensitiveInformationPolicyActionAnonymized, Detected: aws.Bool(true),
+				}},
+			},
+			ContextualGroundingPolicy: &types.GuardrailContextualGroundingPolicyAssessment{
+				Filters: []types.GuardrailContextualGroundingFilter{{
+					Type: types.GuardrailContextualGroundingFilterTypeGrounding, Score: aws.Float64(0.4), Threshold: aws.Float64(0.7),
+					Action: types.GuardrailContextualGroundingPolicyActionBlocked, Detected: aws.Bool(true),
+				}},
+			},
+		}},
+	}
+
+	result := convertGuardrailOutput(output)
+
+	assert.Equal(t, "GUARDRAIL_INTERVENED", result.Action)
+	assert.True(t, result.Intervened)
+	assert.Equal(t, "Guardrail masked.", result.ActionReason)
+	assert.Equal(t, []string{"Call me on {PHONE}."}, result.Outputs)
+	assert.Equal(t, []GuardrailAssessment{
+		{Policy: GuardrailPolicyTopic, Name: "Investments", Type: "DENY", Action: "BLOCKED", Detected: true},
+		{Policy: GuardrailPolicyContent, Type: "INSULTS", Action: "NONE", Confidence: "LOW"},
+		{Policy: GuardrailPolicyWord, Match: "acme", Action: "BLOCKED", Detected: true},
+		{Policy: GuardrailPolicyWord, Type: "PROFANITY", Match: "darn", Action: "BLOCKED", Detected: true},
+		{Policy: GuardrailPolicySensitiveInformation, Type: "PHONE", Match: "555-0100", Action: "ANONYMIZED", Detected: true},
+		{Policy: GuardrailPolicySensitiveInformation, Name: "account", Match: "AC-1234", Action: "ANONYMIZED", Detected: true},
+		{Policy: GuardrailPolicyContextualGrounding, Type: "GROUNDING", Action: "BLOCKED", Detected: true, Score: 0.4, Threshold: 0.7},
+	}, result.Assessments)
+
+	passed := convertGuardrailOutput(&bedrockruntime.ApplyGuardrailOutput{Action: types.GuardrailActionNone})
+	assert.Equal(t, "NONE", passed.Action)
+	assert.False(t, passed.Intervened)
+	assert.Empty(t, passed.Assessments)
+}
@@ -0,0 +1,393 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/firebase/genkit/go/ai"
+)
+
+// Nova Reel limits: single-shot videos are 6 seconds long, multi-shot videos
+// are made of 6-second shots up to two minutes
+const (
+	reelShotSeconds      = 6
+	reelMaxSeconds       = 120
+	reelMaxImageBytes    = 10_000_000
+	reelDefaultDimension = "1280x720"
+	reelDefaultFPS       = 24
+)
+
+// Polling intervals for async invocations, doubling from the first up to the
+// maximum while the job runs
+const (
+	defaultPollInterval = 5 * time.Second
+	maxPollInterval     = time.Minute
+)
+
+// VideoConfig holds settings for video generation models
+type VideoConfig struct {
+	// OutputS3URI is the s3:// prefix Bedrock writes the video under
+	OutputS3URI string `json:"output_s3_uri,omitempty"`
+
+	// BucketOwner is the account that owns the output bucket, when it is
+	// not the caller's
+	BucketOwner string `json:"bucket_owner,omitempty"`
+
+	// KMSKeyID encrypts the output with a customer managed key
+	KMSKeyID string `json:"kms_key_id,omitempty"`
+
+	// DurationSeconds is the video length: 6 for a single shot, or a
+	// multiple of 6 up to 120 for an automated multi-shot video
+	DurationSeconds int `json:"duration_seconds,omitempty"`
+
+	// Seed makes generation repeatable; unset uses the model's default
+	Seed int `json:"seed,omitempty"`
+
+	// Wait makes Generate block until the video is ready. Otherwise Generate
+	// returns as soon as the job starts, with its ARN for CheckVideo.
+	Wait bool `json:"wait,omitempty"`
+
+	// PollIntervalSeconds is the first wait between status checks when
+	// waiting for the video
+	PollIntervalSeconds int `json:"poll_interval_seconds,omitempty"`
+}
+
+// Validate validates a video configuration
+func (vc *VideoConfig) Validate() error {
+	if vc.OutputS3URI != "" && !isS3URI(vc.OutputS3URI) {
+		return errors.New("output_s3_uri must be an s3:// URI")
+	}
+
+	if vc.DurationSeconds < 0 || vc.DurationSeconds%reelShotSeconds != 0 || vc.DurationSeconds > reelMaxSeconds {
+		return fmt.Errorf("duration_seconds must be a multiple of %d up to %d", reelShotSeconds, reelMaxSeconds)
+	}
+
+	if vc.Seed < 0 || vc.PollIntervalSeconds < 0 {
+		return errors.New("seed and poll_interval_seconds must be non-negative")
+	}
+
+	return nil
+}
+
+// overlay returns a copy of the config with every field set in override
+// replacing the corresponding field
+func (vc *VideoConfig) overlay(override *VideoConfig) *VideoConfig {
+	merged := *vc
+
+	if override.OutputS3URI != "" {
+		merged.OutputS3URI = override.OutputS3URI
+	}
+	if override.BucketOwner != "" {
+		merged.BucketOwner = override.BucketOwner
+	}
+	if override.KMSKeyID != "" {
+		merged.KMSKeyID = override.KMSKeyID
+	}
+	if override.DurationSeconds != 0 {
+		merged.DurationSeconds = override.DurationSeconds
+	}
+	if override.Seed != 0 {
+		merged.Seed = override.Seed
+	}
+	if override.Wait {
+		merged.Wait = true
+	}
+	if override.PollIntervalSeconds != 0 {
+		merged.PollIntervalSeconds = override.PollIntervalSeconds
+	}
+
+	return &merged
+}
+
+// asyncInvokeAPI is the part of the Bedrock runtime client that reports on
+// async invocations
+type asyncInvokeAPI interface {
+	GetAsyncInvoke(ctx context.Context, params *bedrockruntime.GetAsyncInvokeInput, optFns ...func(*bedrockruntime.Options)) (*bedrockruntime.GetAsyncInvokeOutput, error)
+}
+
+// StartVideo starts a Nova Reel job for the request and returns its
+// invocation ARN without waiting for the video
+func (m *Model) StartVideo(ctx context.Context, req *ai.ModelRequest) (string, error) {
+	if !isReelModel(m.foundationModel()) {
+		return "", fmt.Errorf("model %s does not generate video", m.modelID)
+	}
+
+	m, err := m.forRequest(req)
+	if err != nil {
+		return "", err
+	}
+	if m.config.GuardrailID != "" {
+		return "", fmt.Errorf("guardrails are not supported for model %s", m.modelID)
+	}
+
+	return m.startVideo(ctx, req)
+}
+
+// CheckVideo reports on a Nova Reel job started by StartVideo or Generate.
+// It returns the video once the job completes, nil while it is running and
+// an error if it failed.
+func (c *Client) CheckVideo(ctx context.Context, invocationARN string) (*ai.ModelResponse, error) {
+	return checkVideo(ctx, c.runtime, invocationARN)
+}
+
+// generateVideo starts a Nova Reel job and, if the config asks to wait,
+// polls it until it finishes. The job keeps running in Bedrock if the
+// context is cancelled, as async invocations cannot be stopped.
+func (m *Model) generateVideo(ctx context.Context, req *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+	arn, err := m.startVideo(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	config := m.config.videoConfig()
+	if !config.Wait {
+		return startedVideoResponse(arn), nil
+	}
+
+	interval := time.Duration(config.PollIntervalSeconds) * time.Second
+	if interval == 0 {
+		interval = defaultPollInterval
+	}
+
+	job, err := pollAsyncInvoke(ctx, m.client.runtime, arn, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := convertReelResponse(job)
+	if err != nil {
+		return nil, err
+	}
+
+	if cb != nil {
+		if err := cb(ctx, &ai.ModelResponseChunk{
+			Role:    "model",
+			Content: response.Message.Content,
+		}); err != nil {
+			return nil, fmt.Errorf("callback failed: %w", err)
+		}
+	}
+
+	return response, nil
+}
+
+// startVideo starts the async invocation for a request whose config is
+// already merged
+func (m *Model) startVideo(ctx context.Context, req *ai.ModelRequest) (string, error) {
+	input, err := m.convertReelRequest(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert request: %w", err)
+	}
+
+	started, err := m.client.runtime.StartAsyncInvoke(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("bedrock async invoke failed: %w", err)
+	}
+
+	return aws.ToString(started.InvocationArn), nil
+}
+
+// startedVideoResponse is the response for a job that is still running: it
+// has no content, and its invocation ARN is under Custom
+func startedVideoResponse(arn string) *ai.ModelResponse {
+	return &ai.ModelResponse{
+		Message: &ai.Message{
+			Role:    "model",
+			Content: []*ai.Part{},
+		},
+		Custom:        map[string]any{"invocationArn": arn},
+		FinishReason:  ai.FinishReasonOther,
+		FinishMessage: "video generation started; check it with CheckVideo",
+	}
+}
+
+// checkVideo gets the status of an async invocation once
+func checkVideo(ctx context.Context, api asyncInvokeAPI, arn string) (*ai.ModelResponse, error) {
+	job, err := getAsyncInvoke(ctx, api, arn)
+	if err != nil || job == nil {
+		return nil, err
+	}
+
+	return convertReelResponse(job)
+}
+
+// pollAsyncInvoke checks an async invocation with exponential backoff until
+// it completes, fails or the context is done
+func pollAsyncInvoke(ctx context.Context, api asyncInvokeAPI, arn string, interval time.Duration) (*bedrockruntime.GetAsyncInvokeOutput, error) {
+	for {
+		job, err := getAsyncInvoke(ctx, api, arn)
+		if err != nil || job != nil {
+			return job, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("stopped waiting for async invoke %s: %w", arn, ctx.Err())
+		case <-time.After(interval):
+		}
+
+		interval = min(interval*2, maxPollInterval)
+	}
+}
+
+// getAsyncInvoke returns an async invocation if it completed, nil if it is
+// still running and an error if it failed
+func getAsyncInvoke(ctx context.Context, api asyncInvokeAPI, arn string) (*bedrockruntime.GetAsyncInvokeOutput, error) {
+	job, err := api.GetAsyncInvoke(ctx, &bedrockruntime.GetAsyncInvokeInput{
+		InvocationArn: aws.String(arn),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get async invoke %s: %w", arn, err)
+	}
+
+	switch job.Status {
+	case types.AsyncInvokeStatusCompleted:
+		return job, nil
+	case types.AsyncInvokeStatusFailed:
+		return nil, fmt.Errorf("async invoke %s failed: %s", arn, aws.ToString(job.FailureMessage))
+	}
+
+	return nil, nil
+}
+
+// convertReelRequest builds the StartAsyncInvoke input for a Nova Reel job.
+// A 6-second video may start from an image; longer videos are generated as
+// automated multi-shot videos from the prompt alone.
+func (m *Model) convertReelRequest(req *ai.ModelRequest) (*bedrockruntime.StartAsyncInvokeInput, error) {
+	config := m.config.videoConfig()
+	if config.OutputS3URI == "" {
+		return nil, fmt.Errorf("output_s3_uri is required for Nova Reel")
+	}
+
+	prompt, images, err := reelInput(req)
+	if err != nil {
+		return nil, err
+	}
+
+	duration := config.DurationSeconds
+	if duration == 0 {
+		duration = reelShotSeconds
+	}
+
+	generationConfig := map[string]interface{}{
+		"durationSeconds": duration,
+		"fps":             reelDefaultFPS,
+		"dimension":       reelDefaultDimension,
+	}
+	if config.Seed > 0 {
+		generationConfig["seed"] = config.Seed
+	}
+
+	params := map[string]interface{}{"text": prompt}
+	reelReq := map[string]interface{}{
+		"videoGenerationConfig": generationConfig,
+	}
+
+	if duration == reelShotSeconds {
+		if len(images) > 0 {
+			params["images"] = images
+		}
+		reelReq["taskType"] = "TEXT_VIDEO"
+		reelReq["textToVideoParams"] = params
+	} else {
+		if len(images) > 0 {
+			return nil, fmt.Errorf("an input image is only supported for %d-second videos", reelShotSeconds)
+		}
+		reelReq["taskType"] = "MULTI_SHOT_AUTOMATED"
+		reelReq["multiShotAutomatedParams"] = params
+	}
+
+	output := types.AsyncInvokeS3OutputDataConfig{S3Uri: aws.String(config.OutputS3URI)}
+	if config.BucketOwner != "" {
+		output.BucketOwner = aws.String(config.BucketOwner)
+	}
+	if config.KMSKeyID != "" {
+		output.KmsKeyId = aws.String(config.KMSKeyID)
+	}
+
+	return &bedrockruntime.StartAsyncInvokeInput{
+		ModelId:          aws.String(m.modelID),
+		ModelInput:       document.NewLazyDocument(reelReq),
+		OutputDataConfig: &types.AsyncInvokeOutputDataConfigMemberS3OutputDataConfig{Value: output},
+	}, nil
+}
+
+// reelInput extracts the prompt and the optional starting image, as Nova
+// image blocks, from the last message of a request
+func reelInput(req *ai.ModelRequest) (string, []map[string]interface{}, error) {
+	if len(req.Messages) == 0 {
+		return "", nil, fmt.Errorf("no messages in request")
+	}
+
+	var prompt strings.Builder
+	var images []map[string]interface{}
+	for _, part := range req.Messages[len(req.Messages)-1].Content {
+		switch {
+		case part.IsText():
+			prompt.WriteString(part.Text)
+		case part.IsMedia():
+			if len(images) > 0 {
+				return "", nil, fmt.Errorf("only one starting image is supported by Nova Reel")
+			}
+			image, format, err := decodeImage(part, reelMaxImageBytes)
+			if err != nil {
+				return "", nil, err
+			}
+			if format != "png" && format != "jpeg" {
+				return "", nil, fmt.Errorf("unsupported image type %q for Nova Reel, use PNG or JPEG", image.mimeType)
+			}
+			images = append(images, map[string]interface{}{
+				"format": format,
+				"source": map[string]interface{}{"bytes": image.base64()},
+			})
+		default:
+			return "", nil, fmt.Errorf("unsupported part kind %d for Nova Reel", part.Kind)
+		}
+	}
+
+	text := strings.TrimSpace(prompt.String())
+	if text == "" {
+		return "", nil, fmt.Errorf("a text prompt is required for Nova Reel")
+	}
+
+	return text, images, nil
+}
+
+// convertReelResponse returns the video of a completed Nova Reel job, which
+// Bedrock writes as output.mp4 under the job's output location
+func convertReelResponse(job *bedrockruntime.GetAsyncInvokeOutput) (*ai.ModelResponse, error) {
+	output, ok := job.OutputDataConfig.(*types.AsyncInvokeOutputDataConfigMemberS3OutputDataConfig)
+	if !ok {
+		return nil, fmt.Errorf("no S3 output in async invoke %s", aws.ToString(job.InvocationArn))
+	}
+
+	uri := strings.TrimSuffix(aws.ToString(output.Value.S3Uri), "/") + "/output.mp4"
+	video := ai.NewMediaPart("video/mp4", uri)
+	if owner := aws.ToString(output.Value.BucketOwner); owner != "" {
+		video.Metadata = map[string]any{"bucketOwner": owner}
+	}
+
+	return &ai.ModelResponse{
+		Message: &ai.Message{
+			Role:    "model",
+			Content: []*ai.Part{video},
+		},
+		Usage:        &ai.GenerationUsage{OutputVideos: 1},
+		Custom:       map[string]any{"invocationArn": aws.ToString(job.InvocationArn)},
+		FinishReason: ai.FinishReasonStop,
+	}, nil
+}
+
+func isReelModel(modelID string) bool {
+	return strings.Contains(strings.ToLower(modelID), "nova-reel")
+}
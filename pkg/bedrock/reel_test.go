@@ -0,0 +1,266 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAsyncInvoke replays a sequence of async invoke statuses
+type fakeAsyncInvoke struct {
+	outputs []*bedrockruntime.GetAsyncInvokeOutput
+	calls   int
+}
+
+func (f *fakeAsyncInvoke) GetAsyncInvoke(_ context.Context, params *bedrockruntime.GetAsyncInvokeInput, _ ...func(*bedrockruntime.Options)) (*bedrockruntime.GetAsyncInvokeOutput, error) {
+	if f.calls >= len(f.outputs) {
+		return nil, errors.New("unexpected call")
+	}
+	output := f.outputs[f.calls]
+	output.InvocationArn = params.InvocationArn
+	f.calls++
+	return output, nil
+}
+
+func TestVideoConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *VideoConfig
+		wantErr string
+	}{
+		{"empty", &VideoConfig{}, ""},
+		{"multi-shot", &VideoConfig{OutputS3URI: "s3://videos/reel/", DurationSeconds: 24}, ""},
+		{"not s3", &VideoConfig{OutputS3URI: "https://videos.example.com"}, "output_s3_uri must be an s3:// URI"},
+		{"partial shot", &VideoConfig{DurationSeconds: 10}, "duration_seconds must be a multiple of 6 up to 120"},
+		{"too long", &VideoConfig{DurationSeconds: 126}, "duration_seconds must be a multiple of 6 up to 120"},
+		{"negative interval", &VideoConfig{PollIntervalSeconds: -1}, "must be non-negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestModel_convertReelRequest(t *testing.T) {
+	image, encoded := testImage()
+
+	tests := []struct {
+		name    string
+		config  *VideoConfig
+		content []*ai.Part
+		want    map[string]any
+		wantErr string
+	}{
+		{
+			name:    "text to video",
+			config:  &VideoConfig{OutputS3URI: "s3://videos/reel/", Seed: 3},
+			content: []*ai.Part{ai.NewTextPart("Waves rolling onto a beach")},
+			want: map[string]any{
+				"taskType":          "TEXT_VIDEO",
+				"textToVideoParams": map[string]any{"text": "Waves rolling onto a beach"},
+				"videoGenerationConfig": map[string]any{
+					"durationSeconds": float64(6),
+					"fps":             float64(24),
+					"dimension":       "1280x720",
+					"seed":            float64(3),
+				},
+			},
+		},
+		{
+			name:    "starting image",
+			config:  &VideoConfig{OutputS3URI: "s3://videos/reel/"},
+			content: []*ai.Part{ai.NewTextPart("Pan right"), image},
+			want: map[string]any{
+				"taskType": "TEXT_VIDEO",
+				"textToVideoParams": map[string]any{
+					"text": "Pan right",
+					"images": []any{map[string]any{
+						"format": "png",
+						"source": map[string]any{"bytes": encoded},
+					}},
+				},
+				"videoGenerationConfig": map[string]any{
+					"durationSeconds": float64(6),
+					"fps":             float64(24),
+					"dimension":       "1280x720",
+				},
+			},
+		},
+		{
+			name:    "multi-shot",
+			config:  &VideoConfig{OutputS3URI: "s3://videos/reel/", DurationSeconds: 18},
+			content: []*ai.Part{ai.NewTextPart("A day in the city")},
+			want: map[string]any{
+				"taskType":                 "MULTI_SHOT_AUTOMATED",
+				"multiShotAutomatedParams": map[string]any{"text": "A day in the city"},
+				"videoGenerationConfig": map[string]any{
+					"durationSeconds": float64(18),
+					"fps":             float64(24),
+					"dimension":       "1280x720",
+				},
+			},
+		},
+		{
+			name:    "multi-shot with image",
+			config:  &VideoConfig{OutputS3URI: "s3://videos/reel/", DurationSeconds: 18},
+			content: []*ai.Part{ai.NewTextPart("A day in the city"), image},
+			wantErr: "only supported for 6-second videos",
+		},
+		{
+			name:    "no output location",
+			content: []*ai.Part{ai.NewTextPart("Waves")},
+			wantErr: "output_s3_uri is required",
+		},
+		{
+			name:    "no prompt",
+			config:  &VideoConfig{OutputS3URI: "s3://videos/reel/"},
+			content: []*ai.Part{image},
+			wantErr: "a text prompt is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &Model{modelID: "amazon.nova-reel-v1:1", config: &ModelConfig{Video: tt.config}}
+			input, err := model.convertReelRequest(&ai.ModelRequest{
+				Messages: []*ai.Message{ai.NewUserMessage(tt.content...)},
+			})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, "amazon.nova-reel-v1:1", aws.ToString(input.ModelId))
+			assert.Equal(t, &types.AsyncInvokeOutputDataConfigMemberS3OutputDataConfig{
+				Value: types.AsyncInvokeS3OutputDataConfig{S3Uri: aws.String("s3://videos/reel/")},
+			}, input.OutputDataConfig)
+
+			reelReq, err := decodeDocument(input.ModelInput)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, reelReq)
+		})
+	}
+}
+
+func TestPollAsyncInvoke(t *testing.T) {
+	const arn = "arn:aws:bedrock:us-east-1:111122223333:async-invoke/abc123"
+
+	t.Run("completes", func(t *testing.T) {
+		api := &fakeAsyncInvoke{outputs: []*bedrockruntime.GetAsyncInvokeOutput{
+			{Status: types.AsyncInvokeStatusInProgress},
+			{Status: types.AsyncInvokeStatusInProgress},
+			{Status: types.AsyncInvokeStatusCompleted},
+		}}
+
+		job, err := pollAsyncInvoke(context.Background(), api, arn, time.Millisecond)
+		require.NoError(t, err)
+		assert.Equal(t, types.AsyncInvokeStatusCompleted, job.Status)
+		assert.Equal(t, 3, api.calls)
+	})
+
+	t.Run("fails", func(t *testing.T) {
+		api := &fakeAsyncInvoke{outputs: []*bedrockruntime.GetAsyncInvokeOutput{
+			{Status: types.AsyncInvokeStatusFailed, FailureMessage: aws.String("content filtered")},
+		}}
+
+		_, err := pollAsyncInvoke(context.Background(), api, arn, time.Millisecond)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "content filtered")
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		api := &fakeAsyncInvoke{outputs: []*bedrockruntime.GetAsyncInvokeOutput{
+			{Status: types.AsyncInvokeStatusInProgress},
+		}}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := pollAsyncInvoke(ctx, api, arn, time.Hour)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, api.calls)
+	})
+}
+
+func TestCheckVideo(t *testing.T) {
+	const arn = "arn:aws:bedrock:us-east-1:111122223333:async-invoke/abc123"
+	api := &fakeAsyncInvoke{outputs: []*bedrockruntime.GetAsyncInvokeOutput{
+		{Status: types.AsyncInvokeStatusInProgress},
+		{
+			Status: types.AsyncInvokeStatusCompleted,
+			OutputDataConfig: &types.AsyncInvokeOutputDataConfigMemberS3OutputDataConfig{
+				Value: types.AsyncInvokeS3OutputDataConfig{S3Uri: aws.String("s3://videos/reel/abc123")},
+			},
+		},
+		{Status: types.AsyncInvokeStatusFailed, FailureMessage: aws.String("content filtered")},
+	}}
+
+	resp, err := checkVideo(context.Background(), api, arn)
+	require.NoError(t, err)
+	assert.Nil(t, resp, "job still running")
+
+	resp, err = checkVideo(context.Background(), api, arn)
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, "s3://videos/reel/abc123/output.mp4", resp.Message.Content[0].Text)
+
+	_, err = checkVideo(context.Background(), api, arn)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "content filtered")
+}
+
+func TestStartedVideoResponse(t *testing.T) {
+	const arn = "arn:aws:bedrock:us-east-1:111122223333:async-invoke/abc123"
+
+	resp := startedVideoResponse(arn)
+	assert.Empty(t, resp.Message.Content)
+	assert.Equal(t, arn, resp.Custom.(map[string]any)["invocationArn"])
+	assert.Equal(t, ai.FinishReasonOther, resp.FinishReason)
+
+	_, err := (&Model{modelID: "amazon.nova-pro-v1:0", config: &ModelConfig{}}).StartVideo(context.Background(), &ai.ModelRequest{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not generate video")
+}
+
+func TestConvertReelResponse(t *testing.T) {
+	result, err := convertReelResponse(&bedrockruntime.GetAsyncInvokeOutput{
+		InvocationArn: aws.String("arn:aws:bedrock:us-east-1:111122223333:async-invoke/abc123"),
+		Status:        types.AsyncInvokeStatusCompleted,
+		OutputDataConfig: &types.AsyncInvokeOutputDataConfigMemberS3OutputDataConfig{
+			Value: types.AsyncInvokeS3OutputDataConfig{S3Uri: aws.String("s3://videos/reel/abc123")},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.Message.Content, 1)
+	video := result.Message.Content[0]
+	assert.Equal(t, "video/mp4", video.ContentType)
+	assert.Equal(t, "s3://videos/reel/abc123/output.mp4", video.Text)
+	assert.Equal(t, 1, result.Usage.OutputVideos)
+	assert.Equal(t, "arn:aws:bedrock:us-east-1:111122223333:async-invoke/abc123", result.Custom.(map[string]any)["invocationArn"])
+
+	supports := (&Model{modelID: "amazon.nova-reel-v1:1"}).Supports()
+	assert.Equal(t, []string{"media"}, supports.Output)
+	assert.False(t, supports.Tools)
+}
@@ -0,0 +1,562 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+	"github.com/firebase/genkit/go/ai"
+)
+
+// Knowledge base search types
+const (
+	SearchTypeHybrid   = "HYBRID"
+	SearchTypeSemantic = "SEMANTIC"
+)
+
+// Keys Bedrock knowledge base results add to document metadata, alongside
+// the metadata stored with the source document
+const (
+	MetadataScore    = "score"
+	MetadataSource   = "source"
+	MetadataLocation = "location"
+)
+
+// retrieverMaxResults is the most results Retrieve returns per call
+const retrieverMaxResults = 100
+
+// retrieveAPI is the part of the Bedrock Agent Runtime client that queries
+// knowledge bases
+type retrieveAPI interface {
+	Retrieve(ctx context.Context, params *bedrockagentruntime.RetrieveInput, optFns ...func(*bedrockagentruntime.Options)) (*bedrockagentruntime.RetrieveOutput, error)
+}
+
+// RetrieverConfig holds configuration for a knowledge base retriever
+type RetrieverConfig struct {
+	// KnowledgeBaseID is the ID of the Bedrock knowledge base to query
+	KnowledgeBaseID string `json:"knowledge_base_id,omitempty"`
+
+	// NumberOfResults is the number of chunks to return, up to 100. Bedrock
+	// returns 5 when it is not set.
+	NumberOfResults int `json:"number_of_results,omitempty"`
+
+	// SearchType is HYBRID or SEMANTIC. Bedrock picks one when it is not set.
+	SearchType string `json:"search_type,omitempty"`
+
+	// Filter limits results by the metadata of their source documents
+	Filter *RetrievalFilter `json:"filter,omitempty"`
+}
+
+// Validate validates a retriever configuration
+func (rc *RetrieverConfig) Validate() error {
+	if rc.KnowledgeBaseID != "" && !isKnowledgeBaseID(rc.KnowledgeBaseID) {
+		return fmt.Errorf("knowledge_base_id %q must be 10 letters or digits", rc.KnowledgeBaseID)
+	}
+
+	if rc.NumberOfResults < 0 || rc.NumberOfResults > retrieverMaxResults {
+		return fmt.Errorf("number_of_results must be between 1 and %d", retrieverMaxResults)
+	}
+
+	switch rc.SearchType {
+	case "", SearchTypeHybrid, SearchTypeSemantic:
+	default:
+		return fmt.Errorf("search_type must be %s or %s, not %q", SearchTypeHybrid, SearchTypeSemantic, rc.SearchType)
+	}
+
+	if rc.Filter != nil {
+		if err := rc.Filter.Validate(); err != nil {
+			return fmt.Errorf("invalid filter: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// overlay returns a copy of the config with every field set in override
+// replacing the corresponding field
+func (rc *RetrieverConfig) overlay(override *RetrieverConfig) *RetrieverConfig {
+	merged := *rc
+
+	if override.KnowledgeBaseID != "" {
+		merged.KnowledgeBaseID = override.KnowledgeBaseID
+	}
+	if override.NumberOfResults != 0 {
+		merged.NumberOfResults = override.NumberOfResults
+	}
+	if override.SearchType != "" {
+		merged.SearchType = override.SearchType
+	}
+	if override.Filter != nil {
+		merged.Filter = override.Filter
+	}
+
+	return &merged
+}
+
+// isKnowledgeBaseID reports whether s has the form of a knowledge base ID
+func isKnowledgeBaseID(s string) bool {
+	if len(s) != 10 {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+// RetrievalFilter is a knowledge base metadata filter. Exactly one field is
+// set; the Filter functions build them. Its JSON form is the Retrieve API's,
+// so filters can also be passed in JSON request options.
+type RetrievalFilter struct {
+	Equals              *FilterAttribute   `json:"equals,omitempty"`
+	NotEquals           *FilterAttribute   `json:"notEquals,omitempty"`
+	GreaterThan         *FilterAttribute   `json:"greaterThan,omitempty"`
+	GreaterThanOrEquals *FilterAttribute   `json:"greaterThanOrEquals,omitempty"`
+	LessThan            *FilterAttribute   `json:"lessThan,omitempty"`
+	LessThanOrEquals    *FilterAttribute   `json:"lessThanOrEquals,omitempty"`
+	In                  *FilterAttribute   `json:"in,omitempty"`
+	NotIn               *FilterAttribute   `json:"notIn,omitempty"`
+	StartsWith          *FilterAttribute   `json:"startsWith,omitempty"`
+	ListContains        *FilterAttribute   `json:"listContains,omitempty"`
+	StringContains      *FilterAttribute   `json:"stringContains,omitempty"`
+	AndAll              []*RetrievalFilter `json:"andAll,omitempty"`
+	OrAll               []*RetrievalFilter `json:"orAll,omitempty"`
+}
+
+// FilterAttribute is a metadata key and the value it is compared with
+type FilterAttribute struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+}
+
+// FilterEquals matches documents whose key equals value
+func FilterEquals(key string, value any) *RetrievalFilter {
+	return &RetrievalFilter{Equals: &FilterAttribute{Key: key, Value: value}}
+}
+
+// FilterNotEquals matches documents whose key does not equal value
+func FilterNotEquals(key string, value any) *RetrievalFilter {
+	return &RetrievalFilter{NotEquals: &FilterAttribute{Key: key, Value: value}}
+}
+
+// FilterGreaterThan matches documents whose key is greater than value
+func FilterGreaterThan(key string, value float64) *RetrievalFilter {
+	return &RetrievalFilter{GreaterThan: &FilterAttribute{Key: key, Value: value}}
+}
+
+// FilterGreaterThanOrEquals matches documents whose key is at least value
+func FilterGreaterThanOrEquals(key string, value float64) *RetrievalFilter {
+	return &RetrievalFilter{GreaterThanOrEquals: &FilterAttribute{Key: key, Value: value}}
+}
+
+// FilterLessThan matches documents whose key is less than value
+func FilterLessThan(key string, value float64) *RetrievalFilter {
+	return &RetrievalFilter{LessThan: &FilterAttribute{Key: key, Value: value}}
+}
+
+// FilterLessThanOrEquals matches documents whose key is at most value
+func FilterLessThanOrEquals(key string, value float64) *RetrievalFilter {
+	return &RetrievalFilter{LessThanOrEquals: &FilterAttribute{Key: key, Value: value}}
+}
+
+// FilterIn matches documents whose key is one of values
+func FilterIn(key string, values ...any) *RetrievalFilter {
+	return &RetrievalFilter{In: &FilterAttribute{Key: key, Value: values}}
+}
+
+// FilterNotIn matches documents whose key is none of values
+func FilterNotIn(key string, values ...any) *RetrievalFilter {
+	return &RetrievalFilter{NotIn: &FilterAttribute{Key: key, Value: values}}
+}
+
+// FilterStartsWith matches documents whose key starts with prefix
+func FilterStartsWith(key, prefix string) *RetrievalFilter {
+	return &RetrievalFilter{StartsWith: &FilterAttribute{Key: key, Value: prefix}}
+}
+
+// FilterListContains matches documents whose list-valued key contains value
+func FilterListContains(key string, value any) *RetrievalFilter {
+	return &RetrievalFilter{ListContains: &FilterAttribute{Key: key, Value: value}}
+}
+
+// FilterStringContains matches documents whose key contains value
+func FilterStringContains(key, value string) *RetrievalFilter {
+	return &RetrievalFilter{StringContains: &FilterAttribute{Key: key, Value: value}}
+}
+
+// FilterAnd matches documents that match every filter
+func FilterAnd(filters ...*RetrievalFilter) *RetrievalFilter {
+	return &RetrievalFilter{AndAll: filters}
+}
+
+// FilterOr matches documents that match any filter
+func FilterOr(filters ...*RetrievalFilter) *RetrievalFilter {
+	return &RetrievalFilter{OrAll: filters}
+}
+
+// Validate checks that a filter has exactly one operator with a key, and
+// that its value suits the operator
+func (f *RetrievalFilter) Validate() error {
+	attributes := map[string]*FilterAttribute{
+		"equals":              f.Equals,
+		"notEquals":           f.NotEquals,
+		"greaterThan":         f.GreaterThan,
+		"greaterThanOrEquals": f.GreaterThanOrEquals,
+		"lessThan":            f.LessThan,
+		"lessThanOrEquals":    f.LessThanOrEquals,
+		"in":                  f.In,
+		"notIn":               f.NotIn,
+		"startsWith":          f.StartsWith,
+		"listContains":        f.ListContains,
+		"stringContains":      f.StringContains,
+	}
+
+	var operators []string
+	for op, attr := range attributes {
+		if attr != nil {
+			operators = append(operators, op)
+		}
+	}
+	if f.AndAll != nil {
+		operators = append(operators, "andAll")
+	}
+	if f.OrAll != nil {
+		operators = append(operators, "orAll")
+	}
+	if len(operators) != 1 {
+		return fmt.Errorf("filter must have exactly one operator, found %d", len(operators))
+	}
+
+	switch op := operators[0]; op {
+	case "andAll", "orAll":
+		filters := f.AndAll
+		if op == "orAll" {
+			filters = f.OrAll
+		}
+		if len(filters) < 2 {
+			return fmt.Errorf("%s needs at least two filters", op)
+		}
+		for i, sub := range filters {
+			if sub == nil {
+				return fmt.Errorf("%s filter %d is nil", op, i)
+			}
+			if err := sub.Validate(); err != nil {
+				return fmt.Errorf("%s filter %d: %w", op, i, err)
+			}
+		}
+	default:
+		attr := attributes[op]
+		if attr.Key == "" {
+			return fmt.Errorf("%s filter needs a key", op)
+		}
+		if attr.Value == nil {
+			return fmt.Errorf("%s filter on %s needs a value", op, attr.Key)
+		}
+
+		kind := reflect.TypeOf(attr.Value).Kind()
+		switch op {
+		case "in", "notIn":
+			if kind != reflect.Slice && kind != reflect.Array {
+				return fmt.Errorf("%s filter on %s needs a list of values", op, attr.Key)
+			}
+		case "greaterThan", "greaterThanOrEquals", "lessThan", "lessThanOrEquals":
+			if kind < reflect.Int || kind > reflect.Float64 {
+				return fmt.Errorf("%s filter on %s needs a number", op, attr.Key)
+			}
+		case "startsWith", "stringContains":
+			if kind != reflect.String {
+				return fmt.Errorf("%s filter on %s needs a string", op, attr.Key)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Retriever is a GenKit retriever backed by a Bedrock knowledge base
+type Retriever struct {
+	client *Client
+	name   string
+	config *RetrieverConfig
+}
+
+// Retriever returns a GenKit-compatible retriever configured by the
+// RetrieverConfigs entry for name
+func (c *Client) Retriever(name string) *Retriever {
+	config := c.config.RetrieverConfigs[name]
+	if config == nil {
+		config = &RetrieverConfig{}
+	}
+
+	return &Retriever{
+		client: c,
+		name:   name,
+		config: config,
+	}
+}
+
+// Supports reports the GenKit capabilities of this retriever
+func (r *Retriever) Supports() *ai.RetrieverSupports {
+	// Multimodal knowledge bases return image chunks
+	return &ai.RetrieverSupports{Media: true}
+}
+
+// Retrieve implements GenKit's retriever interface
+func (r *Retriever) Retrieve(ctx context.Context, req *ai.RetrieverRequest) (*ai.RetrieverResponse, error) {
+	override, err := retrieveRequestConfig(req.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	config := r.config.overlay(override)
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid retrieve options: %w", err)
+	}
+	if config.KnowledgeBaseID == "" {
+		return nil, fmt.Errorf("no knowledge_base_id configured for retriever %s", r.name)
+	}
+
+	if req.Query == nil {
+		return nil, errors.New("no query in request")
+	}
+	query, err := documentText(req.Query)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	input, err := convertRetrieveRequest(query, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert request: %w", err)
+	}
+
+	result, err := r.client.agent.Retrieve(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("bedrock retrieve failed: %w", err)
+	}
+
+	response, err := convertRetrieveResponse(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert response: %w", err)
+	}
+
+	return response, nil
+}
+
+// retrieveRequestConfig converts the options attached to a GenKit retrieve
+// request to a RetrieverConfig
+func retrieveRequestConfig(options any) (*RetrieverConfig, error) {
+	switch o := options.(type) {
+	case nil:
+		return &RetrieverConfig{}, nil
+	case *RetrieverConfig:
+		if o == nil {
+			return &RetrieverConfig{}, nil
+		}
+		return o, nil
+	case RetrieverConfig:
+		return &o, nil
+	case map[string]any:
+		data, err := json.Marshal(o)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode retrieve options: %w", err)
+		}
+		var config RetrieverConfig
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("failed to decode retrieve options: %w", err)
+		}
+		return &config, nil
+	default:
+		return nil, fmt.Errorf("unsupported retrieve options type %T", options)
+	}
+}
+
+// convertRetrieveRequest builds the Retrieve input for a query
+func convertRetrieveRequest(query string, config *RetrieverConfig) (*bedrockagentruntime.RetrieveInput, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, errors.New("query text cannot be empty")
+	}
+
+	input := &bedrockagentruntime.RetrieveInput{
+		KnowledgeBaseId: aws.String(config.KnowledgeBaseID),
+		RetrievalQuery:  &types.KnowledgeBaseQuery{Text: aws.String(query)},
+	}
+
+	if config.NumberOfResults == 0 && config.SearchType == "" && config.Filter == nil {
+		return input, nil
+	}
+
+	search := &types.KnowledgeBaseVectorSearchConfiguration{
+		OverrideSearchType: types.SearchType(config.SearchType),
+	}
+	if config.NumberOfResults > 0 {
+		search.NumberOfResults = aws.Int32(int32(config.NumberOfResults))
+	}
+	if config.Filter != nil {
+		search.Filter = convertRetrievalFilter(config.Filter)
+	}
+	input.RetrievalConfiguration = &types.KnowledgeBaseRetrievalConfiguration{
+		VectorSearchConfiguration: search,
+	}
+
+	return input, nil
+}
+
+// convertRetrievalFilter converts a validated filter to its Agent Runtime
+// form
+func convertRetrievalFilter(f *RetrievalFilter) types.RetrievalFilter {
+	attribute := func(attr *FilterAttribute) types.FilterAttribute {
+		return types.FilterAttribute{
+			Key:   aws.String(attr.Key),
+			Value: document.NewLazyDocument(attr.Value),
+		}
+	}
+	filters := func(subs []*RetrievalFilter) []types.RetrievalFilter {
+		converted := make([]types.RetrievalFilter, 0, len(subs))
+		for _, sub := range subs {
+			converted = append(converted, convertRetrievalFilter(sub))
+		}
+		return converted
+	}
+
+	switch {
+	case f.Equals != nil:
+		return &types.RetrievalFilterMemberEquals{Value: attribute(f.Equals)}
+	case f.NotEquals != nil:
+		return &types.RetrievalFilterMemberNotEquals{Value: attribute(f.NotEquals)}
+	case f.GreaterThan != nil:
+		return &types.RetrievalFilterMemberGreaterThan{Value: attribute(f.GreaterThan)}
+	case f.GreaterThanOrEquals != nil:
+		return &types.RetrievalFilterMemberGreaterThanOrEquals{Value: attribute(f.GreaterThanOrEquals)}
+	case f.LessThan != nil:
+		return &types.RetrievalFilterMemberLessThan{Value: attribute(f.LessThan)}
+	case f.LessThanOrEquals != nil:
+		return &types.RetrievalFilterMemberLessThanOrEquals{Value: attribute(f.LessThanOrEquals)}
+	case f.In != nil:
+		return &types.RetrievalFilterMemberIn{Value: attribute(f.In)}
+	case f.NotIn != nil:
+		return &types.RetrievalFilterMemberNotIn{Value: attribute(f.NotIn)}
+	case f.StartsWith != nil:
+		return &types.RetrievalFilterMemberStartsWith{Value: attribute(f.StartsWith)}
+	case f.ListContains != nil:
+		return &types.RetrievalFilterMemberListContains{Value: attribute(f.ListContains)}
+	case f.StringContains != nil:
+		return &types.RetrievalFilterMemberStringContains{Value: attribute(f.StringContains)}
+	case f.AndAll != nil:
+		return &types.RetrievalFilterMemberAndAll{Value: filters(f.AndAll)}
+	default:
+		return &types.RetrievalFilterMemberOrAll{Value: filters(f.OrAll)}
+	}
+}
+
+// convertRetrieveResponse converts retrieved chunks to GenKit documents. The
+// metadata of each document is its source document's metadata plus its
+// score, source URI and full location.
+func convertRetrieveResponse(output *bedrockagentruntime.RetrieveOutput) (*ai.RetrieverResponse, error) {
+	resp := &ai.RetrieverResponse{}
+	for i, result := range output.RetrievalResults {
+		metadata := map[string]any{}
+		for key, value := range result.Metadata {
+			decoded, err := decodeDocument(value)
+			if err != nil {
+				return nil, fmt.Errorf("result %d metadata %s: %w", i, key, err)
+			}
+			metadata[key] = decoded
+		}
+		if result.Score != nil {
+			metadata[MetadataScore] = *result.Score
+		}
+		if result.Location != nil {
+			location, source := retrievalLocation(result.Location)
+			metadata[MetadataLocation] = location
+			if source != "" {
+				metadata[MetadataSource] = source
+			}
+		}
+
+		content := result.Content
+		if content == nil {
+			content = &types.RetrievalResultContent{}
+		}
+
+		var part *ai.Part
+		switch content.Type {
+		case "", types.RetrievalResultContentTypeText:
+			part = ai.NewTextPart(aws.ToString(content.Text))
+		case types.RetrievalResultContentTypeImage:
+			part = ai.NewMediaPart("", aws.ToString(content.ByteContent))
+			if media, err := decodeMedia(part); err == nil {
+				part.ContentType = media.mimeType
+			}
+		case types.RetrievalResultContentTypeRow:
+			lines := make([]string, 0, len(content.Row))
+			for _, column := range content.Row {
+				lines = append(lines, aws.ToString(column.ColumnName)+": "+aws.ToString(column.ColumnValue))
+			}
+			part = ai.NewTextPart(strings.Join(lines, "\n"))
+		default:
+			return nil, fmt.Errorf("result %d has unsupported content type %q", i, content.Type)
+		}
+
+		resp.Documents = append(resp.Documents, &ai.Document{
+			Content:  []*ai.Part{part},
+			Metadata: metadata,
+		})
+	}
+
+	return resp, nil
+}
+
+// retrievalLocation returns a result's location in the Retrieve API's JSON
+// form, and the URI, URL or ID that identifies its source document
+func retrievalLocation(loc *types.RetrievalResultLocation) (map[string]any, string) {
+	location := map[string]any{"type": string(loc.Type)}
+	var source string
+	add := func(field, key string, value *string) {
+		if value == nil {
+			return
+		}
+		location[field] = map[string]any{key: *value}
+		if source == "" && key != "query" {
+			source = *value
+		}
+	}
+
+	if l := loc.S3Location; l != nil {
+		add("s3Location", "uri", l.Uri)
+	}
+	if l := loc.WebLocation; l != nil {
+		add("webLocation", "url", l.Url)
+	}
+	if l := loc.ConfluenceLocation; l != nil {
+		add("confluenceLocation", "url", l.Url)
+	}
+	if l := loc.SalesforceLocation; l != nil {
+		add("salesforceLocation", "url", l.Url)
+	}
+	if l := loc.SharePointLocation; l != nil {
+		add("sharePointLocation", "url", l.Url)
+	}
+	if l := loc.KendraDocumentLocation; l != nil {
+		add("kendraDocumentLocation", "uri", l.Uri)
+	}
+	if l := loc.CustomDocumentLocation; l != nil {
+		add("customDocumentLocation", "id", l.Id)
+	}
+	if l := loc.SqlLocation; l != nil {
+		add("sqlLocation", "query", l.Query)
+	}
+
+	return location, source
+}
@@ -0,0 +1,310 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockagentruntime/types"
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRetrieve records Retrieve calls and returns a canned response
+type fakeRetrieve struct {
+	input    *bedrockagentruntime.RetrieveInput
+	response *bedrockagentruntime.RetrieveOutput
+	err      error
+}
+
+func (f *fakeRetrieve) Retrieve(_ context.Context, params *bedrockagentruntime.RetrieveInput, _ ...func(*bedrockagentruntime.Options)) (*bedrockagentruntime.RetrieveOutput, error) {
+	f.input = params
+	return f.response, f.err
+}
+
+func TestRetrieverConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *RetrieverConfig
+		wantErr string
+	}{
+		{"empty", &RetrieverConfig{}, ""},
+		{"full", &RetrieverConfig{KnowledgeBaseID: "KB12345678", NumberOfResults: 20, SearchType: SearchTypeHybrid, Filter: FilterEquals("team", "legal")}, ""},
+		{"malformed knowledge base", &RetrieverConfig{KnowledgeBaseID: "kb-123"}, "must be 10 letters or digits"},
+		{"too many results", &RetrieverConfig{NumberOfResults: 101}, "number_of_results must be between 1 and 100"},
+		{"negative results", &RetrieverConfig{NumberOfResults: -1}, "number_of_results must be between 1 and 100"},
+		{"unknown search type", &RetrieverConfig{SearchType: "KEYWORD"}, "search_type must be HYBRID or SEMANTIC"},
+		{"invalid filter", &RetrieverConfig{Filter: &RetrievalFilter{}}, "invalid filter: filter must have exactly one operator, found 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("plugin config", func(t *testing.T) {
+		// The knowledge base can be left to each request
+		config := &Config{
+			Models:           []string{"anthropic.claude-3-5-sonnet-20241022-v2:0"},
+			RetrieverConfigs: map[string]*RetrieverConfig{"docs": {NumberOfResults: 10}},
+		}
+		require.NoError(t, config.Validate())
+
+		config.RetrieverConfigs["docs"].SearchType = "KEYWORD"
+		err := config.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid config for retriever docs: search_type must be HYBRID or SEMANTIC")
+	})
+}
+
+func TestRetrievalFilter_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  *RetrievalFilter
+		wantErr string
+	}{
+		{"equals", FilterEquals("team", "legal"), ""},
+		{"in", FilterIn("year", 2023, 2024), ""},
+		{"nested", FilterAnd(FilterGreaterThanOrEquals("year", 2023), FilterOr(FilterStartsWith("path", "policies/"), FilterListContains("tags", "hr"))), ""},
+		{"two operators", &RetrievalFilter{Equals: &FilterAttribute{Key: "a", Value: 1}, NotEquals: &FilterAttribute{Key: "b", Value: 2}}, "exactly one operator, found 2"},
+		{"missing key", FilterEquals("", "legal"), "equals filter needs a key"},
+		{"missing value", FilterEquals("team", nil), "needs a value"},
+		{"single and", FilterAnd(FilterEquals("team", "legal")), "andAll needs at least two filters"},
+		{"invalid nested", FilterOr(FilterEquals("team", "legal"), &RetrievalFilter{}), "orAll filter 1: filter must have exactly one operator"},
+		{"in without list", &RetrievalFilter{In: &FilterAttribute{Key: "year", Value: 2024}}, "in filter on year needs a list of values"},
+		{"comparison with string", &RetrievalFilter{LessThan: &FilterAttribute{Key: "year", Value: "2024"}}, "lessThan filter on year needs a number"},
+		{"starts with number", &RetrievalFilter{StartsWith: &FilterAttribute{Key: "path", Value: 1}}, "startsWith filter on path needs a string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestConvertRetrieveRequest(t *testing.T) {
+	config := &RetrieverConfig{
+		KnowledgeBaseID: "KB12345678",
+		NumberOfResults: 8,
+		SearchType:      SearchTypeSemantic,
+		Filter:          FilterAnd(FilterEquals("team", "legal"), FilterIn("year", 2023, 2024)),
+	}
+
+	input, err := convertRetrieveRequest("What is the leave policy?", config)
+	require.NoError(t, err)
+	assert.Equal(t, "KB12345678", aws.ToString(input.KnowledgeBaseId))
+	assert.Equal(t, "What is the leave policy?", aws.ToString(input.RetrievalQuery.Text))
+
+	search := input.RetrievalConfiguration.VectorSearchConfiguration
+	assert.Equal(t, int32(8), aws.ToInt32(search.NumberOfResults))
+	assert.Equal(t, types.SearchTypeSemantic, search.OverrideSearchType)
+
+	and := search.Filter.(*types.RetrievalFilterMemberAndAll).Value
+	require.Len(t, and, 2)
+	equals := and[0].(*types.RetrievalFilterMemberEquals).Value
+	assert.Equal(t, "team", aws.ToString(equals.Key))
+	value, err := decodeDocument(equals.Value)
+	require.NoError(t, err)
+	assert.Equal(t, "legal", value)
+	in := and[1].(*types.RetrievalFilterMemberIn).Value
+	assert.Equal(t, "year", aws.ToString(in.Key))
+	value, err = decodeDocument(in.Value)
+	require.NoError(t, err)
+	assert.Equal(t, []any{float64(2023), float64(2024)}, value)
+
+	input, err = convertRetrieveRequest("What is the leave policy?", &RetrieverConfig{KnowledgeBaseID: "KB12345678"})
+	require.NoError(t, err)
+	assert.Nil(t, input.RetrievalConfiguration)
+
+	_, err = convertRetrieveRequest("  ", config)
+	assert.Error(t, err)
+}
+
+func TestConvertRetrievalFilter(t *testing.T) {
+	tests := []struct {
+		filter *RetrievalFilter
+		want   types.RetrievalFilter
+	}{
+		{FilterNotEquals("team", "hr"), &types.RetrievalFilterMemberNotEquals{}},
+		{FilterGreaterThan("year", 2020), &types.RetrievalFilterMemberGreaterThan{}},
+		{FilterGreaterThanOrEquals("year", 2020), &types.RetrievalFilterMemberGreaterThanOrEquals{}},
+		{FilterLessThan("year", 2020), &types.RetrievalFilterMemberLessThan{}},
+		{FilterLessThanOrEquals("year", 2020), &types.RetrievalFilterMemberLessThanOrEquals{}},
+		{FilterNotIn("team", "hr", "it"), &types.RetrievalFilterMemberNotIn{}},
+		{FilterStartsWith("path", "policies/"), &types.RetrievalFilterMemberStartsWith{}},
+		{FilterListContains("tags", "leave"), &types.RetrievalFilterMemberListContains{}},
+		{FilterStringContains("title", "leave"), &types.RetrievalFilterMemberStringContains{}},
+		{FilterOr(FilterEquals("team", "hr"), FilterEquals("team", "it")), &types.RetrievalFilterMemberOrAll{}},
+	}
+
+	for _, tt := range tests {
+		assert.IsType(t, tt.want, convertRetrievalFilter(tt.filter))
+	}
+}
+
+func TestConvertRetrieveResponse(t *testing.T) {
+	_, encoded := testImage()
+	output := &bedrockagentruntime.RetrieveOutput{
+		RetrievalResults: []types.KnowledgeBaseRetrievalResult{
+			{
+				Content: &types.RetrievalResultContent{Type: types.RetrievalResultContentTypeText, Text: aws.String("Employees get 25 days of leave.")},
+				Location: &types.RetrievalResultLocation{
+					Type:       types.RetrievalResultLocationTypeS3,
+					S3Location: &types.RetrievalResultS3Location{Uri: aws.String("s3://handbook/leave.pdf")},
+				},
+				Metadata: map[string]document.Interface{
+					"team":                      document.NewLazyDocument("hr"),
+					"x-amz-bedrock-kb-chunk-id": document.NewLazyDocument("c1"),
+				},
+				Score: aws.Float64(0.82),
+			},
+			{
+				Content: &types.RetrievalResultContent{Type: types.RetrievalResultContentTypeImage, ByteContent: aws.String("data:image/png;base64," + encoded)},
+				Location: &types.RetrievalResultLocation{
+					Type:        types.RetrievalResultLocationTypeWeb,
+					WebLocation: &types.RetrievalResultWebLocation{Url: aws.String("https://example.com/chart")},
+				},
+				Score: aws.Float64(0.5),
+			},
+			{
+				Content: &types.RetrievalResultContent{Type: types.RetrievalResultContentTypeRow, Row: []types.RetrievalResultContentColumn{
+					{ColumnName: aws.String("name"), ColumnValue: aws.String("Ada")},
+					{ColumnName: aws.String("days"), ColumnValue: aws.String("25")},
+				}},
+				Location: &types.RetrievalResultLocation{
+					Type:        types.RetrievalResultLocationTypeSql,
+					SqlLocation: &types.RetrievalResultSqlLocation{Query: aws.String("SELECT name, days FROM leave")},
+				},
+			},
+		},
+	}
+
+	resp, err := convertRetrieveResponse(output)
+	require.NoError(t, err)
+	require.Len(t, resp.Documents, 3)
+
+	text := resp.Documents[0]
+	assert.Equal(t, "Employees get 25 days of leave.", text.Content[0].Text)
+	assert.Equal(t, 0.82, text.Metadata[MetadataScore])
+	assert.Equal(t, "s3://handbook/leave.pdf", text.Metadata[MetadataSource])
+	assert.Equal(t, "hr", text.Metadata["team"])
+	assert.Equal(t, map[string]any{
+		"type":       "S3",
+		"s3Location": map[string]any{"uri": "s3://handbook/leave.pdf"},
+	}, text.Metadata[MetadataLocation])
+
+	image := resp.Documents[1]
+	assert.True(t, image.Content[0].IsMedia())
+	assert.Equal(t, "image/png", image.Content[0].ContentType)
+	assert.Equal(t, "https://example.com/chart", image.Metadata[MetadataSource])
+
+	row := resp.Documents[2]
+	assert.Equal(t, "name: Ada\ndays: 25", row.Content[0].Text)
+	assert.NotContains(t, row.Metadata, MetadataScore)
+	assert.NotContains(t, row.Metadata, MetadataSource)
+
+	_, err = convertRetrieveResponse(&bedrockagentruntime.RetrieveOutput{
+		RetrievalResults: []types.KnowledgeBaseRetrievalResult{{Content: &types.RetrievalResultContent{Type: "VIDEO"}}},
+	})
+	assert.ErrorContains(t, err, `unsupported content type "VIDEO"`)
+}
+
+func TestRetriever_Retrieve(t *testing.T) {
+	fake := &fakeRetrieve{response: &bedrockagentruntime.RetrieveOutput{
+		RetrievalResults: []types.KnowledgeBaseRetrievalResult{
+			{Content: &types.RetrievalResultContent{Text: aws.String("25 days.")}, Score: aws.Float64(0.9)},
+		},
+	}}
+	client := &Client{agent: fake, config: &Config{
+		RetrieverConfigs: map[string]*RetrieverConfig{
+			"handbook": {KnowledgeBaseID: "KB12345678", NumberOfResults: 5},
+		},
+	}}
+	retriever := client.Retriever("handbook")
+
+	t.Run("request options", func(t *testing.T) {
+		resp, err := retriever.Retrieve(context.Background(), &ai.RetrieverRequest{
+			Query:   ai.DocumentFromText("How much leave?", nil),
+			Options: map[string]any{"number_of_results": 3, "search_type": "HYBRID"},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "KB12345678", aws.ToString(fake.input.KnowledgeBaseId))
+		search := fake.input.RetrievalConfiguration.VectorSearchConfiguration
+		assert.Equal(t, int32(3), aws.ToInt32(search.NumberOfResults))
+		assert.Equal(t, types.SearchTypeHybrid, search.OverrideSearchType)
+
+		require.Len(t, resp.Documents, 1)
+		assert.Equal(t, "25 days.", resp.Documents[0].Content[0].Text)
+		assert.Equal(t, 0.9, resp.Documents[0].Metadata[MetadataScore])
+	})
+
+	t.Run("typed filter", func(t *testing.T) {
+		_, err := retriever.Retrieve(context.Background(), &ai.RetrieverRequest{
+			Query:   ai.DocumentFromText("How much leave?", nil),
+			Options: &RetrieverConfig{Filter: FilterEquals("team", "hr")},
+		})
+		require.NoError(t, err)
+		search := fake.input.RetrievalConfiguration.VectorSearchConfiguration
+		assert.Equal(t, "team", aws.ToString(search.Filter.(*types.RetrievalFilterMemberEquals).Value.Key))
+		assert.Equal(t, int32(5), aws.ToInt32(search.NumberOfResults))
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, err := retriever.Retrieve(context.Background(), &ai.RetrieverRequest{
+			Query:   ai.DocumentFromText("How much leave?", nil),
+			Options: map[string]any{"search_type": "KEYWORD"},
+		})
+		assert.ErrorContains(t, err, "invalid retrieve options")
+	})
+
+	t.Run("knowledge base per request", func(t *testing.T) {
+		_, err := client.Retriever("other").Retrieve(context.Background(), &ai.RetrieverRequest{
+			Query:   ai.DocumentFromText("How much leave?", nil),
+			Options: map[string]any{"knowledge_base_id": "KB87654321"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "KB87654321", aws.ToString(fake.input.KnowledgeBaseId))
+	})
+
+	t.Run("no knowledge base", func(t *testing.T) {
+		_, err := client.Retriever("other").Retrieve(context.Background(), &ai.RetrieverRequest{
+			Query: ai.DocumentFromText("How much leave?", nil),
+		})
+		assert.ErrorContains(t, err, "no knowledge_base_id configured for retriever other")
+	})
+
+	t.Run("no query", func(t *testing.T) {
+		_, err := retriever.Retrieve(context.Background(), &ai.RetrieverRequest{})
+		assert.ErrorContains(t, err, "no query in request")
+	})
+
+	t.Run("api error", func(t *testing.T) {
+		failing := &Client{agent: &fakeRetrieve{err: errors.New("AccessDeniedException")}, config: client.config}
+		_, err := failing.Retriever("handbook").Retrieve(context.Background(), &ai.RetrieverRequest{
+			Query: ai.DocumentFromText("How much leave?", nil),
+		})
+		assert.ErrorContains(t, err, "bedrock retrieve failed: AccessDeniedException")
+	})
+}
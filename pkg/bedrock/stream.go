@@ -0,0 +1,639 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/firebase/genkit/go/ai"
+)
+
+// invocationMetrics is the trailing metadata Bedrock appends to the last
+// chunk of every InvokeModelWithResponseStream response
+type invocationMetrics struct {
+	InputTokenCount  int `json:"inputTokenCount"`
+	OutputTokenCount int `json:"outputTokenCount"`
+}
+
+// streamState accumulates the incremental output of a response stream
+type streamState struct {
+	parts          []*ai.Part
+	text           strings.Builder
+	reasoning      strings.Builder
+	reasoningDelta strings.Builder
+	signature      string
+	redacted       string
+	tool           *ai.ToolRequest
+	toolInput      strings.Builder
+	stopReason     string
+	inputTokens    int
+	outputTokens   int
+	cacheRead      int
+	cacheWrite     int
+	custom         map[string]any
+
+	// Guardrail metadata from the last chunk
+	guardrailAction string
+	guardrailTrace  any
+
+	// DeepSeek-R1 streams its reasoning and answer as one text, split by a
+	// closing think tag that may straddle chunks
+	deepSeek deepSeekSplitter
+}
+
+// emitText records a text delta and forwards it to the callback
+func (s *streamState) emitText(ctx context.Context, text string, cb ai.ModelStreamCallback) error {
+	if text == "" {
+		return nil
+	}
+
+	s.text.WriteString(text)
+	if err := cb(ctx, &ai.ModelResponseChunk{
+		Role:    "model",
+		Content: []*ai.Part{{Text: text}},
+	}); err != nil {
+		return fmt.Errorf("callback failed: %w", err)
+	}
+
+	return nil
+}
+
+// appendReasoning records a reasoning delta, to be forwarded to the
+// callback by emitReasoning
+func (s *streamState) appendReasoning(text string) {
+	s.reasoning.WriteString(text)
+	s.reasoningDelta.WriteString(text)
+}
+
+// emitReasoning forwards the reasoning recorded since the last call to the
+// callback as a reasoning chunk
+func (s *streamState) emitReasoning(ctx context.Context, cb ai.ModelStreamCallback) error {
+	if s.reasoningDelta.Len() == 0 {
+		return nil
+	}
+
+	delta := s.reasoningDelta.String()
+	s.reasoningDelta.Reset()
+	if err := cb(ctx, &ai.ModelResponseChunk{
+		Role:    "model",
+		Content: []*ai.Part{ai.NewReasoningPart(delta, nil)},
+	}); err != nil {
+		return fmt.Errorf("callback failed: %w", err)
+	}
+
+	return nil
+}
+
+// startToolUse begins accumulating a tool use block
+func (s *streamState) startToolUse(ref, name string) error {
+	if err := s.stopBlock(); err != nil {
+		return err
+	}
+	s.tool = &ai.ToolRequest{Name: name, Ref: ref}
+	return nil
+}
+
+// appendToolInput records a fragment of the pending tool use's JSON input
+func (s *streamState) appendToolInput(fragment string) {
+	s.toolInput.WriteString(fragment)
+}
+
+// stopBlock closes the current content block and records it as a part.
+// Reasoning always precedes the answer, so it is recorded first when a
+// stream has no block boundaries.
+func (s *streamState) stopBlock() error {
+	if s.reasoning.Len() > 0 || s.signature != "" || s.redacted != "" {
+		part := ai.NewReasoningPart(s.reasoning.String(), []byte(s.signature))
+		if s.redacted != "" {
+			part.Metadata["redactedData"] = s.redacted
+		}
+		s.parts = append(s.parts, part)
+		s.reasoning.Reset()
+		s.signature = ""
+		s.redacted = ""
+	}
+
+	if s.text.Len() > 0 {
+		s.parts = append(s.parts, &ai.Part{Text: s.text.String()})
+		s.text.Reset()
+	}
+
+	if s.tool != nil {
+		input := map[string]any{}
+		if s.toolInput.Len() > 0 {
+			if err := json.Unmarshal([]byte(s.toolInput.String()), &input); err != nil {
+				return fmt.Errorf("failed to decode tool input for %s: %w", s.tool.Name, err)
+			}
+		}
+		s.tool.Input = input
+		s.parts = append(s.parts, ai.NewToolRequestPart(s.tool))
+		s.tool = nil
+		s.toolInput.Reset()
+	}
+
+	return nil
+}
+
+// response assembles the final GenKit response from the accumulated stream
+func (s *streamState) response() (*ai.ModelResponse, error) {
+	s.appendReasoning(s.deepSeek.flush())
+	if err := s.stopBlock(); err != nil {
+		return nil, err
+	}
+
+	content := s.parts
+	if len(content) == 0 {
+		content = []*ai.Part{{Text: ""}}
+	}
+
+	response := &ai.ModelResponse{
+		Message: &ai.Message{
+			Role:    "model",
+			Content: content,
+		},
+		Usage: cacheUsage(&ai.GenerationUsage{
+			InputTokens:  s.inputTokens,
+			OutputTokens: s.outputTokens,
+			TotalTokens:  s.inputTokens + s.outputTokens,
+		}, s.cacheRead, s.cacheWrite),
+		FinishReason:  finishReason(s.stopReason),
+		FinishMessage: s.stopReason,
+	}
+	if s.custom != nil {
+		response.Custom = s.custom
+	}
+	applyGuardrail(response, s.guardrailAction, s.guardrailTrace)
+
+	return response, nil
+}
+
+// applyMetrics records the usage reported in the trailing metadata, which
+// takes precedence over any counts seen earlier in the stream
+func (s *streamState) applyMetrics(metrics *invocationMetrics) {
+	if metrics == nil {
+		return
+	}
+	s.inputTokens = metrics.InputTokenCount
+	s.outputTokens = metrics.OutputTokenCount
+}
+
+// generateStream invokes the model with a response stream and forwards each
+// delta to the callback as it arrives
+func (m *Model) generateStream(ctx context.Context, body []byte, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+	input := &bedrockruntime.InvokeModelWithResponseStreamInput{
+		ModelId:     aws.String(m.modelID),
+		ContentType: aws.String("application/json"),
+		Body:        body,
+	}
+	if m.config.GuardrailID != "" {
+		input.GuardrailIdentifier = aws.String(m.config.GuardrailID)
+		input.GuardrailVersion = aws.String(m.config.GuardrailVersion)
+		input.Trace = m.guardrailInvokeTrace()
+	}
+
+	result, err := m.client.runtime.InvokeModelWithResponseStream(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("bedrock stream invoke failed: %w", err)
+	}
+
+	stream := result.GetStream()
+	defer stream.Close()
+
+	response, err := m.readStream(ctx, stream.Events(), cb)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := stream.Err(); err != nil {
+		return nil, fmt.Errorf("bedrock stream failed: %w", err)
+	}
+
+	return response, nil
+}
+
+// readStream consumes stream events until the channel is closed
+func (m *Model) readStream(ctx context.Context, events <-chan types.ResponseStream, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+	state := &streamState{}
+
+	for event := range events {
+		chunk, ok := event.(*types.ResponseStreamMemberChunk)
+		if !ok {
+			continue
+		}
+
+		delta, err := m.decodeStreamChunk(chunk.Value.Bytes, state)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+
+		guardrail, err := parseInvokeGuardrail(chunk.Value.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		if guardrail != nil {
+			state.guardrailAction = guardrail.Action
+			state.guardrailTrace = guardrail.trace()
+		}
+
+		if err := state.emitReasoning(ctx, cb); err != nil {
+			return nil, err
+		}
+
+		if err := state.emitText(ctx, delta, cb); err != nil {
+			return nil, err
+		}
+	}
+
+	state.appendReasoning(state.deepSeek.flush())
+	if err := state.emitReasoning(ctx, cb); err != nil {
+		return nil, err
+	}
+
+	return state.response()
+}
+
+// decodeStreamChunk decodes a single chunk payload and returns its text delta
+func (m *Model) decodeStreamChunk(payload []byte, state *streamState) (string, error) {
+	switch {
+	case isClaudeModel(m.foundationModel()):
+		return decodeClaudeStreamChunk(payload, state)
+	case isNovaModel(m.foundationModel()):
+		return decodeNovaStreamChunk(payload, state)
+	case isLlamaModel(m.foundationModel()):
+		return decodeLlamaStreamChunk(payload, state)
+	case isMistralModel(m.foundationModel()):
+		return decodeMistralStreamChunk(payload, state)
+	case isCohereModel(m.foundationModel()):
+		return decodeCohereStreamChunk(payload, state)
+	case isTitanTextModel(m.foundationModel()):
+		return decodeTitanStreamChunk(payload, state)
+	case isJambaModel(m.foundationModel()):
+		return decodeJambaStreamChunk(payload, state)
+	case isDeepSeekModel(m.foundationModel()):
+		return decodeDeepSeekStreamChunk(payload, state)
+	default:
+		return "", fmt.Errorf("unsupported model: %s", m.modelID)
+	}
+}
+
+// Claude-specific stream chunk decoding
+func decodeClaudeStreamChunk(payload []byte, state *streamState) (string, error) {
+	var event struct {
+		Type    string `json:"type"`
+		Message struct {
+			Usage struct {
+				InputTokens              int `json:"input_tokens"`
+				CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+				CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+			} `json:"usage"`
+		} `json:"message"`
+		ContentBlock struct {
+			Type string `json:"type"`
+			ID   string `json:"id"`
+			Name string `json:"name"`
+			Data string `json:"data"`
+		} `json:"content_block"`
+		Delta struct {
+			Type        string `json:"type"`
+			Text        string `json:"text"`
+			PartialJSON string `json:"partial_json"`
+			Thinking    string `json:"thinking"`
+			Signature   string `json:"signature"`
+			StopReason  string `json:"stop_reason"`
+		} `json:"delta"`
+		Usage struct {
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+		Metrics *invocationMetrics `json:"amazon-bedrock-invocationMetrics"`
+	}
+
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return "", fmt.Errorf("failed to unmarshal Claude stream event: %w", err)
+	}
+
+	switch event.Type {
+	case "message_start":
+		state.inputTokens = event.Message.Usage.InputTokens
+		state.cacheRead = event.Message.Usage.CacheReadInputTokens
+		state.cacheWrite = event.Message.Usage.CacheCreationInputTokens
+	case "content_block_start":
+		switch event.ContentBlock.Type {
+		case "tool_use":
+			return "", state.startToolUse(event.ContentBlock.ID, event.ContentBlock.Name)
+		case "redacted_thinking":
+			state.redacted = event.ContentBlock.Data
+		}
+	case "content_block_delta":
+		switch event.Delta.Type {
+		case "text_delta":
+			return event.Delta.Text, nil
+		case "input_json_delta":
+			state.appendToolInput(event.Delta.PartialJSON)
+		case "thinking_delta":
+			state.appendReasoning(event.Delta.Thinking)
+		case "signature_delta":
+			state.signature = event.Delta.Signature
+		}
+	case "content_block_stop":
+		return "", state.stopBlock()
+	case "message_delta":
+		state.stopReason = event.Delta.StopReason
+		state.outputTokens = event.Usage.OutputTokens
+	case "message_stop":
+		state.applyMetrics(event.Metrics)
+	}
+
+	return "", nil
+}
+
+// Nova-specific stream chunk decoding
+func decodeNovaStreamChunk(payload []byte, state *streamState) (string, error) {
+	var event struct {
+		ContentBlockStart *struct {
+			Start struct {
+				ToolUse *struct {
+					ToolUseID string `json:"toolUseId"`
+					Name      string `json:"name"`
+				} `json:"toolUse"`
+			} `json:"start"`
+		} `json:"contentBlockStart"`
+		ContentBlockDelta *struct {
+			Delta struct {
+				Text    string `json:"text"`
+				ToolUse *struct {
+					Input string `json:"input"`
+				} `json:"toolUse"`
+				ReasoningContent *struct {
+					Text      string `json:"text"`
+					Signature string `json:"signature"`
+				} `json:"reasoningContent"`
+			} `json:"delta"`
+		} `json:"contentBlockDelta"`
+		ContentBlockStop *struct{} `json:"contentBlockStop"`
+		MessageStop      *struct {
+			StopReason string `json:"stopReason"`
+		} `json:"messageStop"`
+		Metadata *struct {
+			Usage struct {
+				InputTokens               int `json:"inputTokens"`
+				OutputTokens              int `json:"outputTokens"`
+				CacheReadInputTokenCount  int `json:"cacheReadInputTokenCount"`
+				CacheWriteInputTokenCount int `json:"cacheWriteInputTokenCount"`
+			} `json:"usage"`
+		} `json:"metadata"`
+		Metrics *invocationMetrics `json:"amazon-bedrock-invocationMetrics"`
+	}
+
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return "", fmt.Errorf("failed to unmarshal Nova stream event: %w", err)
+	}
+
+	if event.MessageStop != nil {
+		state.stopReason = event.MessageStop.StopReason
+	}
+	if event.Metadata != nil {
+		state.inputTokens = event.Metadata.Usage.InputTokens
+		state.outputTokens = event.Metadata.Usage.OutputTokens
+		state.cacheRead = event.Metadata.Usage.CacheReadInputTokenCount
+		state.cacheWrite = event.Metadata.Usage.CacheWriteInputTokenCount
+	}
+	state.applyMetrics(event.Metrics)
+
+	switch {
+	case event.ContentBlockStart != nil && event.ContentBlockStart.Start.ToolUse != nil:
+		toolUse := event.ContentBlockStart.Start.ToolUse
+		return "", state.startToolUse(toolUse.ToolUseID, toolUse.Name)
+	case event.ContentBlockDelta != nil && event.ContentBlockDelta.Delta.ToolUse != nil:
+		state.appendToolInput(event.ContentBlockDelta.Delta.ToolUse.Input)
+	case event.ContentBlockDelta != nil && event.ContentBlockDelta.Delta.ReasoningContent != nil:
+		reasoning := event.ContentBlockDelta.Delta.ReasoningContent
+		state.appendReasoning(reasoning.Text)
+		if reasoning.Signature != "" {
+			state.signature = reasoning.Signature
+		}
+	case event.ContentBlockDelta != nil:
+		return event.ContentBlockDelta.Delta.Text, nil
+	case event.ContentBlockStop != nil:
+		return "", state.stopBlock()
+	}
+
+	return "", nil
+}
+
+// Llama-specific stream chunk decoding
+func decodeLlamaStreamChunk(payload []byte, state *streamState) (string, error) {
+	var event struct {
+		Generation           string             `json:"generation"`
+		PromptTokenCount     *int               `json:"prompt_token_count"`
+		GenerationTokenCount int                `json:"generation_token_count"`
+		StopReason           *string            `json:"stop_reason"`
+		Metrics              *invocationMetrics `json:"amazon-bedrock-invocationMetrics"`
+	}
+
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return "", fmt.Errorf("failed to unmarshal Llama stream event: %w", err)
+	}
+
+	if event.PromptTokenCount != nil {
+		state.inputTokens = *event.PromptTokenCount
+	}
+	if event.GenerationTokenCount > 0 {
+		state.outputTokens = event.GenerationTokenCount
+	}
+	if event.StopReason != nil {
+		state.stopReason = *event.StopReason
+	}
+	state.applyMetrics(event.Metrics)
+
+	return llamaStopTokens.Replace(event.Generation), nil
+}
+
+// Mistral-specific stream chunk decoding. Completion models stream outputs,
+// chat models stream choices whose tool calls arrive whole.
+func decodeMistralStreamChunk(payload []byte, state *streamState) (string, error) {
+	var event struct {
+		Outputs []struct {
+			Text       string  `json:"text"`
+			StopReason *string `json:"stop_reason"`
+		} `json:"outputs"`
+		Choices []struct {
+			Message struct {
+				Content   string            `json:"content"`
+				ToolCalls []mistralToolCall `json:"tool_calls"`
+			} `json:"message"`
+			StopReason *string `json:"stop_reason"`
+		} `json:"choices"`
+		Metrics *invocationMetrics `json:"amazon-bedrock-invocationMetrics"`
+	}
+
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return "", fmt.Errorf("failed to unmarshal Mistral stream event: %w", err)
+	}
+	state.applyMetrics(event.Metrics)
+
+	var text string
+	switch {
+	case len(event.Choices) > 0:
+		choice := event.Choices[0]
+		for _, call := range choice.Message.ToolCalls {
+			toolReq, err := call.toolRequest()
+			if err != nil {
+				return "", err
+			}
+			if err := state.stopBlock(); err != nil {
+				return "", err
+			}
+			state.parts = append(state.parts, ai.NewToolRequestPart(toolReq))
+		}
+		if choice.StopReason != nil {
+			state.stopReason = *choice.StopReason
+		}
+		text = choice.Message.Content
+	case len(event.Outputs) > 0:
+		if event.Outputs[0].StopReason != nil {
+			state.stopReason = *event.Outputs[0].StopReason
+		}
+		text = event.Outputs[0].Text
+	}
+
+	return text, nil
+}
+
+// Cohere-specific stream chunk decoding
+func decodeCohereStreamChunk(payload []byte, state *streamState) (string, error) {
+	var event struct {
+		EventType    string             `json:"event_type"`
+		Text         string             `json:"text"`
+		ToolCalls    []cohereToolCall   `json:"tool_calls"`
+		Citations    []Citation         `json:"citations"`
+		FinishReason string             `json:"finish_reason"`
+		Metrics      *invocationMetrics `json:"amazon-bedrock-invocationMetrics"`
+	}
+
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return "", fmt.Errorf("failed to unmarshal Cohere stream event: %w", err)
+	}
+	state.applyMetrics(event.Metrics)
+
+	switch event.EventType {
+	case "text-generation":
+		return event.Text, nil
+	case "citation-generation":
+		if state.custom == nil {
+			state.custom = map[string]any{}
+		}
+		citations, _ := state.custom["citations"].([]Citation)
+		state.custom["citations"] = append(citations, event.Citations...)
+	case "tool-calls-generation":
+		if err := state.stopBlock(); err != nil {
+			return "", err
+		}
+		for _, call := range event.ToolCalls {
+			state.parts = append(state.parts, ai.NewToolRequestPart(call.toolRequest()))
+		}
+	case "stream-end":
+		state.stopReason = event.FinishReason
+	}
+
+	return "", nil
+}
+
+// Titan-specific stream chunk decoding
+func decodeTitanStreamChunk(payload []byte, state *streamState) (string, error) {
+	var event struct {
+		OutputText                string             `json:"outputText"`
+		InputTextTokenCount       *int               `json:"inputTextTokenCount"`
+		TotalOutputTextTokenCount int                `json:"totalOutputTextTokenCount"`
+		CompletionReason          *string            `json:"completionReason"`
+		Metrics                   *invocationMetrics `json:"amazon-bedrock-invocationMetrics"`
+	}
+
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return "", fmt.Errorf("failed to unmarshal Titan stream event: %w", err)
+	}
+
+	if event.InputTextTokenCount != nil {
+		state.inputTokens = *event.InputTextTokenCount
+	}
+	if event.TotalOutputTextTokenCount > 0 {
+		state.outputTokens = event.TotalOutputTextTokenCount
+	}
+	if event.CompletionReason != nil {
+		state.stopReason = *event.CompletionReason
+	}
+	state.applyMetrics(event.Metrics)
+
+	return event.OutputText, nil
+}
+
+// Jamba-specific stream chunk decoding
+func decodeJambaStreamChunk(payload []byte, state *streamState) (string, error) {
+	var event struct {
+		Choices []struct {
+			Delta struct {
+				Content string `json:"content"`
+			} `json:"delta"`
+			FinishReason *string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage *struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+		Metrics *invocationMetrics `json:"amazon-bedrock-invocationMetrics"`
+	}
+
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return "", fmt.Errorf("failed to unmarshal Jamba stream event: %w", err)
+	}
+
+	if event.Usage != nil {
+		state.inputTokens = event.Usage.PromptTokens
+		state.outputTokens = event.Usage.CompletionTokens
+	}
+	state.applyMetrics(event.Metrics)
+
+	if len(event.Choices) == 0 {
+		return "", nil
+	}
+	if event.Choices[0].FinishReason != nil {
+		state.stopReason = *event.Choices[0].FinishReason
+	}
+
+	return event.Choices[0].Delta.Content, nil
+}
+
+// DeepSeek-specific stream chunk decoding, split into reasoning and answer
+// by the same rule as InvokeModel responses
+func decodeDeepSeekStreamChunk(payload []byte, state *streamState) (string, error) {
+	var event struct {
+		Choices []struct {
+			Text       string  `json:"text"`
+			StopReason *string `json:"stop_reason"`
+		} `json:"choices"`
+		Metrics *invocationMetrics `json:"amazon-bedrock-invocationMetrics"`
+	}
+
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return "", fmt.Errorf("failed to unmarshal DeepSeek stream event: %w", err)
+	}
+	state.applyMetrics(event.Metrics)
+
+	if len(event.Choices) == 0 {
+		return "", nil
+	}
+	if event.Choices[0].StopReason != nil {
+		state.stopReason = *event.Choices[0].StopReason
+	}
+
+	reasoning, answer := state.deepSeek.write(event.Choices[0].Text)
+	state.appendReasoning(reasoning)
+
+	return answer, nil
+}
@@ -0,0 +1,439 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chunkEvents returns a closed channel pre-loaded with the given payloads
+func chunkEvents(payloads ...string) <-chan types.ResponseStream {
+	ch := make(chan types.ResponseStream, len(payloads))
+	for _, p := range payloads {
+		ch <- &types.ResponseStreamMemberChunk{Value: types.PayloadPart{Bytes: []byte(p)}}
+	}
+	close(ch)
+	return ch
+}
+
+func TestModel_readStream(t *testing.T) {
+	tests := []struct {
+		name         string
+		modelID      string
+		payloads     []string
+		wantDeltas   []string
+		wantText     string
+		wantInput    int
+		wantOutput   int
+		wantTotal    int
+		wantFinish   ai.FinishReason
+		wantErrorMsg string
+	}{
+		{
+			name:    "claude",
+			modelID: "anthropic.claude-3-sonnet-20240229-v1:0",
+			payloads: []string{
+				`{"type":"message_start","message":{"usage":{"input_tokens":12,"output_tokens":1}}}`,
+				`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+				`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`,
+				`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":", world"}}`,
+				`{"type":"content_block_stop","index":0}`,
+				`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":4}}`,
+				`{"type":"message_stop","amazon-bedrock-invocationMetrics":{"inputTokenCount":12,"outputTokenCount":4}}`,
+			},
+			wantDeltas: []string{"Hello", ", world"},
+			wantText:   "Hello, world",
+			wantInput:  12,
+			wantOutput: 4,
+			wantTotal:  16,
+			wantFinish: ai.FinishReasonStop,
+		},
+		{
+			name:    "nova",
+			modelID: "amazon.nova-pro-v1:0",
+			payloads: []string{
+				`{"messageStart":{"role":"assistant"}}`,
+				`{"contentBlockDelta":{"delta":{"text":"Bonjour"},"contentBlockIndex":0}}`,
+				`{"contentBlockDelta":{"delta":{"text":" !"},"contentBlockIndex":0}}`,
+				`{"contentBlockStop":{"contentBlockIndex":0}}`,
+				`{"messageStop":{"stopReason":"end_turn"}}`,
+				`{"metadata":{"usage":{"inputTokens":7,"outputTokens":3}}}`,
+			},
+			wantDeltas: []string{"Bonjour", " !"},
+			wantText:   "Bonjour !",
+			wantInput:  7,
+			wantOutput: 3,
+			wantTotal:  10,
+			wantFinish: ai.FinishReasonStop,
+		},
+		{
+			name:    "llama",
+			modelID: "meta.llama3-2-90b-instruct-v1:0",
+			payloads: []string{
+				`{"generation":"Hi","prompt_token_count":5,"generation_token_count":1,"stop_reason":null}`,
+				`{"generation":" there","prompt_token_count":null,"generation_token_count":2,"stop_reason":null}`,
+				`{"generation":"","prompt_token_count":null,"generation_token_count":2,"stop_reason":"stop","amazon-bedrock-invocationMetrics":{"inputTokenCount":5,"outputTokenCount":2}}`,
+			},
+			wantDeltas: []string{"Hi", " there"},
+			wantText:   "Hi there",
+			wantInput:  5,
+			wantOutput: 2,
+			wantTotal:  7,
+			wantFinish: ai.FinishReasonStop,
+		},
+		{
+			name:    "mistral",
+			modelID: "mistral.mixtral-8x7b-instruct-v0:1",
+			payloads: []string{
+				`{"outputs":[{"text":" Ciao","stop_reason":null}]}`,
+				`{"outputs":[{"text":"!","stop_reason":"stop"}],"amazon-bedrock-invocationMetrics":{"inputTokenCount":6,"outputTokenCount":2}}`,
+			},
+			wantDeltas: []string{" Ciao", "!"},
+			wantText:   " Ciao!",
+			wantInput:  6,
+			wantOutput: 2,
+			wantTotal:  8,
+			wantFinish: ai.FinishReasonStop,
+		},
+		{
+			name:    "mistral large",
+			modelID: "mistral.mistral-large-2407-v1:0",
+			payloads: []string{
+				`{"choices":[{"index":0,"message":{"role":"assistant","content":"Hola"},"stop_reason":null}]}`,
+				`{"choices":[{"index":0,"message":{"role":"assistant","content":""},"stop_reason":"length"}],"amazon-bedrock-invocationMetrics":{"inputTokenCount":4,"outputTokenCount":1}}`,
+			},
+			wantDeltas: []string{"Hola"},
+			wantText:   "Hola",
+			wantInput:  4,
+			wantOutput: 1,
+			wantTotal:  5,
+			wantFinish: ai.FinishReasonLength,
+		},
+		{
+			name:    "cohere",
+			modelID: "cohere.command-r-plus-v1:0",
+			payloads: []string{
+				`{"is_finished":false,"event_type":"stream-start","generation_id":"g1"}`,
+				`{"is_finished":false,"event_type":"text-generation","text":"Jupiter"}`,
+				`{"is_finished":false,"event_type":"text-generation","text":" is largest."}`,
+				`{"is_finished":true,"event_type":"stream-end","finish_reason":"COMPLETE","amazon-bedrock-invocationMetrics":{"inputTokenCount":20,"outputTokenCount":4}}`,
+			},
+			wantDeltas: []string{"Jupiter", " is largest."},
+			wantText:   "Jupiter is largest.",
+			wantInput:  20,
+			wantOutput: 4,
+			wantTotal:  24,
+			wantFinish: ai.FinishReasonStop,
+		},
+		{
+			name:    "titan",
+			modelID: "amazon.titan-text-express-v1",
+			payloads: []string{
+				`{"outputText":" Hello","index":0,"totalOutputTextTokenCount":1,"completionReason":null,"inputTextTokenCount":5}`,
+				`{"outputText":" there","index":0,"totalOutputTextTokenCount":2,"completionReason":"FINISH","inputTextTokenCount":null}`,
+			},
+			wantDeltas: []string{" Hello", " there"},
+			wantText:   " Hello there",
+			wantInput:  5,
+			wantOutput: 2,
+			wantTotal:  7,
+			wantFinish: ai.FinishReasonStop,
+		},
+		{
+			name:    "jamba",
+			modelID: "ai21.jamba-1-5-mini-v1:0",
+			payloads: []string{
+				`{"choices":[{"index":0,"delta":{"role":"assistant","content":""},"finish_reason":null}]}`,
+				`{"choices":[{"index":0,"delta":{"content":"Madrid"},"finish_reason":null}]}`,
+				`{"choices":[{"index":0,"delta":{"content":"."},"finish_reason":"length"}],"usage":{"prompt_tokens":9,"completion_tokens":2}}`,
+			},
+			wantDeltas: []string{"Madrid", "."},
+			wantText:   "Madrid.",
+			wantInput:  9,
+			wantOutput: 2,
+			wantTotal:  11,
+			wantFinish: ai.FinishReasonLength,
+		},
+		{
+			name:         "malformed chunk",
+			modelID:      "amazon.nova-pro-v1:0",
+			payloads:     []string{`{not json`},
+			wantErrorMsg: "failed to decode stream chunk",
+		},
+		{
+			name:         "unsupported model",
+			modelID:      "unknown.model-v1",
+			payloads:     []string{`{}`},
+			wantErrorMsg: "unsupported model",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &Model{modelID: tt.modelID, config: &ModelConfig{}}
+
+			var deltas []string
+			cb := func(_ context.Context, chunk *ai.ModelResponseChunk) error {
+				require.Len(t, chunk.Content, 1)
+				deltas = append(deltas, chunk.Content[0].Text)
+				return nil
+			}
+
+			result, err := model.readStream(context.Background(), chunkEvents(tt.payloads...), cb)
+			if tt.wantErrorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErrorMsg)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantDeltas, deltas)
+			require.NotNil(t, result.Message)
+			require.Len(t, result.Message.Content, 1)
+			assert.Equal(t, tt.wantText, result.Message.Content[0].Text)
+			assert.Equal(t, tt.wantInput, result.Usage.InputTokens)
+			assert.Equal(t, tt.wantOutput, result.Usage.OutputTokens)
+			assert.Equal(t, tt.wantTotal, result.Usage.TotalTokens)
+			assert.Equal(t, tt.wantFinish, result.FinishReason)
+		})
+	}
+}
+
+func TestModel_readStream_ClaudeToolUse(t *testing.T) {
+	model := &Model{modelID: "anthropic.claude-3-5-sonnet-20241022-v2:0", config: &ModelConfig{}}
+
+	events := chunkEvents(
+		`{"type":"message_start","message":{"usage":{"input_tokens":30}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Let me check."}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_01","name":"weather","input":{}}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\": "}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":12}}`,
+		`{"type":"message_stop"}`,
+	)
+
+	cb := func(context.Context, *ai.ModelResponseChunk) error { return nil }
+
+	result, err := model.readStream(context.Background(), events, cb)
+	require.NoError(t, err)
+
+	require.Len(t, result.Message.Content, 2)
+	assert.Equal(t, "Let me check.", result.Message.Content[0].Text)
+	require.True(t, result.Message.Content[1].IsToolRequest())
+	assert.Equal(t, "weather", result.Message.Content[1].ToolRequest.Name)
+	assert.Equal(t, "toolu_01", result.Message.Content[1].ToolRequest.Ref)
+	assert.Equal(t, map[string]any{"city": "Paris"}, result.Message.Content[1].ToolRequest.Input)
+	assert.Equal(t, 30, result.Usage.InputTokens)
+	assert.Equal(t, 12, result.Usage.OutputTokens)
+	assert.Equal(t, ai.FinishReasonStop, result.FinishReason)
+	assert.Equal(t, "tool_use", result.FinishMessage)
+}
+
+func TestModel_readStream_NovaToolUse(t *testing.T) {
+	model := &Model{modelID: "amazon.nova-pro-v1:0", config: &ModelConfig{}}
+
+	events := chunkEvents(
+		`{"messageStart":{"role":"assistant"}}`,
+		`{"contentBlockStart":{"start":{"toolUse":{"toolUseId":"tooluse_1","name":"weather"}},"contentBlockIndex":0}}`,
+		`{"contentBlockDelta":{"delta":{"toolUse":{"input":"{\"city\":\"Paris\"}"}},"contentBlockIndex":0}}`,
+		`{"contentBlockStop":{"contentBlockIndex":0}}`,
+		`{"messageStop":{"stopReason":"tool_use"}}`,
+		`{"metadata":{"usage":{"inputTokens":40,"outputTokens":18}}}`,
+	)
+
+	cb := func(context.Context, *ai.ModelResponseChunk) error { return nil }
+
+	result, err := model.readStream(context.Background(), events, cb)
+	require.NoError(t, err)
+
+	require.Len(t, result.Message.Content, 1)
+	require.True(t, result.Message.Content[0].IsToolRequest())
+	assert.Equal(t, "weather", result.Message.Content[0].ToolRequest.Name)
+	assert.Equal(t, "tooluse_1", result.Message.Content[0].ToolRequest.Ref)
+	assert.Equal(t, map[string]any{"city": "Paris"}, result.Message.Content[0].ToolRequest.Input)
+}
+
+func TestModel_readStream_CallbackError(t *testing.T) {
+	model := &Model{modelID: "amazon.nova-pro-v1:0", config: &ModelConfig{}}
+
+	cb := func(context.Context, *ai.ModelResponseChunk) error {
+		return errors.New("client went away")
+	}
+
+	_, err := model.readStream(context.Background(),
+		chunkEvents(`{"contentBlockDelta":{"delta":{"text":"Hi"}}}`), cb)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "callback failed")
+}
+
+func TestModel_readStream_MistralToolCalls(t *testing.T) {
+	model := &Model{modelID: "mistral.mistral-large-2407-v1:0", config: &ModelConfig{}}
+
+	events := chunkEvents(
+		`{"choices":[{"index":0,"message":{"role":"assistant","content":"","tool_calls":[` +
+			`{"id":"call_1","function":{"name":"weather","arguments":"{\"city\":\"Paris\"}"}}]},"stop_reason":"tool_calls"}]}`,
+	)
+
+	cb := func(context.Context, *ai.ModelResponseChunk) error { return nil }
+
+	result, err := model.readStream(context.Background(), events, cb)
+	require.NoError(t, err)
+
+	require.Len(t, result.Message.Content, 1)
+	require.True(t, result.Message.Content[0].IsToolRequest())
+	assert.Equal(t, "weather", result.Message.Content[0].ToolRequest.Name)
+	assert.Equal(t, "call_1", result.Message.Content[0].ToolRequest.Ref)
+	assert.Equal(t, map[string]any{"city": "Paris"}, result.Message.Content[0].ToolRequest.Input)
+	assert.Equal(t, ai.FinishReasonStop, result.FinishReason)
+	assert.Equal(t, "tool_calls", result.FinishMessage)
+}
+
+func TestModel_readStream_CohereCitationsAndTools(t *testing.T) {
+	model := &Model{modelID: "cohere.command-r-v1:0", config: &ModelConfig{}}
+
+	events := chunkEvents(
+		`{"event_type":"text-generation","text":"Checking."}`,
+		`{"event_type":"citation-generation","citations":[{"start":0,"end":8,"text":"Checking","document_ids":["doc_0"]}]}`,
+		`{"event_type":"tool-calls-generation","tool_calls":[{"name":"weather","parameters":{"city":"Paris"}}]}`,
+		`{"event_type":"stream-end","finish_reason":"COMPLETE"}`,
+	)
+
+	cb := func(context.Context, *ai.ModelResponseChunk) error { return nil }
+
+	result, err := model.readStream(context.Background(), events, cb)
+	require.NoError(t, err)
+
+	require.Len(t, result.Message.Content, 2)
+	assert.Equal(t, "Checking.", result.Message.Content[0].Text)
+	require.True(t, result.Message.Content[1].IsToolRequest())
+	assert.Equal(t, "weather", result.Message.Content[1].ToolRequest.Name)
+	assert.Equal(t, map[string]any{"city": "Paris"}, result.Message.Content[1].ToolRequest.Input)
+	assert.Equal(t, map[string]any{
+		"citations": []Citation{{Start: 0, End: 8, Text: "Checking", DocumentIDs: []string{"doc_0"}}},
+	}, result.Custom)
+	assert.Equal(t, ai.FinishReasonStop, result.FinishReason)
+}
+
+func TestModel_readStream_ClaudeThinking(t *testing.T) {
+	model := &Model{modelID: "anthropic.claude-3-7-sonnet-20250219-v1:0", config: &ModelConfig{}}
+
+	events := chunkEvents(
+		`{"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Let me add "}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"the numbers."}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig-1"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"redacted_thinking","data":"opaque"}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"content_block_start","index":2,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"text_delta","text":"42"}}`,
+		`{"type":"content_block_stop","index":2}`,
+	)
+
+	var chunks []*ai.Part
+	cb := func(_ context.Context, chunk *ai.ModelResponseChunk) error {
+		chunks = append(chunks, chunk.Content...)
+		return nil
+	}
+
+	result, err := model.readStream(context.Background(), events, cb)
+	require.NoError(t, err)
+
+	require.Len(t, chunks, 3)
+	assert.True(t, chunks[0].IsReasoning())
+	assert.Equal(t, "Let me add ", chunks[0].Text)
+	assert.True(t, chunks[2].IsText())
+
+	require.Len(t, result.Message.Content, 3)
+	assert.True(t, result.Message.Content[0].IsReasoning())
+	assert.Equal(t, "Let me add the numbers.", result.Message.Content[0].Text)
+	assert.Equal(t, "sig-1", reasoningSignature(result.Message.Content[0]))
+	assert.True(t, result.Message.Content[1].IsReasoning())
+	assert.Equal(t, "opaque", result.Message.Content[1].Metadata["redactedData"])
+	assert.Equal(t, "42", result.Message.Content[2].Text)
+}
+
+func TestModel_readStream_DeepSeek(t *testing.T) {
+	model := &Model{modelID: "us.deepseek.r1-v1:0", config: &ModelConfig{}}
+
+	events := chunkEvents(
+		`{"choices":[{"text":"2 + 2 is 4.","stop_reason":null}]}`,
+		`{"choices":[{"text":"\n</th","stop_reason":null}]}`,
+		`{"choices":[{"text":"ink>\n\nFour","stop_reason":null}]}`,
+		`{"choices":[{"text":".","stop_reason":"stop"}],"amazon-bedrock-invocationMetrics":{"inputTokenCount":10,"outputTokenCount":12}}`,
+	)
+
+	var reasoning, text []string
+	cb := func(_ context.Context, chunk *ai.ModelResponseChunk) error {
+		for _, part := range chunk.Content {
+			if part.IsReasoning() {
+				reasoning = append(reasoning, part.Text)
+			} else {
+				text = append(text, part.Text)
+			}
+		}
+		return nil
+	}
+
+	result, err := model.readStream(context.Background(), events, cb)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"2 + 2 is 4."}, reasoning)
+	assert.Equal(t, []string{"Four", "."}, text)
+
+	require.Len(t, result.Message.Content, 2)
+	assert.True(t, result.Message.Content[0].IsReasoning())
+	assert.Equal(t, "2 + 2 is 4.", result.Message.Content[0].Text)
+	assert.Equal(t, "Four.", result.Message.Content[1].Text)
+	assert.Equal(t, 10, result.Usage.InputTokens)
+	assert.Equal(t, ai.FinishReasonStop, result.FinishReason)
+}
+
+func TestModel_readStream_DeepSeekUnclosed(t *testing.T) {
+	model := &Model{modelID: "us.deepseek.r1-v1:0", config: &ModelConfig{}}
+
+	events := chunkEvents(
+		`{"choices":[{"text":"The user wants a sum. ","stop_reason":null}]}`,
+		`{"choices":[{"text":"2 + 2 </","stop_reason":"length"}],"amazon-bedrock-invocationMetrics":{"inputTokenCount":10,"outputTokenCount":8}}`,
+	)
+
+	var reasoning strings.Builder
+	cb := func(_ context.Context, chunk *ai.ModelResponseChunk) error {
+		for _, part := range chunk.Content {
+			require.True(t, part.IsReasoning())
+			reasoning.WriteString(part.Text)
+		}
+		return nil
+	}
+
+	result, err := model.readStream(context.Background(), events, cb)
+	require.NoError(t, err)
+
+	assert.Equal(t, "The user wants a sum. 2 + 2 </", reasoning.String())
+
+	// The same output from InvokeModel splits the same way
+	body, err := json.Marshal(map[string]any{
+		"choices": []map[string]any{{"text": "The user wants a sum. 2 + 2 </", "stop_reason": "length"}},
+	})
+	require.NoError(t, err)
+	invoked, err := model.convertResponse(body)
+	require.NoError(t, err)
+
+	require.Len(t, result.Message.Content, 1)
+	assert.True(t, result.Message.Content[0].IsReasoning())
+	assert.Equal(t, invoked.Message.Content[0].Text, result.Message.Content[0].Text)
+	assert.Equal(t, ai.FinishReasonLength, result.FinishReason)
+}
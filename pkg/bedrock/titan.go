@@ -0,0 +1,121 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// Titan-specific request conversion
+func (m *Model) convertTitanRequest(req *ai.ModelRequest) ([]byte, error) {
+	if len(req.Messages) == 0 {
+		return nil, fmt.Errorf("no messages in request")
+	}
+
+	if _, err := m.prepareMedia(req.Messages); err != nil {
+		return nil, err
+	}
+
+	system, conversation, err := splitSystemMessages(req.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt, err := formatTitanPrompt(system, conversation)
+	if err != nil {
+		return nil, err
+	}
+
+	generationConfig := map[string]interface{}{
+		"maxTokenCount": m.config.MaxTokens,
+		"temperature":   m.config.Temperature,
+	}
+
+	if m.config.TopP > 0 {
+		generationConfig["topP"] = m.config.TopP
+	}
+
+	if len(m.config.StopSequences) > 0 {
+		generationConfig["stopSequences"] = m.config.StopSequences
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"inputText":            prompt,
+		"textGenerationConfig": generationConfig,
+	})
+}
+
+// formatTitanPrompt renders a conversation in the User:/Bot: transcript
+// format Titan Text was tuned on, ending with an open Bot: turn
+func formatTitanPrompt(system string, messages []*ai.Message) (string, error) {
+	var prompt strings.Builder
+	if system != "" {
+		prompt.WriteString(system)
+		prompt.WriteString("\n\n")
+	}
+
+	for i, msg := range messages {
+		var text strings.Builder
+		for _, part := range msg.Content {
+			if !part.IsText() {
+				return "", fmt.Errorf("unsupported part kind %d for Titan", part.Kind)
+			}
+			text.WriteString(part.Text)
+		}
+
+		if msg.Role == ai.RoleModel {
+			prompt.WriteString("Bot: ")
+			prompt.WriteString(text.String())
+			if isTrailingModel(msg.Role, i, len(messages)) {
+				return prompt.String(), nil
+			}
+		} else {
+			prompt.WriteString("User: ")
+			prompt.WriteString(text.String())
+		}
+		prompt.WriteString("\n")
+	}
+
+	prompt.WriteString("Bot:")
+	return prompt.String(), nil
+}
+
+// Titan-specific response conversion
+func (m *Model) convertTitanResponse(body []byte) (*ai.ModelResponse, error) {
+	var titanResp struct {
+		InputTextTokenCount int `json:"inputTextTokenCount"`
+		Results             []struct {
+			TokenCount       int    `json:"tokenCount"`
+			OutputText       string `json:"outputText"`
+			CompletionReason string `json:"completionReason"`
+		} `json:"results"`
+	}
+
+	if err := json.Unmarshal(body, &titanResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Titan response: %w", err)
+	}
+
+	if len(titanResp.Results) == 0 {
+		return nil, fmt.Errorf("no results in Titan response")
+	}
+
+	result := titanResp.Results[0]
+	return &ai.ModelResponse{
+		Message: &ai.Message{
+			Role:    "model",
+			Content: []*ai.Part{ai.NewTextPart(result.OutputText)},
+		},
+		Usage: &ai.GenerationUsage{
+			InputTokens:  titanResp.InputTextTokenCount,
+			OutputTokens: result.TokenCount,
+			TotalTokens:  titanResp.InputTextTokenCount + result.TokenCount,
+		},
+		FinishReason:  finishReason(result.CompletionReason),
+		FinishMessage: result.CompletionReason,
+	}, nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatTitanPrompt(t *testing.T) {
+	tests := []struct {
+		name     string
+		system   string
+		messages []*ai.Message
+		expected string
+	}{
+		{
+			name:     "single turn",
+			messages: []*ai.Message{ai.NewUserTextMessage("Hello!")},
+			expected: "User: Hello!\nBot:",
+		},
+		{
+			name:   "multi turn with system",
+			system: "Be brief.",
+			messages: []*ai.Message{
+				ai.NewUserTextMessage("Name a city in Portugal."),
+				ai.NewModelTextMessage("Lisbon."),
+				ai.NewUserTextMessage("And one in Spain?"),
+			},
+			expected: "Be brief.\n\nUser: Name a city in Portugal.\nBot: Lisbon.\nUser: And one in Spain?\nBot:",
+		},
+		{
+			name: "prefill",
+			messages: []*ai.Message{
+				ai.NewUserTextMessage("List three colours as JSON."),
+				ai.NewModelTextMessage("["),
+			},
+			expected: "User: List three colours as JSON.\nBot: [",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompt, err := formatTitanPrompt(tt.system, tt.messages)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, prompt)
+		})
+	}
+}
+
+func TestModel_convertTitanRequest(t *testing.T) {
+	model := &Model{
+		modelID: "amazon.titan-text-premier-v1:0",
+		config: &ModelConfig{
+			MaxTokens:     512,
+			Temperature:   0.7,
+			TopP:          0.9,
+			StopSequences: []string{"User:"},
+		},
+	}
+
+	result, err := model.convertRequest(&ai.ModelRequest{
+		Messages: []*ai.Message{ai.NewUserTextMessage("Hello!")},
+	})
+	require.NoError(t, err)
+
+	var titanReq map[string]interface{}
+	require.NoError(t, json.Unmarshal(result, &titanReq))
+	assert.Equal(t, "User: Hello!\nBot:", titanReq["inputText"])
+	assert.Equal(t, map[string]interface{}{
+		"maxTokenCount": float64(512),
+		"temperature":   0.7,
+		"topP":          0.9,
+		"stopSequences": []interface{}{"User:"},
+	}, titanReq["textGenerationConfig"])
+
+	_, err = model.convertRequest(&ai.ModelRequest{
+		Messages: []*ai.Message{ai.NewUserMessage(ai.NewMediaPart("image/png", pngHeader))},
+	})
+	require.Error(t, err)
+}
+
+func TestModel_convertTitanResponse(t *testing.T) {
+	model := &Model{modelID: "amazon.titan-text-express-v1", config: &ModelConfig{}}
+
+	result, err := model.convertResponse([]byte(`{
+		"inputTextTokenCount": 6,
+		"results": [{"tokenCount": 4, "outputText": " Hello there!", "completionReason": "FINISH"}]
+	}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, " Hello there!", result.Message.Content[0].Text)
+	assert.Equal(t, 6, result.Usage.InputTokens)
+	assert.Equal(t, 4, result.Usage.OutputTokens)
+	assert.Equal(t, 10, result.Usage.TotalTokens)
+	assert.Equal(t, ai.FinishReasonStop, result.FinishReason)
+	assert.Equal(t, "FINISH", result.FinishMessage)
+
+	_, err = model.convertResponse([]byte(`{"inputTextTokenCount": 6, "results": []}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no results")
+}
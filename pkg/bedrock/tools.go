@@ -0,0 +1,57 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"encoding/json"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// toolInput normalizes a tool request input into a JSON object
+func toolInput(input any) any {
+	if input == nil {
+		return map[string]any{}
+	}
+	return input
+}
+
+// jsonObject normalizes a tool input or output into a JSON object, for the
+// Bedrock fields that only accept objects, such as JSON tool results
+func jsonObject(value any) map[string]any {
+	if value == nil {
+		return map[string]any{}
+	}
+
+	// Round-trip through JSON so structs become objects too
+	var obj map[string]any
+	if data, err := json.Marshal(value); err == nil {
+		if err := json.Unmarshal(data, &obj); err == nil && obj != nil {
+			return obj
+		}
+	}
+
+	return map[string]any{"result": value}
+}
+
+// toolSchema returns the tool's input schema, defaulting to an empty object
+func toolSchema(schema map[string]any) map[string]any {
+	if len(schema) == 0 {
+		return map[string]any{"type": "object", "properties": map[string]any{}}
+	}
+	return schema
+}
+
+// hasToolParts reports whether any message holds a tool request or response.
+// Nova and Converse reject such a history unless tool specs are sent with it.
+func hasToolParts(messages []*ai.Message) bool {
+	for _, msg := range messages {
+		for _, part := range msg.Content {
+			if part.IsToolRequest() || part.IsToolResponse() {
+				return true
+			}
+		}
+	}
+	return false
+}
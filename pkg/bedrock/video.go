@@ -0,0 +1,176 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/firebase/genkit/go/ai"
+)
+
+// Nova video limits: inline video counts against the 25 MB request payload,
+// larger files must be referenced from S3
+const (
+	novaMaxVideoBytes  = 25_000_000
+	maxVideosPerPrompt = 1
+)
+
+// videoFormats maps supported video MIME types to Nova format names
+var videoFormats = map[string]string{
+	"video/mp4":        "mp4",
+	"video/quicktime":  "mov",
+	"video/x-matroska": "mkv",
+	"video/webm":       "webm",
+	"video/x-flv":      "flv",
+	"video/mpeg":       "mpeg",
+	"video/mpg":        "mpg",
+	"video/x-ms-wmv":   "wmv",
+	"video/3gpp":       "three_gp",
+}
+
+// videoExtensions maps video file extensions to Nova format names, for S3
+// parts without a content type
+var videoExtensions = map[string]string{
+	".mp4":  "mp4",
+	".mov":  "mov",
+	".mkv":  "mkv",
+	".webm": "webm",
+	".flv":  "flv",
+	".mpeg": "mpeg",
+	".mpg":  "mpg",
+	".wmv":  "wmv",
+	".3gp":  "three_gp",
+}
+
+// isS3URI reports whether a media URL references an S3 object
+func isS3URI(url string) bool {
+	return strings.HasPrefix(url, "s3://")
+}
+
+// isVideoPart reports whether a part is a video, either inline or in S3. An
+// S3 part without a content type is a video if its key has a video extension.
+func isVideoPart(part *ai.Part) bool {
+	if part.IsVideo() {
+		return true
+	}
+	_, ok := videoExtensions[s3Extension(part)]
+	return ok
+}
+
+// s3Extension returns the lowercase file extension of an S3 media part
+// without a content type, or "" for any other part
+func s3Extension(part *ai.Part) string {
+	if !part.IsMedia() || part.ContentType != "" || !isS3URI(part.Text) {
+		return ""
+	}
+	return strings.ToLower(path.Ext(part.Text))
+}
+
+// videoSource is a video part's format and either its inline data or its S3
+// location
+type videoSource struct {
+	format      string
+	data        *mediaData
+	uri         string
+	bucketOwner string
+}
+
+// parseVideoPart validates a video part. The part holds either inline data or
+// an s3:// URI, in which case the optional "bucketOwner" metadata names the
+// account that owns the bucket.
+func parseVideoPart(part *ai.Part) (*videoSource, error) {
+	if isS3URI(part.Text) {
+		mimeType, _, _ := mime.ParseMediaType(part.ContentType)
+		format, ok := videoFormats[mimeType]
+		if part.ContentType == "" {
+			format, ok = videoExtensions[s3Extension(part)]
+		}
+		if !ok {
+			return nil, fmt.Errorf("unsupported video type %q for %s", part.ContentType, part.Text)
+		}
+
+		source := &videoSource{format: format, uri: part.Text}
+		if owner, ok := part.Metadata["bucketOwner"].(string); ok {
+			source.bucketOwner = owner
+		}
+		return source, nil
+	}
+
+	video, err := decodeMedia(part)
+	if err != nil {
+		return nil, err
+	}
+
+	format, ok := videoFormats[video.mimeType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported video type %q", video.mimeType)
+	}
+
+	// The limit applies to the request payload, which carries the video
+	// base64 encoded
+	if size := base64.StdEncoding.EncodedLen(len(video.data)); size > novaMaxVideoBytes {
+		return nil, fmt.Errorf("video is %d bytes encoded, exceeding the %d byte inline limit; reference it from S3 instead",
+			size, novaMaxVideoBytes)
+	}
+
+	return &videoSource{format: format, data: video}, nil
+}
+
+// novaVideoBlock converts a video part to a Nova video content block
+func novaVideoBlock(part *ai.Part) (map[string]interface{}, error) {
+	video, err := parseVideoPart(part)
+	if err != nil {
+		return nil, err
+	}
+
+	source := map[string]interface{}{}
+	if video.data != nil {
+		source["bytes"] = video.data.base64()
+	} else {
+		location := map[string]interface{}{"uri": video.uri}
+		if video.bucketOwner != "" {
+			location["bucketOwner"] = video.bucketOwner
+		}
+		source["s3Location"] = location
+	}
+
+	return map[string]interface{}{
+		"video": map[string]interface{}{
+			"format": video.format,
+			"source": source,
+		},
+	}, nil
+}
+
+// converseVideoBlock converts a video part to a Converse video content block
+func converseVideoBlock(part *ai.Part) (*types.ContentBlockMemberVideo, error) {
+	video, err := parseVideoPart(part)
+	if err != nil {
+		return nil, err
+	}
+
+	block := types.VideoBlock{Format: types.VideoFormat(video.format)}
+	if video.data != nil {
+		block.Source = &types.VideoSourceMemberBytes{Value: video.data.data}
+	} else {
+		location := types.S3Location{Uri: aws.String(video.uri)}
+		if video.bucketOwner != "" {
+			location.BucketOwner = aws.String(video.bucketOwner)
+		}
+		block.Source = &types.VideoSourceMemberS3Location{Value: location}
+	}
+
+	return &types.ContentBlockMemberVideo{Value: block}, nil
+}
+
+// acceptsVideo reports whether the model accepts video input
+func (m *Model) acceptsVideo() bool {
+	return isNovaModel(m.foundationModel()) && isVisionModel(m.foundationModel())
+}
@@ -0,0 +1,187 @@
+// Copyright 2025 Scott Friedman
+// Licensed under the Apache License, Version 2.0
+
+package bedrock
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNovaVideoBlock(t *testing.T) {
+	s3Part := ai.NewMediaPart("video/mp4", "s3://moderation-inbox/clips/0001.mp4")
+	s3Part.Metadata = map[string]any{"bucketOwner": "111122223333"}
+
+	tests := []struct {
+		name         string
+		part         *ai.Part
+		expected     map[string]interface{}
+		wantErrorMsg string
+	}{
+		{
+			name: "inline data URI",
+			part: ai.NewMediaPart("", "data:video/webm;base64,aGVsbG8="),
+			expected: map[string]interface{}{
+				"video": map[string]interface{}{
+					"format": "webm",
+					"source": map[string]interface{}{"bytes": "aGVsbG8="},
+				},
+			},
+		},
+		{
+			name: "inline 3GPP",
+			part: ai.NewMediaPart("video/3gpp", "aGVsbG8="),
+			expected: map[string]interface{}{
+				"video": map[string]interface{}{
+					"format": "three_gp",
+					"source": map[string]interface{}{"bytes": "aGVsbG8="},
+				},
+			},
+		},
+		{
+			name: "S3 with bucket owner",
+			part: s3Part,
+			expected: map[string]interface{}{
+				"video": map[string]interface{}{
+					"format": "mp4",
+					"source": map[string]interface{}{
+						"s3Location": map[string]interface{}{
+							"uri":         "s3://moderation-inbox/clips/0001.mp4",
+							"bucketOwner": "111122223333",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "S3 without bucket owner",
+			part: ai.NewMediaPart("video/quicktime", "s3://clips/a.mov"),
+			expected: map[string]interface{}{
+				"video": map[string]interface{}{
+					"format": "mov",
+					"source": map[string]interface{}{
+						"s3Location": map[string]interface{}{"uri": "s3://clips/a.mov"},
+					},
+				},
+			},
+		},
+		{
+			name:         "unsupported format",
+			part:         ai.NewMediaPart("video/avi", "aGVsbG8="),
+			wantErrorMsg: "unsupported video type",
+		},
+		{
+			name: "S3 video extension without a type",
+			part: ai.NewMediaPart("", "s3://clips/A.MKV"),
+			expected: map[string]interface{}{
+				"video": map[string]interface{}{
+					"format": "mkv",
+					"source": map[string]interface{}{
+						"s3Location": map[string]interface{}{"uri": "s3://clips/A.MKV"},
+					},
+				},
+			},
+		},
+		{
+			name:         "S3 without a video type",
+			part:         ai.NewMediaPart("", "s3://clips/a.bin"),
+			wantErrorMsg: "unsupported video type",
+		},
+		{
+			name: "inline video over the limit once encoded",
+			part: ai.NewMediaPart("video/mp4",
+				base64.StdEncoding.EncodeToString([]byte(strings.Repeat("x", novaMaxVideoBytes*3/4+1)))),
+			wantErrorMsg: "reference it from S3 instead",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block, err := novaVideoBlock(tt.part)
+			if tt.wantErrorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErrorMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, block)
+		})
+	}
+}
+
+func TestIsVideoPart(t *testing.T) {
+	tests := []struct {
+		name     string
+		part     *ai.Part
+		expected bool
+	}{
+		{"inline video", ai.NewMediaPart("video/mp4", "aGVsbG8="), true},
+		{"video data URI", ai.NewMediaPart("", "data:video/webm;base64,aGVsbG8="), true},
+		{"S3 video", ai.NewMediaPart("video/quicktime", "s3://clips/a"), true},
+		{"S3 video extension", ai.NewMediaPart("", "s3://clips/a.mp4"), true},
+		{"S3 image", ai.NewMediaPart("image/png", "s3://images/a.png"), false},
+		{"S3 document", ai.NewMediaPart("application/pdf", "s3://docs/a.mp4"), false},
+		{"S3 without a type or extension", ai.NewMediaPart("", "s3://clips/a"), false},
+		{"inline image", ai.NewMediaPart("image/png", "aGVsbG8="), false},
+		{"text", ai.NewTextPart("s3://clips/a.mp4"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isVideoPart(tt.part))
+		})
+	}
+}
+
+func TestModel_convertNovaRequest_Video(t *testing.T) {
+	model := &Model{modelID: "amazon.nova-pro-v1:0", config: &ModelConfig{}}
+
+	result, err := model.convertNovaRequest(&ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewUserMessage(
+				ai.NewMediaPart("video/mp4", "s3://moderation-inbox/clips/0001.mp4"),
+				ai.NewTextPart("Does this clip contain violence?"),
+			),
+		},
+	})
+	require.NoError(t, err)
+
+	var novaReq map[string]interface{}
+	require.NoError(t, json.Unmarshal(result, &novaReq))
+	blocks := novaReq["messages"].([]interface{})[0].(map[string]interface{})["content"].([]interface{})
+	require.Len(t, blocks, 2)
+	assert.Contains(t, blocks[0], "video")
+	assert.Equal(t, map[string]interface{}{"text": "Does this clip contain violence?"}, blocks[1])
+}
+
+func TestModel_prepareMedia_Video(t *testing.T) {
+	video := ai.NewMediaPart("video/mp4", "s3://clips/a.mp4")
+
+	t.Run("one video per request", func(t *testing.T) {
+		model := &Model{modelID: "amazon.nova-lite-v1:0", config: &ModelConfig{}}
+		_, err := model.prepareMedia([]*ai.Message{ai.NewUserMessage(video, video)})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "more than 1 video")
+	})
+
+	t.Run("converse", func(t *testing.T) {
+		model := &Model{modelID: "amazon.nova-lite-v1:0", config: &ModelConfig{}, useConverse: true}
+		_, err := model.prepareMedia([]*ai.Message{ai.NewUserMessage(video)})
+		require.NoError(t, err)
+	})
+
+	for _, modelID := range []string{"amazon.nova-micro-v1:0", "anthropic.claude-3-5-sonnet-20241022-v2:0"} {
+		t.Run(modelID, func(t *testing.T) {
+			model := &Model{modelID: modelID, config: &ModelConfig{}}
+			_, err := model.prepareMedia([]*ai.Message{ai.NewUserMessage(video)})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "does not accept video input")
+		})
+	}
+}
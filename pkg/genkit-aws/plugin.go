@@ -80,18 +80,133 @@ func (p *Plugin) DefineModel(g *genkit.Genkit, name string, opts *ai.ModelOption
 
 	if opts == nil {
 		opts = &ai.ModelOptions{
-			Label: fmt.Sprintf("AWS Bedrock - %s", name),
-			Supports: &ai.ModelSupports{
-				Output:     []string{"text"},
-				Tools:      false,
-				Media:      false,
-				Multiturn:  true,
-				SystemRole: true,
-			},
+			Label:    fmt.Sprintf("AWS Bedrock - %s", name),
+			Supports: bedrockModel.Supports(),
 		}
 	}
 
-	return genkit.DefineModel(g, name, opts, bedrockModel.Generate)
+	generate := bedrockModel.Generate
+	if p.monitor != nil {
+		generate = monitored(p.monitor, name, generate)
+	}
+
+	return genkit.DefineModel(g, name, opts, generate)
+}
+
+// DefineEmbedder defines a Bedrock embedder in the given registry
+func (p *Plugin) DefineEmbedder(g *genkit.Genkit, name string, opts *ai.EmbedderOptions) ai.Embedder {
+	if p.bedrock == nil {
+		panic("plugin not initialized or Bedrock not configured")
+	}
+
+	bedrockEmbedder := p.bedrock.Embedder(name)
+
+	if opts == nil {
+		opts = &ai.EmbedderOptions{
+			Label:      fmt.Sprintf("AWS Bedrock - %s", name),
+			Supports:   bedrockEmbedder.Supports(),
+			Dimensions: bedrockEmbedder.Dimensions(),
+		}
+	}
+
+	return genkit.DefineEmbedder(g, name, opts, bedrockEmbedder.Embed)
+}
+
+// DefineRetriever defines a Bedrock knowledge base retriever in the given
+// registry, configured by the RetrieverConfigs entry for name
+func (p *Plugin) DefineRetriever(g *genkit.Genkit, name string, opts *ai.RetrieverOptions) ai.Retriever {
+	if p.bedrock == nil {
+		panic("plugin not initialized or Bedrock not configured")
+	}
+
+	bedrockRetriever := p.bedrock.Retriever(name)
+
+	if opts == nil {
+		opts = &ai.RetrieverOptions{
+			Label:    fmt.Sprintf("AWS Bedrock Knowledge Base - %s", name),
+			Supports: bedrockRetriever.Supports(),
+		}
+	}
+
+	return genkit.DefineRetriever(g, name, opts, bedrockRetriever.Retrieve)
+}
+
+// StartVideo starts generating a video with a Bedrock video model and
+// returns the job's invocation ARN without waiting for it
+func (p *Plugin) StartVideo(ctx context.Context, modelID string, req *ai.ModelRequest) (string, error) {
+	if p.bedrock == nil {
+		return "", fmt.Errorf("plugin not initialized or Bedrock not configured")
+	}
+
+	return p.bedrock.Model(modelID).StartVideo(ctx, req)
+}
+
+// CheckVideo reports on a video job: it returns the video once the job
+// completes, nil while it is running and an error if it failed
+func (p *Plugin) CheckVideo(ctx context.Context, invocationARN string) (*ai.ModelResponse, error) {
+	if p.bedrock == nil {
+		return nil, fmt.Errorf("plugin not initialized or Bedrock not configured")
+	}
+
+	return p.bedrock.CheckVideo(ctx, invocationARN)
+}
+
+// ApplyGuardrail checks text and images against a Bedrock guardrail with the
+// ApplyGuardrail API, so flows can gate content before it reaches a model
+func (p *Plugin) ApplyGuardrail(ctx context.Context, guardrailID, guardrailVersion string, input *bedrock.GuardrailInput) (*bedrock.GuardrailResult, error) {
+	if p.bedrock == nil {
+		return nil, fmt.Errorf("plugin not initialized or Bedrock not configured")
+	}
+
+	return p.bedrock.Guardrail(guardrailID, guardrailVersion).Apply(ctx, input)
+}
+
+// DefineGuardrail defines a tool that checks content against a Bedrock
+// guardrail, for models and the Developer UI
+func (p *Plugin) DefineGuardrail(g *genkit.Genkit, name, guardrailID, guardrailVersion string) ai.Tool {
+	if p.bedrock == nil {
+		panic("plugin not initialized or Bedrock not configured")
+	}
+
+	guardrail := p.bedrock.Guardrail(guardrailID, guardrailVersion)
+
+	return genkit.DefineTool(g, name,
+		"Checks user input (source INPUT) or model and tool output (source OUTPUT) against a content guardrail and reports whether it intervened, the masked output and each policy finding",
+		func(ctx *ai.ToolContext, input *bedrock.GuardrailInput) (*bedrock.GuardrailResult, error) {
+			return guardrail.Apply(ctx, input)
+		})
+}
+
+// modelMonitor records per-model metrics, as the CloudWatch monitor does
+type modelMonitor interface {
+	OnPromptCache(ctx context.Context, modelID string, readTokens, writeTokens int)
+	OnGuardrailIntervened(ctx context.Context, modelID string)
+}
+
+// monitored wraps a model function to report each generation's prompt cache
+// usage and guardrail interventions to the monitor
+func monitored(monitor modelMonitor, modelID string, generate ai.ModelFunc) ai.ModelFunc {
+	return func(ctx context.Context, req *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+		resp, err := generate(ctx, req, cb)
+		if err != nil {
+			return resp, err
+		}
+
+		if bedrock.GuardrailIntervened(resp) {
+			monitor.OnGuardrailIntervened(ctx, modelID)
+		}
+
+		if resp.Usage == nil {
+			return resp, nil
+		}
+
+		usage := resp.Usage
+		monitor.OnPromptCache(ctx, modelID,
+			int(usage.Custom[bedrock.UsageCacheReadInputTokens]),
+			int(usage.Custom[bedrock.UsageCacheWriteInputTokens]))
+
+		return resp, nil
+	}
 }
 
 // GetMonitor returns the CloudWatch monitor instance
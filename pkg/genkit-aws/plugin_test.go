@@ -5,12 +5,85 @@ package genkitaws
 
 import (
 	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/scttfrdmn/genkit-aws/pkg/bedrock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// fakeBedrock is an HTTP client that answers Bedrock API calls with canned
+// JSON bodies by request path and records the requests it receives
+type fakeBedrock struct {
+	responses map[string]string
+	requests  map[string]string
+	headers   map[string]http.Header
+}
+
+func (f *fakeBedrock) Do(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if f.requests == nil {
+		f.requests = map[string]string{}
+		f.headers = map[string]http.Header{}
+	}
+	f.requests[req.URL.Path] = string(body)
+	f.headers[req.URL.Path] = req.Header
+
+	status, response := http.StatusOK, f.responses[req.URL.Path]
+	if response == "" {
+		status, response = http.StatusNotFound, `{"message":"no fake response for `+req.URL.Path+`"}`
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(response)),
+		Request:    req,
+	}, nil
+}
+
+// fakeMonitor records the model metrics it is given
+type fakeMonitor struct {
+	cacheReads    []int
+	cacheWrites   []int
+	interventions []string
+}
+
+func (f *fakeMonitor) OnPromptCache(_ context.Context, _ string, readTokens, writeTokens int) {
+	f.cacheReads = append(f.cacheReads, readTokens)
+	f.cacheWrites = append(f.cacheWrites, writeTokens)
+}
+
+func (f *fakeMonitor) OnGuardrailIntervened(_ context.Context, modelID string) {
+	f.interventions = append(f.interventions, modelID)
+}
+
+// newTestPlugin returns a plugin whose Bedrock client calls the fake
+func newTestPlugin(t *testing.T, fake *fakeBedrock, config *bedrock.Config) *Plugin {
+	awsCfg := aws.Config{
+		Region: "us-east-1",
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"}, nil
+		}),
+		HTTPClient: fake,
+	}
+
+	client, err := bedrock.NewClient(context.Background(), awsCfg, config)
+	require.NoError(t, err)
+
+	return &Plugin{config: &Config{Region: "us-east-1", Bedrock: config}, bedrock: client}
+}
+
 func TestNew(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -134,3 +207,201 @@ func TestPlugin_Init(t *testing.T) {
 	// Verify plugin is properly initialized
 	assert.NotNil(t, plugin.config)
 }
+
+func TestPlugin_DefineEmbedder(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeBedrock{responses: map[string]string{
+		"/model/amazon.titan-embed-text-v2:0/invoke": `{"embedding":[0.1,0.2],"inputTextTokenCount":2}`,
+	}}
+	plugin := newTestPlugin(t, fake, &bedrock.Config{
+		EmbedderConfigs: map[string]*bedrock.EmbedderConfig{
+			"amazon.titan-embed-text-v2:0": {Dimensions: 256},
+		},
+	})
+
+	g := genkit.Init(ctx)
+	embedder := plugin.DefineEmbedder(g, "amazon.titan-embed-text-v2:0", nil)
+	assert.NotNil(t, genkit.LookupEmbedder(g, "amazon.titan-embed-text-v2:0"))
+
+	resp, err := genkit.Embed(ctx, g,
+		ai.WithEmbedder(embedder),
+		ai.WithTextDocs("hello"),
+	)
+	require.NoError(t, err)
+	require.Len(t, resp.Embeddings, 1)
+	assert.Equal(t, []float32{0.1, 0.2}, resp.Embeddings[0].Embedding)
+	assert.JSONEq(t, `{"inputText":"hello","dimensions":256}`,
+		fake.requests["/model/amazon.titan-embed-text-v2:0/invoke"])
+
+	assert.Panics(t, func() {
+		(&Plugin{}).DefineEmbedder(g, "amazon.titan-embed-text-v2:0", nil)
+	})
+}
+
+// guardrailResponse is an ApplyGuardrail response that blocks violent content
+const guardrailResponse = `{
+	"action": "GUARDRAIL_INTERVENED",
+	"outputs": [{"text": "Sorry, I can't help with that."}],
+	"assessments": [{"contentPolicy": {"filters": [
+		{"type": "VIOLENCE", "confidence": "HIGH", "action": "BLOCKED", "detected": true}
+	]}}]
+}`
+
+func TestPlugin_ApplyGuardrail(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeBedrock{responses: map[string]string{
+		"/guardrail/gr-moderation/version/2/apply": guardrailResponse,
+	}}
+	plugin := newTestPlugin(t, fake, &bedrock.Config{})
+
+	result, err := plugin.ApplyGuardrail(ctx, "gr-moderation", "2", &bedrock.GuardrailInput{
+		Text: []string{"How do I hurt someone?"},
+	})
+	require.NoError(t, err)
+	assert.True(t, result.Intervened)
+	assert.Equal(t, []string{"Sorry, I can't help with that."}, result.Outputs)
+	require.Len(t, result.Assessments, 1)
+	assert.Equal(t, bedrock.GuardrailPolicyContent, result.Assessments[0].Policy)
+	assert.Equal(t, "VIOLENCE", result.Assessments[0].Type)
+	assert.Contains(t, fake.requests["/guardrail/gr-moderation/version/2/apply"], "How do I hurt someone?")
+
+	_, err = (&Plugin{}).ApplyGuardrail(ctx, "gr-moderation", "2", &bedrock.GuardrailInput{Text: []string{"hi"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not initialized")
+}
+
+func TestPlugin_DefineGuardrail(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeBedrock{responses: map[string]string{
+		"/guardrail/gr-moderation/version/2/apply": guardrailResponse,
+	}}
+	plugin := newTestPlugin(t, fake, &bedrock.Config{})
+
+	g := genkit.Init(ctx)
+	tool := plugin.DefineGuardrail(g, "moderate", "gr-moderation", "2")
+	assert.Equal(t, "moderate", tool.Name())
+	assert.NotNil(t, genkit.LookupTool(g, "moderate"))
+
+	output, err := tool.RunRaw(ctx, map[string]any{
+		"source": bedrock.GuardrailSourceOutput,
+		"text":   []string{"Here is how to hurt someone"},
+	})
+	require.NoError(t, err)
+	// Tools return their output as JSON
+	result, ok := output.(map[string]any)
+	require.True(t, ok, "output is %T", output)
+	assert.Equal(t, true, result["intervened"])
+	assert.Contains(t, fake.requests["/guardrail/gr-moderation/version/2/apply"], `"source":"OUTPUT"`)
+
+	assert.Panics(t, func() {
+		(&Plugin{}).DefineGuardrail(g, "moderate", "gr-moderation", "2")
+	})
+}
+
+func TestPlugin_DefineRetriever(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeBedrock{responses: map[string]string{
+		"/knowledgebases/KB12345678/retrieve": `{"retrievalResults": [{
+			"content": {"type": "TEXT", "text": "Staff get 25 days of leave."},
+			"location": {"type": "S3", "s3Location": {"uri": "s3://handbook/leave.pdf"}},
+			"score": 0.82
+		}]}`,
+	}}
+	plugin := newTestPlugin(t, fake, &bedrock.Config{
+		RetrieverConfigs: map[string]*bedrock.RetrieverConfig{
+			"handbook": {KnowledgeBaseID: "KB12345678", NumberOfResults: 3},
+		},
+	})
+
+	g := genkit.Init(ctx)
+	retriever := plugin.DefineRetriever(g, "handbook", nil)
+	assert.NotNil(t, genkit.LookupRetriever(g, "handbook"))
+
+	resp, err := genkit.Retrieve(ctx, g,
+		ai.WithRetriever(retriever),
+		ai.WithTextDocs("How much leave do I get?"),
+	)
+	require.NoError(t, err)
+	require.Len(t, resp.Documents, 1)
+	doc := resp.Documents[0]
+	assert.Equal(t, "Staff get 25 days of leave.", doc.Content[0].Text)
+	assert.Equal(t, "s3://handbook/leave.pdf", doc.Metadata[bedrock.MetadataSource])
+	assert.InDelta(t, 0.82, doc.Metadata[bedrock.MetadataScore], 1e-6)
+	assert.JSONEq(t, `{
+		"retrievalQuery": {"text": "How much leave do I get?"},
+		"retrievalConfiguration": {"vectorSearchConfiguration": {"numberOfResults": 3}}
+	}`, fake.requests["/knowledgebases/KB12345678/retrieve"])
+
+	assert.Panics(t, func() {
+		(&Plugin{}).DefineRetriever(g, "handbook", nil)
+	})
+}
+
+func TestMonitored_PromptCache(t *testing.T) {
+	ctx := context.Background()
+	usage := &ai.GenerationUsage{Custom: map[string]float64{
+		bedrock.UsageCacheReadInputTokens:  900,
+		bedrock.UsageCacheWriteInputTokens: 100,
+	}}
+
+	monitor := &fakeMonitor{}
+	generate := monitored(monitor, "anthropic.claude-3-7-sonnet-20250219-v1:0",
+		func(context.Context, *ai.ModelRequest, ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			return &ai.ModelResponse{Usage: usage}, nil
+		})
+
+	resp, err := generate(ctx, &ai.ModelRequest{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, usage, resp.Usage)
+	assert.Equal(t, []int{900}, monitor.cacheReads)
+	assert.Equal(t, []int{100}, monitor.cacheWrites)
+	assert.Empty(t, monitor.interventions)
+
+	// Failed generations and responses without usage record nothing
+	monitor = &fakeMonitor{}
+	_, err = monitored(monitor, "anthropic.claude-3-7-sonnet-20250219-v1:0",
+		func(context.Context, *ai.ModelRequest, ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			return nil, errors.New("throttled")
+		})(ctx, &ai.ModelRequest{}, nil)
+	require.Error(t, err)
+	_, err = monitored(monitor, "anthropic.claude-3-7-sonnet-20250219-v1:0",
+		func(context.Context, *ai.ModelRequest, ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			return &ai.ModelResponse{}, nil
+		})(ctx, &ai.ModelRequest{}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, monitor.cacheReads)
+}
+
+func TestMonitored_Guardrail(t *testing.T) {
+	ctx := context.Background()
+	const modelID = "anthropic.claude-3-5-sonnet-20241022-v2:0"
+	fake := &fakeBedrock{responses: map[string]string{
+		"/model/" + modelID + "/invoke": `{
+			"content": [{"type": "text", "text": "Sorry, I can't help with that."}],
+			"stop_reason": "end_turn",
+			"usage": {"input_tokens": 12, "output_tokens": 8},
+			"amazon-bedrock-guardrailAction": "INTERVENED"
+		}`,
+	}}
+	plugin := newTestPlugin(t, fake, &bedrock.Config{
+		Models: []string{modelID},
+		ModelConfigs: map[string]*bedrock.ModelConfig{
+			modelID: {MaxTokens: 256, GuardrailID: "gr-compliance", GuardrailVersion: "3"},
+		},
+	})
+
+	g := genkit.Init(ctx)
+	model := plugin.DefineModel(g, modelID, nil)
+	resp, err := genkit.Generate(ctx, g, ai.WithModel(model), ai.WithPrompt("Which stocks should I buy?"))
+	require.NoError(t, err)
+	assert.Equal(t, ai.FinishReasonBlocked, resp.FinishReason)
+	header := fake.headers["/model/"+modelID+"/invoke"]
+	assert.Equal(t, "gr-compliance", header.Get("X-Amzn-Bedrock-GuardrailIdentifier"))
+	assert.Equal(t, "3", header.Get("X-Amzn-Bedrock-GuardrailVersion"))
+
+	monitor := &fakeMonitor{}
+	generate := monitored(monitor, modelID, plugin.bedrock.Model(modelID).Generate)
+	_, err = generate(ctx, &ai.ModelRequest{Messages: []*ai.Message{ai.NewUserTextMessage("Which stocks should I buy?")}}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{modelID}, monitor.interventions)
+}
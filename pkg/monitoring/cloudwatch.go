@@ -119,6 +119,35 @@ func (cw *CloudWatch) OnGenerate(ctx context.Context, modelID string, tokensUsed
 	cw.putMetric(ctx, "GenerationCount", 1.0, dimensions)
 }
 
+// OnPromptCache is called for each model generation that read from or wrote
+// to the prompt cache
+func (cw *CloudWatch) OnPromptCache(ctx context.Context, modelID string, readTokens, writeTokens int) {
+	if !cw.config.EnableModelMetrics || (readTokens == 0 && writeTokens == 0) {
+		return
+	}
+
+	dimensions := cw.buildDimensions(map[string]string{
+		"ModelID": modelID,
+	})
+
+	cw.putMetric(ctx, "CacheReadInputTokens", float64(readTokens), dimensions)
+	cw.putMetric(ctx, "CacheWriteInputTokens", float64(writeTokens), dimensions)
+}
+
+// OnGuardrailIntervened is called for each model generation in which a
+// guardrail blocked or masked content
+func (cw *CloudWatch) OnGuardrailIntervened(ctx context.Context, modelID string) {
+	if !cw.config.EnableModelMetrics {
+		return
+	}
+
+	dimensions := cw.buildDimensions(map[string]string{
+		"ModelID": modelID,
+	})
+
+	cw.putMetric(ctx, "GuardrailIntervened", 1.0, dimensions)
+}
+
 // putMetric adds a metric to the buffer
 func (cw *CloudWatch) putMetric(ctx context.Context, metricName string, value float64, dimensions []types.Dimension) {
 	metric := types.MetricDatum{
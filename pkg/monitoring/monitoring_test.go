@@ -4,9 +4,12 @@
 package monitoring
 
 import (
+	"context"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestConfig_Validate(t *testing.T) {
@@ -275,6 +278,62 @@ func TestContains(t *testing.T) {
 	}
 }
 
+func TestCloudWatch_OnPromptCache(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      *Config
+		read, write int
+		wantMetrics map[string]float64
+	}{
+		{
+			name:        "cache read and write",
+			config:      &Config{EnableModelMetrics: true, MetricBufferSize: 10},
+			read:        1200,
+			write:       300,
+			wantMetrics: map[string]float64{"CacheReadInputTokens": 1200, "CacheWriteInputTokens": 300},
+		},
+		{
+			name:        "no cache usage",
+			config:      &Config{EnableModelMetrics: true, MetricBufferSize: 10},
+			wantMetrics: map[string]float64{},
+		},
+		{
+			name:        "model metrics disabled",
+			config:      &Config{EnableFlowMetrics: true, MetricBufferSize: 10},
+			read:        1200,
+			wantMetrics: map[string]float64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cw := &CloudWatch{config: tt.config}
+			cw.OnPromptCache(context.Background(), "anthropic.claude-3-5-sonnet-20241022-v2:0", tt.read, tt.write)
+
+			metrics := map[string]float64{}
+			for _, datum := range cw.metricBuffer {
+				metrics[aws.ToString(datum.MetricName)] = aws.ToFloat64(datum.Value)
+				assert.Equal(t, "ModelID", aws.ToString(datum.Dimensions[0].Name))
+			}
+			assert.Equal(t, tt.wantMetrics, metrics)
+		})
+	}
+}
+
+func TestCloudWatch_OnGuardrailIntervened(t *testing.T) {
+	cw := &CloudWatch{config: &Config{EnableModelMetrics: true, MetricBufferSize: 10}}
+	cw.OnGuardrailIntervened(context.Background(), "amazon.nova-pro-v1:0")
+
+	require.Len(t, cw.metricBuffer, 1)
+	assert.Equal(t, "GuardrailIntervened", aws.ToString(cw.metricBuffer[0].MetricName))
+	assert.Equal(t, 1.0, aws.ToFloat64(cw.metricBuffer[0].Value))
+	assert.Equal(t, "amazon.nova-pro-v1:0", aws.ToString(cw.metricBuffer[0].Dimensions[0].Value))
+
+	disabled := &CloudWatch{config: &Config{EnableFlowMetrics: true, MetricBufferSize: 10}}
+	disabled.OnGuardrailIntervened(context.Background(), "amazon.nova-pro-v1:0")
+	assert.Empty(t, disabled.metricBuffer)
+}
+
 // testError is a simple error implementation for testing
 type testError struct {
 	message string